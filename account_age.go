@@ -0,0 +1,109 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// accountAge works out how old a Discord account is from its snowflake ID
+func accountAge(userID string, now time.Time) time.Duration {
+	created, err := discordgo.SnowflakeTimestamp(userID)
+	if err != nil {
+		return 0
+	}
+	return now.Sub(created)
+}
+
+// isNewAccount reports whether the account is younger than the threshold.
+// A threshold of 0 turns the check off.
+func isNewAccount(userID string, threshold time.Duration, now time.Time) bool {
+	return threshold > 0 && accountAge(userID, now) < threshold
+}
+
+// denyNewAccount removes a member whose account is younger than the guild's
+// hard cutoff before they can take up moderators' time.
+func denyNewAccount(s discordSession, guildID, userID string, serverConfig ServerConfig) {
+	logger := slog.With("guild_id", guildID, "user_id", userID)
+
+	err := sendDM(s, userID, "Sorry, your Discord account is too new to join this server. You're welcome to try again once it's a little older.")
+	if err != nil {
+		logger.Warn("Could not DM new account before kicking", "error", err)
+	}
+
+	err = withRetry(func() error { return memberKick(s, guildID, userID) })
+	if err != nil && !hasErrorCode(err, discordgo.ErrCodeUnknownMember) {
+		logger.Error("Error kicking new account", "error", err)
+		return
+	}
+	logger.Info("Kicked account below the minimum age", "cutoff", serverConfig.AccountAgeCutoff)
+
+	if serverConfig.MemberAuditChannelID == "" {
+		return
+	}
+	_, err = sendAuditMessage(s, serverConfig, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("👢 <@%s> was kicked on joining because their account is younger than %s.", userID, serverConfig.AccountAgeCutoff),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		logger.Error("Error logging new account kick", "error", err)
+	}
+}
+
+func setAccountAge(s discordSession, i *discordgo.InteractionCreate) {
+	var flagDays, denyDays int64
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "flag_days":
+			flagDays = option.IntValue()
+		case "deny_days":
+			denyDays = option.IntValue()
+		}
+	}
+	guildID := i.GuildID
+
+	if flagDays < 0 || denyDays < 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The account ages cannot be negative",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.MinAccountAge = time.Duration(flagDays) * 24 * time.Hour
+	serverConfig.AccountAgeCutoff = time.Duration(denyDays) * 24 * time.Hour
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "New accounts are no longer flagged! :white_check_mark:"
+	if flagDays > 0 {
+		content = fmt.Sprintf("Accounts younger than %d days will be flagged on verification requests! :white_check_mark:", flagDays)
+	}
+	if denyDays > 0 {
+		content += fmt.Sprintf("\nAccounts younger than %d days will be kicked when they join.", denyDays)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
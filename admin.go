@@ -0,0 +1,28 @@
+package main
+
+import "github.com/bwmarrin/discordgo"
+
+// Hides config-changing commands from non-administrators in Discord's UI
+var adminPermission int64 = discordgo.PermissionAdministrator
+
+func isAdministrator(i *discordgo.InteractionCreate) bool {
+	return i.Member != nil && i.Member.Permissions&discordgo.PermissionAdministrator != 0
+}
+
+// adminOnly wraps a command handler so it only runs for administrators,
+// backing up the command's default permissions.
+func adminOnly(handler func(s discordSession, i *discordgo.InteractionCreate)) func(s discordSession, i *discordgo.InteractionCreate) {
+	return func(s discordSession, i *discordgo.InteractionCreate) {
+		if !isAdministrator(i) {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: "You need administrator permission to use this command.",
+					Flags:   discordgo.MessageFlagsEphemeral,
+				},
+			})
+			return
+		}
+		handler(s, i)
+	}
+}
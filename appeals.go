@@ -0,0 +1,194 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Denied members with appeals enabled stay in the server this long so they
+// can ask for another look before being kicked. Kicks still waiting when the
+// bot restarts are dropped; the verification timeout catches those members.
+var appealGracePeriod = 15 * time.Minute
+
+// Appeals each member gets per guild
+const maxAppeals = 1
+
+var (
+	appealKicks  = make(map[string]*time.Timer)
+	appealCounts = make(map[string]int)
+	appealsLock  sync.Mutex
+)
+
+// appealAvailable reports whether the member may still appeal a denial
+func appealAvailable(guildID, userID string) bool {
+	appealsLock.Lock()
+	defer appealsLock.Unlock()
+	return appealCounts[rateLimitKey(guildID, userID)] < maxAppeals
+}
+
+// offerAppeal sends the denial DM with a Request Review button and holds off
+// the kick for the grace period. If the DM can't be delivered nothing is
+// scheduled and the caller should kick straight away.
+func offerAppeal(s discordSession, guildID, userID, denial string) error {
+	err := sendDMComplex(s, userID, &discordgo.MessageSend{
+		Content: denial + fmt.Sprintf("\n\nThink this was a mistake? You have %d minutes to ask the moderators to take another look.", int(appealGracePeriod/time.Minute)),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Request Review",
+						Style:    discordgo.PrimaryButton,
+						CustomID: encodeCustomID("appeal", guildID),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		return err
+	}
+
+	key := rateLimitKey(guildID, userID)
+	appealsLock.Lock()
+	appealKicks[key] = time.AfterFunc(appealGracePeriod, func() {
+		appealsLock.Lock()
+		delete(appealKicks, key)
+		appealsLock.Unlock()
+
+		err := withRetry(func() error { return memberKick(s, guildID, userID) })
+		if err != nil && !hasErrorCode(err, discordgo.ErrCodeUnknownMember) {
+			slog.Error("Error kicking user after appeal window", "guild_id", guildID, "user_id", userID, "error", err)
+			return
+		}
+		resetDenialRetries(guildID, userID)
+	})
+	appealsLock.Unlock()
+	return nil
+}
+
+// lastDecisionEmail finds the email from the member's most recent decision
+func lastDecisionEmail(guildID, userID string) string {
+	verificationLogLock.Lock()
+	defer verificationLogLock.Unlock()
+
+	for idx := len(verificationLog) - 1; idx >= 0; idx-- {
+		entry := verificationLog[idx]
+		if entry.GuildID == guildID && entry.UserID == userID {
+			return entry.Email
+		}
+	}
+	return ""
+}
+
+// handleAppeal re-posts a denied member's request to the audit channel when
+// they press Request Review in their denial DM.
+func handleAppeal(s discordSession, i *discordgo.InteractionCreate, guildID string) {
+	userID := interactionUserID(i)
+	logger := slog.With("guild_id", guildID, "user_id", userID)
+
+	respond := func(content string) {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+	}
+
+	key := rateLimitKey(guildID, userID)
+	appealsLock.Lock()
+	timer, waiting := appealKicks[key]
+	// Check before stopping the timer, so the kick still goes ahead
+	if waiting && appealCounts[key] >= maxAppeals {
+		appealsLock.Unlock()
+		respond("You've already requested a review.")
+		return
+	}
+	if !waiting || !timer.Stop() {
+		appealsLock.Unlock()
+		respond("Sorry, the time to request a review has passed.")
+		return
+	}
+	delete(appealKicks, key)
+	appealCounts[key]++
+	appealsLock.Unlock()
+
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	email := lastDecisionEmail(guildID, userID)
+	_, err := sendAuditMessage(s, serverConfig, &discordgo.MessageSend{
+		Content: fmt.Sprintf("⚖️ **APPEAL** - <@%s> was denied and has asked for their request to be reviewed again. Email: %s", userID, email),
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Approve",
+						Style:    discordgo.SuccessButton,
+						CustomID: encodeCustomID("approve", userID),
+					},
+					discordgo.Button{
+						Label:    "Deny",
+						Style:    discordgo.DangerButton,
+						CustomID: encodeCustomID("deny", userID),
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		logger.Error("Error posting appeal to audit channel", "error", err)
+		respond("Sorry, your request couldn't be sent to the moderators. Please contact a moderator directly.")
+		return
+	}
+	addPendingVerification(guildID, userID, email)
+	logger.Info("Denied user appealed")
+
+	// Stop the button being pressed again
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    i.ChannelID,
+		ID:         i.Message.ID,
+		Components: &[]discordgo.MessageComponent{},
+	})
+	if err != nil {
+		logger.Error("Error editing denial DM", "error", err)
+	}
+
+	respond("Your request has been sent to the moderators. You'll hear back once they've looked at it again.")
+}
+
+func setAppeals(s discordSession, i *discordgo.InteractionCreate) {
+	enabled := i.ApplicationCommandData().Options[0].BoolValue()
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.AppealsEnabled = enabled
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Appeals disabled successfully! :white_check_mark:"
+	if enabled {
+		content = fmt.Sprintf("Appeals enabled successfully! :white_check_mark: Denied members get %d minutes to request a review before they're kicked.", int(appealGracePeriod/time.Minute))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
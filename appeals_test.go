@@ -0,0 +1,150 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func appealInteraction(guildID, userID string) *discordgo.InteractionCreate {
+	return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionMessageComponent,
+		ChannelID: "dm-" + userID,
+		Message:   &discordgo.Message{ID: "denial"},
+		User:      &discordgo.User{ID: userID},
+		Data:      discordgo.MessageComponentInteractionData{CustomID: encodeCustomID("appeal", guildID)},
+	}}
+}
+
+func TestAppeals(t *testing.T) {
+	const guildID = "100000000000000017"
+	useServerConfig(t, guildID, ServerConfig{MemberAuditChannelID: "audit", AppealsEnabled: true})
+
+	cleanup := func(userID string) {
+		t.Cleanup(func() {
+			key := rateLimitKey(guildID, userID)
+			appealsLock.Lock()
+			if timer, ok := appealKicks[key]; ok {
+				timer.Stop()
+			}
+			delete(appealKicks, key)
+			delete(appealCounts, key)
+			appealsLock.Unlock()
+			removePendingVerification(guildID, userID)
+		})
+	}
+
+	t.Run("offer holds off the kick", func(t *testing.T) {
+		const userID = "200000000000000094"
+		cleanup(userID)
+
+		s := &fakeSession{}
+		if err := offerAppeal(s, guildID, userID, "You've been denied."); err != nil {
+			t.Fatal(err)
+		}
+
+		if len(s.sent) != 1 || s.sent[0].ChannelID != "dm-"+userID {
+			t.Fatalf("want the denial DM sent, got calls %q", s.calls)
+		}
+		row := s.sent[0].Data.Components[0].(discordgo.ActionsRow)
+		if button := row.Components[0].(discordgo.Button); button.CustomID != encodeCustomID("appeal", guildID) {
+			t.Errorf("button = %q, want the appeal button", button.CustomID)
+		}
+		appealsLock.Lock()
+		_, waiting := appealKicks[rateLimitKey(guildID, userID)]
+		appealsLock.Unlock()
+		if !waiting || s.called("GuildMemberDelete "+guildID+" "+userID) {
+			t.Errorf("want the kick scheduled, not done (calls %q)", s.calls)
+		}
+	})
+
+	t.Run("appeal goes to the moderators", func(t *testing.T) {
+		const userID = "200000000000000095"
+		cleanup(userID)
+
+		s := &fakeSession{}
+		if err := offerAppeal(s, guildID, userID, "You've been denied."); err != nil {
+			t.Fatal(err)
+		}
+		handleAppeal(s, appealInteraction(guildID, userID), guildID)
+
+		if !s.called("ChannelMessageSend audit") {
+			t.Errorf("appeal not posted to the audit channel (calls %q)", s.calls)
+		}
+		if !s.called("InteractionResponseEdit Your request has been sent to the moderators. You'll hear back once they've looked at it again.") {
+			t.Errorf("missing confirmation (calls %q)", s.calls)
+		}
+		if _, ok := removePendingVerification(guildID, userID); !ok {
+			t.Errorf("appeal not added back to the pending requests")
+		}
+		appealsLock.Lock()
+		_, waiting := appealKicks[rateLimitKey(guildID, userID)]
+		appealsLock.Unlock()
+		if waiting {
+			t.Errorf("kick still scheduled after appealing")
+		}
+	})
+
+	t.Run("second appeal leaves the kick scheduled", func(t *testing.T) {
+		const userID = "200000000000000096"
+		cleanup(userID)
+
+		appealsLock.Lock()
+		appealCounts[rateLimitKey(guildID, userID)] = maxAppeals
+		appealsLock.Unlock()
+
+		s := &fakeSession{}
+		if err := offerAppeal(s, guildID, userID, "You've been denied."); err != nil {
+			t.Fatal(err)
+		}
+		handleAppeal(s, appealInteraction(guildID, userID), guildID)
+
+		if !s.called("InteractionResponseEdit You've already requested a review.") {
+			t.Errorf("missing refusal (calls %q)", s.calls)
+		}
+		if s.called("ChannelMessageSend audit") {
+			t.Errorf("appeal posted to the audit channel again")
+		}
+		appealsLock.Lock()
+		timer, waiting := appealKicks[rateLimitKey(guildID, userID)]
+		appealsLock.Unlock()
+		if !waiting || !timer.Stop() {
+			t.Errorf("kick no longer scheduled")
+		}
+	})
+
+	t.Run("expiry kicks the member", func(t *testing.T) {
+		const userID = "200000000000000097"
+		cleanup(userID)
+
+		previous := appealGracePeriod
+		appealGracePeriod = 10 * time.Millisecond
+		t.Cleanup(func() { appealGracePeriod = previous })
+
+		s := &fakeSession{}
+		if err := offerAppeal(s, guildID, userID, "You've been denied."); err != nil {
+			t.Fatal(err)
+		}
+
+		kick := "GuildMemberDelete " + guildID + " " + userID
+		deadline := time.Now().Add(time.Second)
+		for !s.called(kick) && time.Now().Before(deadline) {
+			time.Sleep(5 * time.Millisecond)
+		}
+		if !s.called(kick) {
+			t.Fatalf("member not kicked after the appeal window (calls %q)", s.calls)
+		}
+
+		handleAppeal(s, appealInteraction(guildID, userID), guildID)
+		for _, call := range s.calls {
+			if strings.HasPrefix(call, "InteractionResponseEdit ") && call != "InteractionResponseEdit Sorry, the time to request a review has passed." {
+				t.Errorf("late appeal got %q", call)
+			}
+		}
+		if !s.called("InteractionResponseEdit Sorry, the time to request a review has passed.") {
+			t.Errorf("late appeal wasn't refused (calls %q)", s.calls)
+		}
+	})
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"testing"
+)
+
+// tempFilesIn lists leftover temporary files from writeFileAtomic in dir
+func tempFilesIn(t *testing.T, dir string) []string {
+	t.Helper()
+	matches, err := filepath.Glob(filepath.Join(dir, "*.tmp*"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	return matches
+}
+
+func TestWriteFileAtomic(t *testing.T) {
+	dir := t.TempDir()
+	path := filepath.Join(dir, "data.json")
+
+	if err := os.WriteFile(path, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+	if err := writeFileAtomic(path, []byte("new"), 0644); err != nil {
+		t.Fatalf("writeFileAtomic() error = %v", err)
+	}
+
+	data, err := os.ReadFile(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if string(data) != "new" {
+		t.Errorf("file = %q, want %q", data, "new")
+	}
+	if leftover := tempFilesIn(t, dir); len(leftover) != 0 {
+		t.Errorf("temporary files left behind: %v", leftover)
+	}
+}
+
+func TestWriteFileAtomicFailure(t *testing.T) {
+	dir := t.TempDir()
+
+	// Renaming over a non-empty directory fails, but only after the
+	// temporary file has been written
+	path := filepath.Join(dir, "data.json")
+	original := filepath.Join(path, "original")
+	if err := os.MkdirAll(path, 0755); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(original, []byte("old"), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	if err := writeFileAtomic(path, []byte("new"), 0644); err == nil {
+		t.Fatal("writeFileAtomic() succeeded, want an error")
+	}
+
+	data, err := os.ReadFile(original)
+	if err != nil {
+		t.Fatalf("original gone after a failed write: %v", err)
+	}
+	if string(data) != "old" {
+		t.Errorf("original = %q, want it untouched", data)
+	}
+	if leftover := tempFilesIn(t, dir); len(leftover) != 0 {
+		t.Errorf("temporary files left behind: %v", leftover)
+	}
+}
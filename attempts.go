@@ -0,0 +1,228 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Invalid submissions per member, reset once they send a valid one. Counts
+// live in memory only, so a restart gives everyone a clean slate.
+var (
+	failedAttempts     = make(map[string]int)
+	failedAttemptsLock sync.Mutex
+)
+
+// recordFailedAttempt counts an invalid submission and reports whether the
+// member has now used up the guild's allowance. A limit of 0 never kicks.
+func recordFailedAttempt(guildID, userID string, limit int) bool {
+	if limit <= 0 {
+		return false
+	}
+
+	failedAttemptsLock.Lock()
+	defer failedAttemptsLock.Unlock()
+
+	key := rateLimitKey(guildID, userID)
+	failedAttempts[key]++
+	if failedAttempts[key] < limit {
+		return false
+	}
+	delete(failedAttempts, key)
+	return true
+}
+
+func resetFailedAttempts(guildID, userID string) {
+	failedAttemptsLock.Lock()
+	delete(failedAttempts, rateLimitKey(guildID, userID))
+	failedAttemptsLock.Unlock()
+}
+
+// Longest cooldown /set_attempt_cooldown accepts
+const maxAttemptCooldownMinutes = 1440
+
+// Sent when a member uses up their attempts, unless the guild sets its own.
+// It's deliberately different from the normal cooldown message so members
+// know why they're waiting.
+const defaultAttemptCooldownMessage = "You've sent too many invalid verification attempts. Please wait {remaining}, then try again with your university email."
+
+// When each member's attempt cooldown ends, keyed like failedAttempts and
+// guarded by failedAttemptsLock
+var attemptCooldowns = make(map[string]time.Time)
+
+func startAttemptCooldown(guildID, userID string, cooldown time.Duration, now time.Time) {
+	failedAttemptsLock.Lock()
+	defer failedAttemptsLock.Unlock()
+
+	// Drop finished cooldowns so the map doesn't grow forever
+	for key, until := range attemptCooldowns {
+		if !now.Before(until) {
+			delete(attemptCooldowns, key)
+		}
+	}
+	attemptCooldowns[rateLimitKey(guildID, userID)] = now.Add(cooldown)
+}
+
+// attemptCooldownRemaining returns how long the member must still wait after
+// using up their attempts, or zero if they aren't in a cooldown.
+func attemptCooldownRemaining(guildID, userID string, now time.Time) time.Duration {
+	failedAttemptsLock.Lock()
+	defer failedAttemptsLock.Unlock()
+
+	until, exists := attemptCooldowns[rateLimitKey(guildID, userID)]
+	if !exists || !now.Before(until) {
+		return 0
+	}
+	return until.Sub(now)
+}
+
+func attemptCooldownMessage(template string, remaining time.Duration) string {
+	if template == "" {
+		template = defaultAttemptCooldownMessage
+	}
+	return strings.ReplaceAll(template, "{remaining}", formatRemaining(remaining))
+}
+
+// handleAttemptsUsedUp deals with a member who has just used up their
+// attempts, putting them in a cooldown if the guild has one and kicking them
+// otherwise.
+func handleAttemptsUsedUp(s discordSession, m *discordgo.MessageCreate, guildID string, serverConfig ServerConfig) {
+	if serverConfig.AttemptCooldown <= 0 {
+		kickForFailedAttempts(s, m, guildID, serverConfig)
+		return
+	}
+
+	slog.Info("Member used up their invalid attempts, starting cooldown", "guild_id", guildID, "user_id", m.Author.ID, "cooldown", serverConfig.AttemptCooldown)
+	startAttemptCooldown(guildID, m.Author.ID, serverConfig.AttemptCooldown, time.Now())
+	replyToSubmission(s, m, attemptCooldownMessage(serverConfig.AttemptCooldownMessage, serverConfig.AttemptCooldown))
+}
+
+// kickForFailedAttempts removes a member who kept sending invalid
+// submissions and leaves a note in the audit channel.
+func kickForFailedAttempts(s discordSession, m *discordgo.MessageCreate, guildID string, serverConfig ServerConfig) {
+	logger := slog.With("guild_id", guildID, "user_id", m.Author.ID)
+
+	replyToSubmission(s, m, "You've been removed from the server for sending too many invalid verification attempts. You're welcome to rejoin and try again with your university email.")
+
+	err := withRetry(func() error { return memberKick(s, guildID, m.Author.ID) })
+	if err != nil && !hasErrorCode(err, discordgo.ErrCodeUnknownMember) {
+		logger.Error("Error kicking user after too many invalid attempts", "error", err)
+		return
+	}
+	logger.Info("Kicked user after too many invalid attempts", "max_attempts", serverConfig.MaxAttempts)
+
+	if serverConfig.MemberAuditChannelID == "" {
+		return
+	}
+	_, err = sendAuditMessage(s, serverConfig, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("👢 <@%s> was kicked after %d invalid verification attempts.", m.Author.ID, serverConfig.MaxAttempts),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		logger.Error("Error logging invalid attempts kick", "error", err)
+	}
+}
+
+func setMaxAttempts(s discordSession, i *discordgo.InteractionCreate) {
+	limit := i.ApplicationCommandData().Options[0].IntValue()
+	guildID := i.GuildID
+
+	if limit < 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The limit cannot be negative",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.MaxAttempts = int(limit)
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Members are no longer kicked for invalid attempts! :white_check_mark:"
+	if limit > 0 && serverConfig.AttemptCooldown > 0 {
+		content = fmt.Sprintf("Members will be put in a %s cooldown after %d invalid verification attempts! :white_check_mark:", serverConfig.AttemptCooldown, limit)
+	} else if limit > 0 {
+		content = fmt.Sprintf("Members will be kicked after %d invalid verification attempts! :white_check_mark:", limit)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func setAttemptCooldown(s discordSession, i *discordgo.InteractionCreate) {
+	var minutes int64
+	var message string
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "minutes":
+			minutes = option.IntValue()
+		case "message":
+			message = strings.TrimSpace(option.StringValue())
+		}
+	}
+	guildID := i.GuildID
+
+	if minutes < 0 || minutes > maxAttemptCooldownMinutes {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("The cooldown must be between 0 and %d minutes", maxAttemptCooldownMinutes),
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.AttemptCooldown = time.Duration(minutes) * time.Minute
+	serverConfig.AttemptCooldownMessage = message
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Members who use up their invalid attempts will be kicked again! :white_check_mark:"
+	if minutes > 0 {
+		content = fmt.Sprintf("Members who use up their invalid attempts will wait %d minute(s) before trying again! :white_check_mark: They will see:\n%s", minutes, attemptCooldownMessage(message, serverConfig.AttemptCooldown))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestAttemptCooldownMessage(t *testing.T) {
+	remaining := 10 * time.Minute
+	if attemptCooldownMessage("", remaining) == cooldownMessage("", remaining) {
+		t.Error("attempt cooldown message is the same as the rate limit one")
+	}
+	got := attemptCooldownMessage("Too many tries, wait {remaining}", remaining)
+	if got != "Too many tries, wait 10 minutes" {
+		t.Errorf("attemptCooldownMessage() = %q", got)
+	}
+}
+
+func TestAttemptCooldown(t *testing.T) {
+	const (
+		guildID = "100000000000000006"
+		userID  = "200000000000000041"
+	)
+	useServerConfig(t, guildID, ServerConfig{
+		MemberAuditChannelID:   "audit",
+		MaxAttempts:            2,
+		AttemptCooldown:        10 * time.Minute,
+		AttemptCooldownMessage: "Too many tries, wait {remaining}",
+	})
+
+	submissions := []struct {
+		content   string
+		wantReply string
+	}{
+		{content: "not an email", wantReply: "@uclan.ac.uk"},
+		{content: "still not an email", wantReply: "Too many tries, wait 10 minutes"},
+		// Even a valid email waits for the cooldown
+		{content: "student@uclan.ac.uk", wantReply: "Too many tries, wait"},
+	}
+
+	for idx, submission := range submissions {
+		s := &fakeSession{}
+		processEmailVerification(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID:        "submission",
+			ChannelID: "dm-" + userID,
+			GuildID:   guildID,
+			Content:   submission.content,
+			Author:    &discordgo.User{ID: userID},
+		}})
+
+		if s.called("GuildMemberDelete " + guildID + " " + userID) {
+			t.Errorf("submission %d: member kicked, want a cooldown", idx+1)
+		}
+		if len(s.sent) != 1 || !strings.Contains(s.sent[0].Data.Content, submission.wantReply) {
+			t.Errorf("submission %d: replies %v, want one containing %q", idx+1, s.calls, submission.wantReply)
+		}
+	}
+
+	if attemptCooldownRemaining(guildID, userID, time.Now().Add(10*time.Minute)) != 0 {
+		t.Error("cooldown still running after it should have ended")
+	}
+}
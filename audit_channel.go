@@ -0,0 +1,186 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// sendAuditMessage posts to the guild's audit channel, falling back to the
+// backup channel if the primary one can't be reached
+func sendAuditMessage(s discordSession, serverConfig ServerConfig, data *discordgo.MessageSend) (*discordgo.Message, error) {
+	message, err := s.ChannelMessageSendComplex(serverConfig.MemberAuditChannelID, data)
+	if err == nil || serverConfig.FallbackAuditChannelID == "" {
+		return message, err
+	}
+
+	slog.Warn("Error sending message to audit channel, trying fallback", "channel_id", serverConfig.MemberAuditChannelID, "error", err)
+	return s.ChannelMessageSendComplex(serverConfig.FallbackAuditChannelID, data)
+}
+
+// Longest email address allowed by RFC 5321; anything longer isn't an email
+const maxEmailLength = 254
+
+// Room left for user-supplied details in an audit message, well inside
+// Discord's 2000 character limit
+const maxAuditDetailsLength = 1500
+
+// truncateText shortens text to at most limit characters, marking the cut
+// with an ellipsis.
+func truncateText(text string, limit int) string {
+	runes := []rune(text)
+	if len(runes) <= limit {
+		return text
+	}
+	return string(runes[:limit-1]) + "…"
+}
+
+// discordTimestamp renders a time as a Discord timestamp, shown in each
+// reader's timezone along with how long ago it was
+func discordTimestamp(t time.Time) string {
+	if t.IsZero() {
+		return "Unknown"
+	}
+	return fmt.Sprintf("<t:%d:f> (<t:%d:R>)", t.Unix(), t.Unix())
+}
+
+// memberJoinedAt looks up when the user joined the guild, preferring the
+// gateway cache. It returns the zero time if they can't be found.
+func memberJoinedAt(s discordSession, guildID, userID string) time.Time {
+	member, err := stateOf(s).Member(guildID, userID)
+	if err != nil {
+		member, err = s.GuildMember(guildID, userID)
+		if err != nil {
+			return time.Time{}
+		}
+	}
+	return member.JoinedAt
+}
+
+// verificationRequestEmbed lays out a verification request for moderators,
+// with the account details that help spot throwaway accounts
+func verificationRequestEmbed(user *discordgo.User, email, details string, joinedAt time.Time, newAccount bool, language string) *discordgo.MessageEmbed {
+	created, _ := discordgo.SnowflakeTimestamp(user.ID)
+	embed := &discordgo.MessageEmbed{
+		Title:       embedText(language, "title"),
+		Description: truncateText(strings.TrimSpace(details), maxAuditDetailsLength),
+		Color:       0x5865F2,
+		Thumbnail:   &discordgo.MessageEmbedThumbnail{URL: user.AvatarURL("128")},
+		Fields: []*discordgo.MessageEmbedField{
+			{Name: embedText(language, "username"), Value: user.Username, Inline: true},
+			{Name: embedText(language, "user_id"), Value: user.ID, Inline: true},
+			{Name: embedText(language, "email"), Value: truncateText(email, maxEmailLength)},
+			{Name: embedText(language, "account_created"), Value: discordTimestamp(created), Inline: true},
+			{Name: embedText(language, "joined_server"), Value: discordTimestamp(joinedAt), Inline: true},
+		},
+	}
+	if newAccount {
+		embed.Color = 0xFEE75C
+		embed.Fields = append(embed.Fields, &discordgo.MessageEmbedField{
+			Name:  embedText(language, "new_account"),
+			Value: embedText(language, "new_account_warning"),
+		})
+	}
+	return embed
+}
+
+// Discord only accepts these auto-archive durations, in minutes
+var threadArchiveDurations = []int{60, 1440, 4320, 10080}
+
+// startAuditThread opens a discussion thread on a verification request. The
+// buttons stay on the parent message.
+func startAuditThread(s discordSession, serverConfig ServerConfig, message *discordgo.Message, username string) {
+	archive := serverConfig.AuditThreadArchive
+	if archive == 0 {
+		archive = 1440
+	}
+
+	name := "Verification: " + username
+	if len(name) > 100 {
+		name = name[:100]
+	}
+
+	_, err := s.MessageThreadStartComplex(message.ChannelID, message.ID, &discordgo.ThreadStart{
+		Name:                name,
+		AutoArchiveDuration: archive,
+	})
+	if err != nil {
+		slog.Error("Error starting audit thread", "channel_id", message.ChannelID, "message_id", message.ID, "error", err)
+	}
+}
+
+// archiveAuditThread closes the discussion thread on a request once it has
+// been decided, for guilds that ask for it. Threads started from a message
+// share its ID.
+func archiveAuditThread(s discordSession, guildID string, message *discordgo.Message) {
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	if !serverConfig.UseAuditThreads || !serverConfig.ArchiveDecidedThreads {
+		return
+	}
+
+	archived := true
+	_, err := s.ChannelEdit(message.ID, &discordgo.ChannelEdit{Archived: &archived})
+	// Requests posted before threads were enabled have none
+	if err != nil && !hasErrorCode(err, discordgo.ErrCodeUnknownChannel) {
+		slog.Error("Error archiving audit thread", "guild_id", guildID, "thread_id", message.ID, "error", err)
+	}
+}
+
+func setAuditThreads(s discordSession, i *discordgo.InteractionCreate) {
+	var enabled, archiveDecided bool
+	var archive int
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "enabled":
+			enabled = option.BoolValue()
+		case "archive_after":
+			archive = int(option.IntValue())
+		case "archive_on_decision":
+			archiveDecided = option.BoolValue()
+		}
+	}
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.UseAuditThreads = enabled
+	serverConfig.AuditThreadArchive = archive
+	serverConfig.ArchiveDecidedThreads = archiveDecided
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Audit threads disabled successfully! :white_check_mark:"
+	if enabled {
+		content = "Audit threads enabled successfully! :white_check_mark: Each verification request will get a discussion thread."
+		if archive > 0 {
+			content += fmt.Sprintf(" Threads archive after %d minutes of inactivity.", archive)
+		}
+		if archiveDecided {
+			content += " Threads are archived once the request is decided."
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
@@ -0,0 +1,56 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"os"
+	"time"
+)
+
+var auditWebhookClient = &http.Client{Timeout: 10 * time.Second}
+
+type auditEvent struct {
+	GuildID     string    `json:"guild_id"`
+	UserID      string    `json:"user_id"`
+	Action      string    `json:"action"`
+	ModeratorID string    `json:"moderator_id,omitempty"`
+	Timestamp   time.Time `json:"timestamp"`
+}
+
+func postAuditEvent(url string, event auditEvent) error {
+	body, err := json.Marshal(event)
+	if err != nil {
+		return err
+	}
+
+	resp, err := auditWebhookClient.Post(url, "application/json", bytes.NewReader(body))
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		return fmt.Errorf("webhook returned %s", resp.Status)
+	}
+	return nil
+}
+
+// sendAuditEvent forwards a verification event to AUDIT_WEBHOOK_URL, if set.
+// It runs in the background so a slow webhook never holds up Discord, and
+// failures are only logged.
+func sendAuditEvent(event auditEvent) {
+	url := os.Getenv("AUDIT_WEBHOOK_URL")
+	if url == "" {
+		return
+	}
+
+	go func() {
+		err := postAuditEvent(url, event)
+		if err != nil {
+			slog.Warn("Error sending audit webhook", "guild_id", event.GuildID, "action", event.Action, "error", err)
+		}
+	}()
+}
@@ -0,0 +1,320 @@
+package main
+
+import (
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long a user has to finish signing in after requesting a link
+const azureStateTTL = 10 * time.Minute
+
+var (
+	errWrongTenant  = errors.New("account belongs to a different tenant")
+	errWrongClient  = errors.New("token was issued for a different application")
+	errNoEmailClaim = errors.New("token has no verified email")
+)
+
+type azureState struct {
+	GuildID   string
+	UserID    string
+	ExpiresAt time.Time
+}
+
+var (
+	azureStates     = make(map[string]azureState)
+	azureStatesLock sync.Mutex
+)
+
+type azureSettings struct {
+	ClientID     string
+	ClientSecret string
+	RedirectURL  string
+}
+
+// Read from AZURE_CLIENT_ID, AZURE_CLIENT_SECRET and OAUTH_REDIRECT_URL at
+// startup. Microsoft verification is unavailable when ClientID is empty.
+var azure azureSettings
+
+func newAzureState(guildID, userID string) (string, error) {
+	buf := make([]byte, 16)
+	_, err := rand.Read(buf)
+	if err != nil {
+		return "", err
+	}
+	state := hex.EncodeToString(buf)
+
+	azureStatesLock.Lock()
+	azureStates[state] = azureState{GuildID: guildID, UserID: userID, ExpiresAt: time.Now().Add(azureStateTTL)}
+	azureStatesLock.Unlock()
+	return state, nil
+}
+
+// takeAzureState consumes a state so each sign-in link can only be used once.
+func takeAzureState(state string) (azureState, bool) {
+	azureStatesLock.Lock()
+	defer azureStatesLock.Unlock()
+
+	entry, exists := azureStates[state]
+	delete(azureStates, state)
+	if !exists || time.Now().After(entry.ExpiresAt) {
+		return azureState{}, false
+	}
+	return entry, true
+}
+
+// pruneAzureStates drops sign-in links that expired without being used.
+func pruneAzureStates(states map[string]azureState, now time.Time) {
+	for state, entry := range states {
+		if now.After(entry.ExpiresAt) {
+			delete(states, state)
+		}
+	}
+}
+
+// runAzureStateSweeps clears out abandoned sign-in links, which would
+// otherwise stay in memory until the bot restarts.
+func runAzureStateSweeps() {
+	ticker := time.NewTicker(azureStateTTL)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		azureStatesLock.Lock()
+		pruneAzureStates(azureStates, time.Now())
+		azureStatesLock.Unlock()
+	}
+}
+
+func azureAuthorizeURL(tenantID, state string) string {
+	query := url.Values{
+		"client_id":     {azure.ClientID},
+		"response_type": {"code"},
+		"redirect_uri":  {azure.RedirectURL},
+		"response_mode": {"query"},
+		"scope":         {"openid email profile"},
+		"state":         {state},
+	}
+	return fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/authorize?%s", url.PathEscape(tenantID), query.Encode())
+}
+
+func matchesTenant(claims *jwtClaims, tenantID string) bool {
+	return claims.TenantID != "" && strings.EqualFold(claims.TenantID, tenantID)
+}
+
+// azureEmail picks the user's email from an ID token. The email claim is only
+// trusted when Microsoft says the domain is verified; otherwise we fall back
+// to the sign-in name, which is always in one of the tenant's own domains.
+func azureEmail(claims *jwtClaims) (string, error) {
+	if claims.Email != "" && claims.EmailDomainVerified {
+		return strings.ToLower(claims.Email), nil
+	}
+	if strings.Contains(claims.PreferredUsername, "@") {
+		return strings.ToLower(claims.PreferredUsername), nil
+	}
+	return "", errNoEmailClaim
+}
+
+// validateAzureIDToken checks the ID token was signed by Microsoft for our
+// application and belongs to the guild's tenant, returning the user's email.
+func validateAzureIDToken(idToken, tenantID string, keyFunc func(kid string) (*rsa.PublicKey, error), now time.Time) (string, error) {
+	issuer := fmt.Sprintf("https://login.microsoftonline.com/%s/v2.0", tenantID)
+	claims, err := validateInstitutionJWT(idToken, keyFunc, issuer, azure.ClientID, "", now)
+	if errors.Is(err, errWrongIssuer) {
+		return "", errWrongTenant
+	}
+	if errors.Is(err, errWrongAudience) {
+		return "", errWrongClient
+	}
+	if err != nil {
+		return "", err
+	}
+	if !matchesTenant(claims, tenantID) {
+		return "", errWrongTenant
+	}
+	return azureEmail(claims)
+}
+
+func exchangeAzureCode(tenantID, code string) (string, error) {
+	form := url.Values{
+		"client_id":     {azure.ClientID},
+		"client_secret": {azure.ClientSecret},
+		"code":          {code},
+		"redirect_uri":  {azure.RedirectURL},
+		"grant_type":    {"authorization_code"},
+		"scope":         {"openid email profile"},
+	}
+	resp, err := jwksClient.PostForm(fmt.Sprintf("https://login.microsoftonline.com/%s/oauth2/v2.0/token", url.PathEscape(tenantID)), form)
+	if err != nil {
+		return "", err
+	}
+	defer resp.Body.Close()
+
+	var token struct {
+		IDToken          string `json:"id_token"`
+		Error            string `json:"error"`
+		ErrorDescription string `json:"error_description"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&token)
+	if err != nil {
+		return "", err
+	}
+	if token.Error != "" {
+		return "", fmt.Errorf("%s: %s", token.Error, token.ErrorDescription)
+	}
+	if token.IDToken == "" {
+		return "", errors.New("token response did not include an ID token")
+	}
+	return token.IDToken, nil
+}
+
+func azureCallbackHandler(s discordSession) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		state, ok := takeAzureState(r.URL.Query().Get("state"))
+		if !ok {
+			http.Error(w, "This sign-in link has expired. Please run /verify_microsoft again.", http.StatusBadRequest)
+			return
+		}
+
+		if errMsg := r.URL.Query().Get("error"); errMsg != "" {
+			slog.Warn("Microsoft sign-in failed", "user_id", state.UserID, "reason", errMsg)
+			http.Error(w, "Sign-in was cancelled or failed. Please run /verify_microsoft again.", http.StatusBadRequest)
+			return
+		}
+
+		configMutex.RLock()
+		serverConfig := config.Servers[state.GuildID]
+		configMutex.RUnlock()
+
+		if serverConfig.AzureTenantID == "" {
+			http.Error(w, "Microsoft verification is no longer enabled for this server.", http.StatusBadRequest)
+			return
+		}
+
+		idToken, err := exchangeAzureCode(serverConfig.AzureTenantID, r.URL.Query().Get("code"))
+		if err != nil {
+			slog.Error("Error exchanging Microsoft auth code", "user_id", state.UserID, "error", err)
+			http.Error(w, "Something went wrong completing sign-in. Please try again.", http.StatusBadGateway)
+			return
+		}
+
+		keysURL := fmt.Sprintf("https://login.microsoftonline.com/%s/discovery/v2.0/keys", url.PathEscape(serverConfig.AzureTenantID))
+		keyFunc := func(kid string) (*rsa.PublicKey, error) {
+			return jwksKey(keysURL, kid)
+		}
+		email, err := validateAzureIDToken(idToken, serverConfig.AzureTenantID, keyFunc, time.Now())
+		if err != nil {
+			slog.Warn("Microsoft token rejected", "user_id", state.UserID, "error", err)
+			http.Error(w, "Your account could not be verified: "+err.Error(), http.StatusForbidden)
+			return
+		}
+
+		if entry, blocked := blocklistMatch(state.UserID, email, serverConfig.Blocklist); blocked {
+			removeBlocklisted(s, state.GuildID, state.UserID, serverConfig, email, entry)
+			http.Error(w, "Your account could not be verified. Please contact a moderator.", http.StatusForbidden)
+			return
+		}
+
+		// Remove unverified role
+		if serverConfig.UnverifiedRoleID != "" {
+			err = withRetry(func() error {
+				return memberRoleRemove(s, state.GuildID, state.UserID, serverConfig.UnverifiedRoleID)
+			})
+			if err != nil {
+				slog.Error("Error removing unverified role", "error", err)
+				http.Error(w, "You were signed in but something went wrong updating your roles. Please contact a moderator.", http.StatusBadGateway)
+				return
+			}
+		}
+		grantVerifiedRole(s, state.GuildID, state.UserID)
+
+		slog.Info("User verified via Microsoft", "guild_id", state.GuildID, "user_id", state.UserID)
+		recordDecision(s, VerificationLogEntry{
+			GuildID: state.GuildID,
+			UserID:  state.UserID,
+			Action:  "approve",
+			Time:    time.Now(),
+		})
+
+		fmt.Fprint(w, "You're verified! You can close this window and return to Discord.")
+	}
+}
+
+// startCallbackServer serves the Microsoft sign-in callback and the links in
+// event QR codes.
+func startCallbackServer(s discordSession, addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.Handle("/oauth/callback", azureCallbackHandler(s))
+	mux.Handle("/event/verify", eventVerifyHandler())
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go runAzureStateSweeps()
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			slog.Info("Callback server stopped", "error", err)
+		}
+	}()
+	return server
+}
+
+func loadAzureSettings() {
+	azure = azureSettings{
+		ClientID:     os.Getenv("AZURE_CLIENT_ID"),
+		ClientSecret: os.Getenv("AZURE_CLIENT_SECRET"),
+		RedirectURL:  os.Getenv("OAUTH_REDIRECT_URL"),
+	}
+}
+
+func verifyMicrosoft(s discordSession, i *discordgo.InteractionCreate) {
+	configMutex.RLock()
+	tenantID := config.Servers[i.GuildID].AzureTenantID
+	configMutex.RUnlock()
+
+	if azure.ClientID == "" || tenantID == "" || i.Member == nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Microsoft verification isn't enabled for this server",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	state, err := newAzureState(i.GuildID, i.Member.User.ID)
+	if err != nil {
+		slog.Error("Error creating sign-in state", "error", err)
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Sign in with your university Microsoft account to verify. This link expires in 10 minutes.",
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label: "Sign in with Microsoft",
+							Style: discordgo.LinkButton,
+							URL:   azureAuthorizeURL(tenantID, state),
+						},
+					},
+				},
+			},
+		},
+	})
+}
@@ -0,0 +1,89 @@
+package main
+
+import (
+	"errors"
+	"testing"
+	"time"
+)
+
+func TestMatchesTenant(t *testing.T) {
+	const tenantID = "0b0a1f4e-3c2d-4e5f-8a9b-1c2d3e4f5a6b"
+
+	tests := []struct {
+		name     string
+		claimTID string
+		want     bool
+	}{
+		{name: "same tenant", claimTID: tenantID, want: true},
+		{name: "different case", claimTID: "0B0A1F4E-3C2D-4E5F-8A9B-1C2D3E4F5A6B", want: true},
+		{name: "other tenant", claimTID: "11111111-2222-3333-4444-555555555555", want: false},
+		{name: "no tenant claim", claimTID: "", want: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := matchesTenant(&jwtClaims{TenantID: tt.claimTID}, tenantID)
+			if got != tt.want {
+				t.Errorf("matchesTenant() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAzureEmail(t *testing.T) {
+	tests := []struct {
+		name    string
+		claims  jwtClaims
+		want    string
+		wantErr error
+	}{
+		{
+			name:   "verified email",
+			claims: jwtClaims{Email: "Student@UCLan.ac.uk", EmailDomainVerified: true, PreferredUsername: "other@uclan.ac.uk"},
+			want:   "student@uclan.ac.uk",
+		},
+		{
+			name:   "unverified email falls back to sign-in name",
+			claims: jwtClaims{Email: "student@example.com", PreferredUsername: "Student@uclan.ac.uk"},
+			want:   "student@uclan.ac.uk",
+		},
+		{
+			name:    "sign-in name isn't an email",
+			claims:  jwtClaims{Email: "student@example.com", PreferredUsername: "student"},
+			wantErr: errNoEmailClaim,
+		},
+		{
+			name:    "no email claims",
+			wantErr: errNoEmailClaim,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := azureEmail(&tt.claims)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if got != tt.want {
+				t.Errorf("azureEmail() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneAzureStates(t *testing.T) {
+	now := time.Date(2026, 9, 21, 12, 0, 0, 0, time.UTC)
+	states := map[string]azureState{
+		"expired": {UserID: "200000000000000061", ExpiresAt: now.Add(-time.Second)},
+		"live":    {UserID: "200000000000000062", ExpiresAt: now.Add(time.Minute)},
+	}
+
+	pruneAzureStates(states, now)
+
+	if _, ok := states["expired"]; ok {
+		t.Error("expired state kept")
+	}
+	if _, ok := states["live"]; !ok {
+		t.Error("live state removed")
+	}
+}
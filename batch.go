@@ -0,0 +1,263 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord allows at most 25 options in a select menu
+const maxBatchSize = 25
+
+// Longest text Discord allows in a select menu option's label or description
+const maxSelectOptionLength = 100
+
+// Room for a batch message's text, leaving space under Discord's 2000
+// character limit for the line counting what didn't fit
+const maxBatchContentLength = 1950
+
+type batchApplicant struct {
+	UserID   string
+	Username string
+	Email    string
+	Details  string
+}
+
+type reviewBatch struct {
+	GuildID    string
+	ChannelID  string
+	Reactions  []string
+	Applicants []batchApplicant
+	Results    []string
+}
+
+var (
+	// Batches still collecting applicants, keyed by guild ID
+	openBatches = make(map[string]*reviewBatch)
+	// Batches posted to an audit channel, keyed by message ID
+	postedBatches = make(map[string]*reviewBatch)
+	batchLock     sync.Mutex
+)
+
+// addToBatch adds the applicant to the guild's open batch, starting a new one
+// if there isn't one. It returns the batch and whether this call started it.
+// A batch that fills up is closed immediately and reported as full.
+func addToBatch(guildID, channelID string, reactions []string, applicant batchApplicant) (batch *reviewBatch, started bool, full bool) {
+	batch, exists := openBatches[guildID]
+	if !exists {
+		batch = &reviewBatch{GuildID: guildID, ChannelID: channelID, Reactions: reactions}
+		openBatches[guildID] = batch
+	}
+
+	// A resubmission replaces the applicant's earlier entry
+	replaced := false
+	for idx, existing := range batch.Applicants {
+		if existing.UserID == applicant.UserID {
+			batch.Applicants[idx] = applicant
+			replaced = true
+			break
+		}
+	}
+	if !replaced {
+		batch.Applicants = append(batch.Applicants, applicant)
+	}
+
+	if len(batch.Applicants) >= maxBatchSize {
+		delete(openBatches, guildID)
+		return batch, !exists, true
+	}
+	return batch, !exists, false
+}
+
+// closeBatch removes the batch from the open set, reporting false if it was
+// already closed (for example because it filled up).
+func closeBatch(batch *reviewBatch) bool {
+	if openBatches[batch.GuildID] != batch {
+		return false
+	}
+	delete(openBatches, batch.GuildID)
+	return true
+}
+
+func queueBatchApplicant(s discordSession, guildID string, serverConfig ServerConfig, applicant batchApplicant) {
+	batchLock.Lock()
+	batch, started, full := addToBatch(guildID, serverConfig.MemberAuditChannelID, serverConfig.AuditReactions, applicant)
+	batchLock.Unlock()
+
+	if full {
+		postBatch(s, batch)
+		return
+	}
+
+	if started {
+		time.AfterFunc(serverConfig.BatchReviewWindow, func() {
+			batchLock.Lock()
+			closed := closeBatch(batch)
+			batchLock.Unlock()
+
+			if closed {
+				postBatch(s, batch)
+			}
+		})
+	}
+}
+
+func postBatch(s discordSession, batch *reviewBatch) {
+	batchLock.Lock()
+	content, components := renderBatch(batch)
+	batchLock.Unlock()
+
+	message, err := s.ChannelMessageSendComplex(batch.ChannelID, &discordgo.MessageSend{
+		Content:    content,
+		Components: components,
+	})
+	if err != nil {
+		slog.Error("Error sending batch to audit channel", "error", err)
+		return
+	}
+
+	batchLock.Lock()
+	postedBatches[message.ID] = batch
+	batchLock.Unlock()
+
+	addAuditReactions(s, message, batch.Reactions)
+}
+
+// joinLinesCapped joins lines until the next one would pass limit, then
+// counts the ones left out.
+func joinLinesCapped(lines []string, limit int) string {
+	var sb strings.Builder
+	for idx, line := range lines {
+		if sb.Len()+len(line)+1 > limit {
+			fmt.Fprintf(&sb, "…and %d more", len(lines)-idx)
+			break
+		}
+		sb.WriteString(line + "\n")
+	}
+	return sb.String()
+}
+
+func renderBatch(batch *reviewBatch) (string, []discordgo.MessageComponent) {
+	var lines []string
+	if len(batch.Applicants) > 0 {
+		lines = append(lines, fmt.Sprintf("**%d user(s) have requested verification**", len(batch.Applicants)))
+		for _, applicant := range batch.Applicants {
+			details := strings.ReplaceAll(applicant.Details, "\n", "\n  ")
+			lines = append(lines, fmt.Sprintf("- %s (<@%s>) with email %s%s", applicant.Username, applicant.UserID, applicant.Email, details))
+		}
+	}
+	lines = append(lines, batch.Results...)
+	content := joinLinesCapped(lines, maxBatchContentLength)
+
+	if len(batch.Applicants) == 0 {
+		return content, []discordgo.MessageComponent{}
+	}
+
+	options := make([]discordgo.SelectMenuOption, 0, len(batch.Applicants))
+	for _, applicant := range batch.Applicants {
+		options = append(options, discordgo.SelectMenuOption{
+			Label:       applicant.Username,
+			Value:       applicant.UserID,
+			Description: truncateText(applicant.Email, maxSelectOptionLength),
+		})
+	}
+
+	minValues := 1
+	components := []discordgo.MessageComponent{
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    "batchapprove",
+					Placeholder: "Approve applicants...",
+					MinValues:   &minValues,
+					MaxValues:   len(options),
+					Options:     options,
+				},
+			},
+		},
+		discordgo.ActionsRow{
+			Components: []discordgo.MessageComponent{
+				discordgo.SelectMenu{
+					CustomID:    "batchdeny",
+					Placeholder: "Deny applicants...",
+					MinValues:   &minValues,
+					MaxValues:   len(options),
+					Options:     options,
+				},
+			},
+		},
+	}
+	return content, components
+}
+
+// removeFromBatch drops handled applicants from a posted batch and records
+// their outcomes.
+func removeFromBatch(batch *reviewBatch, handled map[string]string) {
+	remaining := batch.Applicants[:0]
+	for _, applicant := range batch.Applicants {
+		if result, ok := handled[applicant.UserID]; ok {
+			batch.Results = append(batch.Results, result)
+			continue
+		}
+		remaining = append(remaining, applicant)
+	}
+	batch.Applicants = remaining
+}
+
+func handleBatchSelect(s discordSession, i *discordgo.InteractionCreate, action string) {
+	handled := make(map[string]string)
+	failed := 0
+	for _, userID := range i.MessageComponentData().Values {
+		responseContent, ok := processDecision(s, i, action, userID)
+		if !ok {
+			failed++
+			continue
+		}
+		handled[userID] = auditResultContent(i.GuildID, userID, responseContent) + moderatorAttribution(i)
+	}
+
+	batchLock.Lock()
+	batch, exists := postedBatches[i.Message.ID]
+	var content string
+	var components []discordgo.MessageComponent
+	if exists {
+		removeFromBatch(batch, handled)
+		content, components = renderBatch(batch)
+		if len(batch.Applicants) == 0 {
+			delete(postedBatches, i.Message.ID)
+		}
+	}
+	batchLock.Unlock()
+
+	// The batch is only tracked in memory, so after a restart the message
+	// can't be re-rendered and is left as it is
+	if exists {
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel:    i.ChannelID,
+			ID:         i.Message.ID,
+			Content:    &content,
+			Components: &components,
+		})
+		if err != nil {
+			slog.Error("Error editing batch message", "error", err)
+		}
+	}
+
+	// processDecision has already put the error in the response, so leave it
+	// there for the moderator to see
+	if failed > 0 {
+		return
+	}
+
+	completionMessage := fmt.Sprintf("Action completed for %d user(s)", len(handled))
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &completionMessage,
+	})
+	if err != nil {
+		slog.Error("Error editing interaction response", "error", err)
+	}
+}
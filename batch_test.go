@@ -0,0 +1,106 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestRenderBatchLength(t *testing.T) {
+	batch := &reviewBatch{GuildID: "guild"}
+	for n := 0; n < maxBatchSize; n++ {
+		batch.Applicants = append(batch.Applicants, batchApplicant{
+			UserID:   fmt.Sprintf("2000000000000001%02d", n),
+			Username: "student",
+			Email:    strings.Repeat("a", 60) + "@uclan.ac.uk",
+			Details:  "\n" + strings.Repeat("details ", 20),
+		})
+	}
+
+	content, components := renderBatch(batch)
+	if len(content) > 2000 {
+		t.Errorf("content is %d characters, want at most 2000", len(content))
+	}
+	if !strings.Contains(content, "more") {
+		t.Errorf("content doesn't say how many applicants were left out:\n%s", content)
+	}
+
+	// Every applicant can still be picked from the menus
+	menu := components[0].(discordgo.ActionsRow).Components[0].(discordgo.SelectMenu)
+	if len(menu.Options) != maxBatchSize {
+		t.Errorf("menu has %d options, want %d", len(menu.Options), maxBatchSize)
+	}
+}
+
+func TestJoinLinesCapped(t *testing.T) {
+	tests := []struct {
+		name  string
+		lines []string
+		limit int
+		want  string
+	}{
+		{name: "fits", lines: []string{"one", "two"}, limit: 100, want: "one\ntwo\n"},
+		{name: "cut short", lines: []string{"one", "two", "three"}, limit: 8, want: "one\ntwo\n…and 1 more"},
+		{name: "nothing fits", lines: []string{"one"}, limit: 2, want: "…and 1 more"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := joinLinesCapped(tt.lines, tt.limit); got != tt.want {
+				t.Errorf("joinLinesCapped() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestHandleBatchSelectKeepsErrors(t *testing.T) {
+	const (
+		guildID     = "100000000000000010"
+		moderatorID = "300000000000000004"
+		userID      = "200000000000000051"
+	)
+	useServerConfig(t, guildID, ServerConfig{MemberAuditChannelID: "audit", PreventSelfApproval: true})
+
+	batch := &reviewBatch{GuildID: guildID, ChannelID: "audit", Applicants: []batchApplicant{
+		{UserID: moderatorID, Username: "moderator"},
+		{UserID: userID, Username: "student"},
+	}}
+	batchLock.Lock()
+	postedBatches["batch-errors"] = batch
+	batchLock.Unlock()
+	t.Cleanup(func() {
+		batchLock.Lock()
+		delete(postedBatches, "batch-errors")
+		batchLock.Unlock()
+	})
+
+	s := &fakeSession{}
+	handleBatchSelect(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionMessageComponent,
+		GuildID:   guildID,
+		ChannelID: "audit",
+		Message:   &discordgo.Message{ID: "batch-errors"},
+		Member:    &discordgo.Member{User: &discordgo.User{ID: moderatorID}},
+		Data: discordgo.MessageComponentInteractionData{
+			CustomID: "batchapprove",
+			Values:   []string{moderatorID, userID},
+		},
+	}}, "approve")
+
+	var last string
+	for _, call := range s.calls {
+		if strings.HasPrefix(call, "InteractionResponseEdit ") {
+			last = call
+		}
+	}
+	if !strings.Contains(last, "can't approve or deny your own") {
+		t.Errorf("last response = %q, want the self-approval error kept", last)
+	}
+
+	// The applicant who was approved still comes off the batch
+	if len(batch.Applicants) != 1 || batch.Applicants[0].UserID != moderatorID {
+		t.Errorf("applicants left = %v, want only the moderator", batch.Applicants)
+	}
+}
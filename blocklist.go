@@ -0,0 +1,179 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// blocklistMatch returns the blocklist entry matching the user's ID or email,
+// if any. Emails are compared case-insensitively.
+func blocklistMatch(userID, email string, blocklist []string) (string, bool) {
+	for _, entry := range blocklist {
+		if entry == userID || (email != "" && strings.EqualFold(entry, email)) {
+			return entry, true
+		}
+	}
+	return "", false
+}
+
+// denyBlocklisted turns a blocklisted submission away without involving the
+// moderators, and leaves a note in the audit channel so it isn't silent.
+func denyBlocklisted(s discordSession, m *discordgo.MessageCreate, guildID string, serverConfig ServerConfig, email, entry string) {
+	replyToSubmission(s, m, denialDM(serverConfig))
+	removeBlocklisted(s, guildID, m.Author.ID, serverConfig, email, entry)
+}
+
+// removeBlocklisted kicks a blocklisted user if the guild asks for it and
+// notes the attempt in the audit channel. Telling the user is left to the
+// caller, since it depends on how they tried to verify.
+func removeBlocklisted(s discordSession, guildID, userID string, serverConfig ServerConfig, email, entry string) {
+	logger := slog.With("guild_id", guildID, "user_id", userID, "entry", entry)
+
+	outcome := "They were not kicked."
+	if serverConfig.BlocklistKick {
+		err := withRetry(func() error { return memberKick(s, guildID, userID) })
+		if err != nil && !hasErrorCode(err, discordgo.ErrCodeUnknownMember) {
+			logger.Error("Error kicking blocklisted user", "error", err)
+			outcome = "Kicking them failed: " + err.Error()
+		} else {
+			outcome = "They were kicked."
+		}
+	}
+	logger.Info("Denied blocklisted verification request", "kicked", serverConfig.BlocklistKick)
+
+	if serverConfig.MemberAuditChannelID == "" {
+		return
+	}
+	_, err := sendAuditMessage(s, serverConfig, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("🚫 <@%s> tried to verify with email %s but matches the blocklist entry %s. %s", userID, email, entry, outcome),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		logger.Error("Error logging blocklist denial", "error", err)
+	}
+}
+
+func blockEntry(s discordSession, i *discordgo.InteractionCreate) {
+	entry := strings.ToLower(strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue()))
+	guildID := i.GuildID
+
+	if !emailRegex.MatchString(entry) && !userIDRegex.MatchString(entry) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Please give an email address or a user ID",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	_, found := blocklistMatch(entry, entry, serverConfig.Blocklist)
+	if !found {
+		serverConfig.Blocklist = append(serverConfig.Blocklist, entry)
+	}
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := fmt.Sprintf("%s added to the blocklist successfully! :white_check_mark:", entry)
+	if found {
+		content = fmt.Sprintf("%s is already on the blocklist", entry)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func unblockEntry(s discordSession, i *discordgo.InteractionCreate) {
+	entry := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	var remaining []string
+	for _, blocked := range serverConfig.Blocklist {
+		if !strings.EqualFold(blocked, entry) {
+			remaining = append(remaining, blocked)
+		}
+	}
+	removed := len(remaining) != len(serverConfig.Blocklist)
+	serverConfig.Blocklist = remaining
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := fmt.Sprintf("%s removed from the blocklist successfully! :white_check_mark:", entry)
+	if !removed {
+		content = fmt.Sprintf("%s isn't on the blocklist", entry)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func setBlocklistKick(s discordSession, i *discordgo.InteractionCreate) {
+	enabled := i.ApplicationCommandData().Options[0].BoolValue()
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.BlocklistKick = enabled
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Blocklisted users will be denied without being kicked! :white_check_mark:"
+	if enabled {
+		content = "Blocklisted users will be denied and kicked! :white_check_mark:"
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
@@ -0,0 +1,118 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Defaults match the old fixed role migration pacing of one change every
+// 250ms
+const (
+	defaultBulkBatchSize     = 1
+	defaultBulkBatchInterval = 250 * time.Millisecond
+)
+
+// bulkPolicy paces bulk operations (role migrations, summary DMs) so a busy
+// server doesn't push the bot into Discord's rate limits
+type bulkPolicy struct {
+	BatchSize int
+	Interval  time.Duration
+}
+
+func guildBulkPolicy(guildID string) bulkPolicy {
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	// Guilds that never set a policy keep the defaults
+	if serverConfig.BulkBatchSize <= 0 {
+		return bulkPolicy{BatchSize: defaultBulkBatchSize, Interval: defaultBulkBatchInterval}
+	}
+	return bulkPolicy{
+		BatchSize: serverConfig.BulkBatchSize,
+		Interval:  serverConfig.BulkBatchInterval,
+	}
+}
+
+// bulkThrottle counts operations and pauses for the policy interval each
+// time a batch fills up
+type bulkThrottle struct {
+	policy bulkPolicy
+	count  int
+	sleep  func(time.Duration)
+}
+
+func newBulkThrottle(policy bulkPolicy) *bulkThrottle {
+	return &bulkThrottle{policy: policy, sleep: time.Sleep}
+}
+
+// done records one operation against the API
+func (t *bulkThrottle) done() {
+	t.count++
+	if t.count%t.policy.BatchSize == 0 && t.policy.Interval > 0 {
+		t.sleep(t.policy.Interval)
+	}
+}
+
+func showBulkPolicy(s discordSession, i *discordgo.InteractionCreate) {
+	policy := guildBulkPolicy(i.GuildID)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Bulk operations run in batches of %d with %s between batches", policy.BatchSize, policy.Interval),
+		},
+	})
+}
+
+func setBulkPolicy(s discordSession, i *discordgo.InteractionCreate) {
+	var batchSize, intervalMs int64
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "batch_size":
+			batchSize = option.IntValue()
+		case "interval_ms":
+			intervalMs = option.IntValue()
+		}
+	}
+	guildID := i.GuildID
+
+	if batchSize < 1 || intervalMs < 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The batch size must be at least 1 and the interval cannot be negative",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.BulkBatchSize = int(batchSize)
+	serverConfig.BulkBatchInterval = time.Duration(intervalMs) * time.Millisecond
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	slog.Info("Bulk policy set", "guild_id", guildID, "batch_size", batchSize, "interval_ms", intervalMs)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Bulk operation policy updated successfully! :white_check_mark:",
+		},
+	})
+}
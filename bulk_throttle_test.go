@@ -0,0 +1,70 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestBulkThrottle(t *testing.T) {
+	tests := []struct {
+		name       string
+		policy     bulkPolicy
+		operations int
+		wantPauses []int
+	}{
+		{
+			name:       "pauses after each full batch",
+			policy:     bulkPolicy{BatchSize: 3, Interval: time.Second},
+			operations: 7,
+			wantPauses: []int{3, 6},
+		},
+		{
+			name:       "one at a time",
+			policy:     bulkPolicy{BatchSize: 1, Interval: time.Second},
+			operations: 3,
+			wantPauses: []int{1, 2, 3},
+		},
+		{
+			name:       "no interval",
+			policy:     bulkPolicy{BatchSize: 2},
+			operations: 5,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			throttle := newBulkThrottle(tt.policy)
+			var pauses []int
+			operation := 0
+			throttle.sleep = func(d time.Duration) {
+				if d != tt.policy.Interval {
+					t.Errorf("paused for %s, want %s", d, tt.policy.Interval)
+				}
+				pauses = append(pauses, operation)
+			}
+
+			for operation = 1; operation <= tt.operations; operation++ {
+				throttle.done()
+			}
+
+			if !slices.Equal(pauses, tt.wantPauses) {
+				t.Errorf("paused after operations %v, want %v", pauses, tt.wantPauses)
+			}
+		})
+	}
+}
+
+func TestGuildBulkPolicy(t *testing.T) {
+	const guildID = "100000000000000033"
+
+	useServerConfig(t, guildID, ServerConfig{})
+	if got := guildBulkPolicy(guildID); got != (bulkPolicy{BatchSize: defaultBulkBatchSize, Interval: defaultBulkBatchInterval}) {
+		t.Errorf("default policy = %+v", got)
+	}
+
+	useServerConfig(t, guildID, ServerConfig{BulkBatchSize: 10, BulkBatchInterval: 2 * time.Second})
+	if got := guildBulkPolicy(guildID); got != (bulkPolicy{BatchSize: 10, Interval: 2 * time.Second}) {
+		t.Errorf("configured policy = %+v", got)
+	}
+}
@@ -0,0 +1,192 @@
+package main
+
+import (
+	"log/slog"
+	"slices"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Commands registered so far, keyed by guild ID ("" for global commands), so
+// they can be cleaned up on exit and guilds aren't registered twice
+var (
+	registeredCommands     = make(map[string][]*discordgo.ApplicationCommand)
+	perGuildCommands       bool
+	registeredCommandsLock sync.Mutex
+)
+
+// Commands that only apply once a feature is set up. Guilds that haven't
+// enabled the feature don't see them; the command that turns it on is
+// always shown.
+var featureCommands = map[string]func(ServerConfig) bool{
+	"verify_microsoft":             func(c ServerConfig) bool { return c.AzureTenantID != "" },
+	"disable_azure_verification":   func(c ServerConfig) bool { return c.AzureTenantID != "" },
+	"disable_jwt_verification":     func(c ServerConfig) bool { return c.JWKSURL != "" },
+	"disable_membership_api":       func(c ServerConfig) bool { return c.MembershipAPIURL != "" },
+	"disable_partner_verification": func(c ServerConfig) bool { return c.PartnerGuildID != "" },
+	"disable_review_summary":       func(c ServerConfig) bool { return c.SummaryTime != "" },
+	"remove_verification_field":    func(c ServerConfig) bool { return len(c.VerificationFields) > 0 },
+	"remove_verification_message":  func(c ServerConfig) bool { return len(c.VerificationMessages) > 0 },
+	"pause_auto_kick":              func(c ServerConfig) bool { return c.VerificationTimeout > 0 && !c.AutoKickPaused },
+	"resume_auto_kick":             func(c ServerConfig) bool { return c.AutoKickPaused },
+	"event_qr":                     func(ServerConfig) bool { return len(eventQR.Secret) != 0 },
+}
+
+// guildCommands returns the commands that apply to a guild with the given
+// config.
+func guildCommands(serverConfig ServerConfig) []*discordgo.ApplicationCommand {
+	available := make([]*discordgo.ApplicationCommand, 0, len(commands))
+	for _, cmd := range commands {
+		if enabled, gated := featureCommands[cmd.Name]; gated && !enabled(serverConfig) {
+			continue
+		}
+		available = append(available, cmd)
+	}
+	return available
+}
+
+// commandsFor returns the command set to register in a guild, or the full
+// set for global commands ("").
+func commandsFor(guildID string) []*discordgo.ApplicationCommand {
+	if guildID == "" {
+		return commands
+	}
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+	return guildCommands(serverConfig)
+}
+
+// registerGuildCommands overwrites the command set in one guild, or globally
+// for "". Guild commands show up straight away, unlike global ones.
+func registerGuildCommands(s discordSession, guildID string) error {
+	registeredCommandsLock.Lock()
+	defer registeredCommandsLock.Unlock()
+
+	if _, done := registeredCommands[guildID]; done {
+		return nil
+	}
+
+	created, err := s.ApplicationCommandBulkOverwrite(botUserID(s), guildID, commandsFor(guildID))
+	if err != nil {
+		return err
+	}
+	registeredCommands[guildID] = created
+	slog.Info("Registered commands", "guild_id", guildID, "total", len(created))
+	return nil
+}
+
+func commandNames(cmds []*discordgo.ApplicationCommand) []string {
+	names := make([]string, 0, len(cmds))
+	for _, cmd := range cmds {
+		names = append(names, cmd.Name)
+	}
+	slices.Sort(names)
+	return names
+}
+
+// syncGuildCommands re-registers a guild's commands if a config change has
+// altered which of them apply. Guilds using global commands are left alone.
+func syncGuildCommands(s discordSession, guildID string) {
+	if guildID == "" {
+		return
+	}
+
+	registeredCommandsLock.Lock()
+	defer registeredCommandsLock.Unlock()
+
+	registered, exists := registeredCommands[guildID]
+	if !exists {
+		return
+	}
+	wanted := commandsFor(guildID)
+	if slices.Equal(commandNames(registered), commandNames(wanted)) {
+		return
+	}
+
+	created, err := s.ApplicationCommandBulkOverwrite(botUserID(s), guildID, wanted)
+	if err != nil {
+		slog.Error("Error updating commands", "guild_id", guildID, "error", err)
+		return
+	}
+	registeredCommands[guildID] = created
+	slog.Info("Updated commands", "guild_id", guildID, "total", len(created))
+}
+
+// registerCommands registers commands in every guild the bot is in, falling
+// back to global commands when it isn't in any. GUILD_ID limits registration
+// to that one guild.
+func registerCommands(s discordSession, onlyGuildID string) error {
+	if onlyGuildID != "" {
+		return registerGuildCommands(s, onlyGuildID)
+	}
+
+	var guildIDs []string
+	state := stateOf(s)
+	state.RLock()
+	for _, guild := range state.Guilds {
+		if guildAllowed(guild.ID) {
+			guildIDs = append(guildIDs, guild.ID)
+		}
+	}
+	state.RUnlock()
+	if len(guildIDs) == 0 {
+		slog.Info("Not in any guilds, deploying commands globally")
+		return registerGuildCommands(s, "")
+	}
+
+	// Clear out global commands from older versions so they don't show up
+	// twice alongside the guild ones
+	_, err := s.ApplicationCommandBulkOverwrite(botUserID(s), "", []*discordgo.ApplicationCommand{})
+	if err != nil {
+		slog.Warn("Error removing global commands", "error", err)
+	}
+
+	registeredCommandsLock.Lock()
+	perGuildCommands = true
+	registeredCommandsLock.Unlock()
+
+	for _, guildID := range guildIDs {
+		err := registerGuildCommands(s, guildID)
+		if err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// registerJoinedGuild gives a guild joined after startup its commands.
+func registerJoinedGuild(s discordSession, guildID string) {
+	registeredCommandsLock.Lock()
+	perGuild := perGuildCommands
+	registeredCommandsLock.Unlock()
+	if !perGuild {
+		return
+	}
+
+	err := registerGuildCommands(s, guildID)
+	if err != nil {
+		slog.Error("Error registering commands", "guild_id", guildID, "error", err)
+	}
+}
+
+// unregisterCommands deletes every command registered by this run.
+func unregisterCommands(s discordSession) {
+	registeredCommandsLock.Lock()
+	defer registeredCommandsLock.Unlock()
+
+	removed, total := 0, 0
+	for guildID, created := range registeredCommands {
+		for _, cmd := range created {
+			total++
+			err := s.ApplicationCommandDelete(botUserID(s), guildID, cmd.ID)
+			if err != nil {
+				slog.Error("Error deleting command", "guild_id", guildID, "command", cmd.Name, "error", err)
+				continue
+			}
+			removed++
+		}
+	}
+	slog.Info("Removed commands", "removed", removed, "total", total)
+}
@@ -0,0 +1,70 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+)
+
+func TestGuildCommands(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverConfig ServerConfig
+		shown        []string
+		hidden       []string
+	}{
+		{
+			name:   "nothing set up",
+			shown:  []string{"set_azure_verification", "set_jwt_verification", "set_partner_verification", "add_verification_field", "verify_user"},
+			hidden: []string{"verify_microsoft", "disable_azure_verification", "disable_jwt_verification", "disable_partner_verification", "remove_verification_field"},
+		},
+		{
+			name:         "azure enabled",
+			serverConfig: ServerConfig{AzureTenantID: "0b0a1f4e-3c2d-4e5f-8a9b-1c2d3e4f5a6b"},
+			shown:        []string{"verify_microsoft", "disable_azure_verification", "set_azure_verification"},
+			hidden:       []string{"disable_jwt_verification"},
+		},
+		{
+			name:         "partner and fields set up",
+			serverConfig: ServerConfig{PartnerGuildID: "guild", VerificationFields: []VerificationField{{Name: "Course"}}},
+			shown:        []string{"disable_partner_verification", "remove_verification_field"},
+			hidden:       []string{"verify_microsoft"},
+		},
+		{
+			name:         "auto-kick running",
+			serverConfig: ServerConfig{VerificationTimeout: 48 * time.Hour},
+			shown:        []string{"pause_auto_kick"},
+			hidden:       []string{"resume_auto_kick"},
+		},
+		{
+			name:         "auto-kick paused",
+			serverConfig: ServerConfig{VerificationTimeout: 48 * time.Hour, AutoKickPaused: true},
+			shown:        []string{"resume_auto_kick"},
+			hidden:       []string{"pause_auto_kick"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			names := commandNames(guildCommands(tt.serverConfig))
+			for _, name := range tt.shown {
+				if !slices.Contains(names, name) {
+					t.Errorf("%s hidden, want it shown", name)
+				}
+			}
+			for _, name := range tt.hidden {
+				if slices.Contains(names, name) {
+					t.Errorf("%s shown, want it hidden", name)
+				}
+			}
+		})
+	}
+
+	// Every gated command must exist, or a typo would hide nothing
+	all := commandNames(commands)
+	for name := range featureCommands {
+		if !slices.Contains(all, name) {
+			t.Errorf("feature command %s isn't a registered command", name)
+		}
+	}
+}
@@ -0,0 +1,114 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func isNotFound(err error) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Response != nil && restErr.Response.StatusCode == http.StatusNotFound
+}
+
+// hasErrorCode reports whether err is a Discord API error with the given
+// JSON error code, e.g. discordgo.ErrCodeUnknownMember.
+func hasErrorCode(err error, code int) bool {
+	var restErr *discordgo.RESTError
+	return errors.As(err, &restErr) && restErr.Message != nil && restErr.Message.Code == code
+}
+
+// validateConfig checks that the channels and roles each guild refers to
+// still exist. Every problem is collected so admins can fix them all at
+// once; lookups that fail for other reasons are logged and skipped.
+func validateConfig(s discordSession) error {
+	configMutex.RLock()
+	servers := make(map[string]ServerConfig, len(config.Servers))
+	for guildID, serverConfig := range config.Servers {
+		servers[guildID] = serverConfig
+	}
+	configMutex.RUnlock()
+
+	var problems []error
+	for guildID, serverConfig := range servers {
+		if serverConfig.MemberAuditChannelID != "" {
+			channel, err := s.Channel(serverConfig.MemberAuditChannelID)
+			switch {
+			case isNotFound(err) || (err == nil && channel.GuildID != guildID):
+				problems = append(problems, fmt.Errorf("guild %s: member audit channel %s does not exist", guildID, serverConfig.MemberAuditChannelID))
+			case err != nil:
+				slog.Warn("Could not check member audit channel", "guild_id", guildID, "error", err)
+			}
+		}
+
+		if serverConfig.FallbackAuditChannelID != "" {
+			channel, err := s.Channel(serverConfig.FallbackAuditChannelID)
+			switch {
+			case isNotFound(err) || (err == nil && channel.GuildID != guildID):
+				problems = append(problems, fmt.Errorf("guild %s: fallback audit channel %s does not exist", guildID, serverConfig.FallbackAuditChannelID))
+			case err != nil:
+				slog.Warn("Could not check fallback audit channel", "guild_id", guildID, "error", err)
+			}
+		}
+
+		if serverConfig.UnverifiedRoleID != "" {
+			roles, err := s.GuildRoles(guildID)
+			if err != nil {
+				slog.Warn("Could not check unverified role", "guild_id", guildID, "error", err)
+				continue
+			}
+			found := false
+			for _, role := range roles {
+				if role.ID == serverConfig.UnverifiedRoleID {
+					found = true
+					break
+				}
+			}
+			if !found {
+				problems = append(problems, fmt.Errorf("guild %s: unverified role %s does not exist", guildID, serverConfig.UnverifiedRoleID))
+			}
+		}
+	}
+
+	for _, problem := range problems {
+		slog.Warn("Invalid config reference", "error", problem)
+	}
+	return errors.Join(problems...)
+}
+
+// Admins are reminded about a deleted unverified role at most this often,
+// rather than on every join
+const missingRoleWarningInterval = time.Hour
+
+var (
+	missingRoleWarnings     = make(map[string]time.Time)
+	missingRoleWarningsLock sync.Mutex
+)
+
+// warnMissingUnverifiedRole tells the guild's admins in the audit channel
+// that new members are joining without the unverified role.
+func warnMissingUnverifiedRole(s discordSession, guildID string, serverConfig ServerConfig, now time.Time) {
+	missingRoleWarningsLock.Lock()
+	last, warned := missingRoleWarnings[guildID]
+	if warned && now.Sub(last) < missingRoleWarningInterval {
+		missingRoleWarningsLock.Unlock()
+		return
+	}
+	missingRoleWarnings[guildID] = now
+	missingRoleWarningsLock.Unlock()
+
+	if serverConfig.MemberAuditChannelID == "" {
+		return
+	}
+	_, err := sendAuditMessage(s, serverConfig, &discordgo.MessageSend{
+		Content: fmt.Sprintf("⚠️ The unverified role (%s) no longer exists, so new members are joining without it. An admin needs to run /set_unverified_role.", serverConfig.UnverifiedRoleID),
+	})
+	if err != nil {
+		slog.Error("Error warning about missing unverified role", "guild_id", guildID, "error", err)
+	}
+}
@@ -0,0 +1,271 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/url"
+	"regexp"
+	"slices"
+	"sort"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Largest config patch accepted as an attachment
+const maxConfigPatchSize = 64 * 1024
+
+// applyConfigPatch merges the fields in patch into a copy of current,
+// rejecting unknown fields and values of the wrong type. It returns the
+// updated config and the names of the fields that were set.
+func applyConfigPatch(current ServerConfig, patch []byte) (ServerConfig, []string, error) {
+	var fields map[string]json.RawMessage
+	err := json.Unmarshal(patch, &fields)
+	if err != nil || fields == nil {
+		return current, nil, errors.New("the patch must be a JSON object")
+	}
+	if len(fields) == 0 {
+		return current, nil, errors.New("the patch doesn't set any fields")
+	}
+
+	// Round-trip through JSON so the patch can't modify slices shared with
+	// the live config
+	data, err := json.Marshal(current)
+	if err != nil {
+		return current, nil, err
+	}
+	var updated ServerConfig
+	err = json.Unmarshal(data, &updated)
+	if err != nil {
+		return current, nil, err
+	}
+
+	decoder := json.NewDecoder(bytes.NewReader(patch))
+	decoder.DisallowUnknownFields()
+	err = decoder.Decode(&updated)
+	if err != nil {
+		return current, nil, err
+	}
+
+	err = validateServerConfig(updated)
+	if err != nil {
+		return current, nil, err
+	}
+
+	names := make([]string, 0, len(fields))
+	for name := range fields {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return updated, names, nil
+}
+
+// validateServerConfig checks field values that the commands would normally
+// validate before saving.
+func validateServerConfig(serverConfig ServerConfig) error {
+	if serverConfig.RateLimitDuration < 0 {
+		return errors.New("rate_limit_duration cannot be negative")
+	}
+	if serverConfig.RateLimitDuration > maxRateLimitMinutes*time.Minute {
+		return fmt.Errorf("rate_limit_duration cannot be longer than %d minutes", maxRateLimitMinutes)
+	}
+	if serverConfig.RateLimitEnabled && serverConfig.RateLimitDuration == 0 {
+		return errors.New("rate_limit_duration must be set when rate limiting is enabled")
+	}
+	if serverConfig.DenialRetries < 0 {
+		return errors.New("denial_retries cannot be negative")
+	}
+	if serverConfig.BatchReviewWindow < 0 {
+		return errors.New("batch_review_window cannot be negative")
+	}
+	if serverConfig.ModActionCooldown < 0 {
+		return errors.New("mod_action_cooldown cannot be negative")
+	}
+	if serverConfig.JWKSURL != "" {
+		parsedURL, err := url.Parse(serverConfig.JWKSURL)
+		if err != nil || parsedURL.Scheme != "https" || parsedURL.Host == "" {
+			return errors.New("jwks_url must be a valid https:// URL")
+		}
+		if serverConfig.JWTIssuer == "" {
+			return errors.New("jwt_issuer must be set when jwks_url is set")
+		}
+	}
+	if serverConfig.AzureTenantID != "" && !tenantIDRegex.MatchString(serverConfig.AzureTenantID) {
+		return errors.New("azure_tenant_id must be a GUID")
+	}
+	if serverConfig.MembershipAPIURL != "" {
+		parsedURL, err := url.Parse(serverConfig.MembershipAPIURL)
+		if err != nil || (parsedURL.Scheme != "https" && parsedURL.Scheme != "http") || parsedURL.Host == "" {
+			return errors.New("membership_api_url must be a valid http:// or https:// URL")
+		}
+	}
+	if serverConfig.MembershipMode != "" && serverConfig.MembershipMode != "flag" && serverConfig.MembershipMode != "auto" {
+		return errors.New(`membership_mode must be "flag" or "auto"`)
+	}
+	if serverConfig.BulkBatchSize < 0 {
+		return errors.New("bulk_batch_size cannot be negative")
+	}
+	if serverConfig.BulkBatchInterval < 0 {
+		return errors.New("bulk_batch_interval cannot be negative")
+	}
+	if serverConfig.InvalidReplyLimit < 0 {
+		return errors.New("invalid_reply_limit cannot be negative")
+	}
+	if serverConfig.MaxAttempts < 0 {
+		return errors.New("max_attempts cannot be negative")
+	}
+	if serverConfig.AttemptCooldown < 0 || serverConfig.AttemptCooldown > maxAttemptCooldownMinutes*time.Minute {
+		return fmt.Errorf("attempt_cooldown must be between 0 and %d minutes", maxAttemptCooldownMinutes)
+	}
+	for _, domain := range serverConfig.AllowedEmailDomains {
+		if !domainRegex.MatchString(domain) {
+			return fmt.Errorf("allowed_email_domains entry %q is not a valid lowercase domain", domain)
+		}
+	}
+	if serverConfig.VerificationMode != "" && serverConfig.VerificationMode != "manual" && serverConfig.VerificationMode != "code" {
+		return errors.New(`verification_mode must be "manual" or "code"`)
+	}
+	if serverConfig.InviteLink != "" && !inviteLinkRegex.MatchString(serverConfig.InviteLink) {
+		return errors.New("invite_link must be a Discord invite such as https://discord.gg/abc123")
+	}
+	if serverConfig.VerificationTimeout < 0 {
+		return errors.New("verification_timeout cannot be negative")
+	}
+	if serverConfig.MinAccountAge < 0 || serverConfig.AccountAgeCutoff < 0 {
+		return errors.New("min_account_age and account_age_cutoff cannot be negative")
+	}
+	if serverConfig.MilestoneInterval < 0 {
+		return errors.New("milestone_interval cannot be negative")
+	}
+	if serverConfig.EmailRegexPattern != "" {
+		_, err := compileEmailPattern(serverConfig.EmailRegexPattern)
+		if err != nil {
+			return fmt.Errorf("email_regex_pattern is not a valid regular expression: %w", err)
+		}
+	}
+	if serverConfig.StudentIDRegex != "" {
+		_, err := compileStudentIDPattern(serverConfig.StudentIDRegex)
+		if err != nil {
+			return fmt.Errorf("student_id_regex is not a valid regular expression: %w", err)
+		}
+	}
+	if serverConfig.AuditThreadArchive != 0 && !slices.Contains(threadArchiveDurations, serverConfig.AuditThreadArchive) {
+		return errors.New("audit_thread_archive must be 60, 1440, 4320 or 10080 minutes")
+	}
+	if serverConfig.WarnThreshold < 0 {
+		return errors.New("warn_threshold cannot be negative")
+	}
+	if serverConfig.WarnTimeout < 0 || serverConfig.WarnTimeout > maxWarnTimeout {
+		return errors.New("warn_timeout must be between 0 and 28 days")
+	}
+	for _, field := range serverConfig.VerificationFields {
+		if field.Name == "" {
+			return errors.New("verification_fields entries must have a name")
+		}
+		if _, err := regexp.Compile(field.Pattern); err != nil {
+			return fmt.Errorf("verification field %s has an invalid pattern: %w", field.Name, err)
+		}
+	}
+	if serverConfig.SummaryTime != "" {
+		if _, err := time.Parse("15:04", serverConfig.SummaryTime); err != nil {
+			return errors.New("summary_time must be in HH:MM format")
+		}
+		if _, err := time.LoadLocation(serverConfig.SummaryTimezone); err != nil {
+			return fmt.Errorf("unknown summary_timezone %q", serverConfig.SummaryTimezone)
+		}
+	}
+	return nil
+}
+
+func fetchConfigPatchAttachment(attachment *discordgo.MessageAttachment) ([]byte, error) {
+	if attachment.Size > maxConfigPatchSize {
+		return nil, fmt.Errorf("the attachment is too large (max %d KB)", maxConfigPatchSize/1024)
+	}
+
+	resp, err := jwksClient.Get(attachment.URL)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	return io.ReadAll(io.LimitReader(resp.Body, maxConfigPatchSize))
+}
+
+func updateConfig(s discordSession, i *discordgo.InteractionCreate) {
+	data := i.ApplicationCommandData()
+	guildID := i.GuildID
+
+	var patch []byte
+	for _, option := range data.Options {
+		switch option.Name {
+		case "json":
+			patch = []byte(option.StringValue())
+		case "file":
+			attachmentID, _ := option.Value.(string)
+			attachment, exists := data.Resolved.Attachments[attachmentID]
+			if !exists {
+				continue
+			}
+			var err error
+			patch, err = fetchConfigPatchAttachment(attachment)
+			if err != nil {
+				slog.Error("Error downloading config patch", "error", err)
+				s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+					Type: discordgo.InteractionResponseChannelMessageWithSource,
+					Data: &discordgo.InteractionResponseData{
+						Content: "Error reading attachment: " + err.Error(),
+					},
+				})
+				return
+			}
+		}
+	}
+
+	if len(patch) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Please provide the changes as JSON text or an attached file",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	updated, fields, err := applyConfigPatch(config.Servers[guildID], patch)
+	if err == nil {
+		config.Servers[guildID] = updated
+	}
+	configMutex.Unlock()
+
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Invalid config update: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	err = saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Updated %d setting(s) successfully! :white_check_mark: %v", len(fields), fields),
+		},
+	})
+}
@@ -0,0 +1,81 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestApplyConfigPatch(t *testing.T) {
+	current := ServerConfig{
+		MemberAuditChannelID: "audit",
+		AllowedEmailDomains:  []string{"uclan.ac.uk"},
+	}
+
+	tests := []struct {
+		name       string
+		patch      string
+		wantErr    bool
+		wantFields []string
+		check      func(ServerConfig) bool
+	}{
+		{
+			name:       "sets fields and keeps the rest",
+			patch:      `{"rate_limit_enabled": true, "rate_limit_duration": 300000000000}`,
+			wantFields: []string{"rate_limit_duration", "rate_limit_enabled"},
+			check: func(c ServerConfig) bool {
+				return c.RateLimitEnabled && c.RateLimitDuration == 5*time.Minute && c.MemberAuditChannelID == "audit"
+			},
+		},
+		{
+			name:       "replaces lists",
+			patch:      `{"allowed_email_domains": ["example.ac.uk"]}`,
+			wantFields: []string{"allowed_email_domains"},
+			check: func(c ServerConfig) bool {
+				return reflect.DeepEqual(c.AllowedEmailDomains, []string{"example.ac.uk"})
+			},
+		},
+		{name: "not an object", patch: `[1, 2]`, wantErr: true},
+		{name: "empty object", patch: `{}`, wantErr: true},
+		{name: "unknown field", patch: `{"no_such_setting": 1}`, wantErr: true},
+		{name: "wrong type", patch: `{"denial_retries": "three"}`, wantErr: true},
+		{name: "fails validation", patch: `{"denial_retries": -1}`, wantErr: true},
+		{name: "rate limit without a duration", patch: `{"rate_limit_enabled": true}`, wantErr: true},
+		{name: "unknown membership mode", patch: `{"membership_mode": "sometimes"}`, wantErr: true},
+		{name: "invalid student ID pattern", patch: `{"student_id_regex": "^G(\\d{8}$"}`, wantErr: true},
+		{
+			name:       "valid student ID pattern",
+			patch:      `{"student_id_regex": "^G\\d{8}$"}`,
+			wantFields: []string{"student_id_regex"},
+			check: func(c ServerConfig) bool {
+				return c.StudentIDRegex == `^G\d{8}$`
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			updated, fields, err := applyConfigPatch(current, []byte(tt.patch))
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if err != nil {
+				if !reflect.DeepEqual(updated, current) {
+					t.Errorf("config changed despite the error: %+v", updated)
+				}
+				return
+			}
+			if !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("fields = %v, want %v", fields, tt.wantFields)
+			}
+			if !tt.check(updated) {
+				t.Errorf("unexpected config %+v", updated)
+			}
+		})
+	}
+
+	// The patch works on a copy, so the live config's lists are untouched
+	if !reflect.DeepEqual(current.AllowedEmailDomains, []string{"uclan.ac.uk"}) {
+		t.Errorf("patch modified the original config: %v", current.AllowedEmailDomains)
+	}
+}
@@ -0,0 +1,221 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ldavidson8/computing-society-mod-bot/store"
+)
+
+var configStore *store.Store
+
+// Config changes are written at most this often, so a burst of commands
+// becomes a single write per guild
+const configSaveDelay = 2 * time.Second
+
+var (
+	dirtyGuildConfigs = make(map[string]bool)
+	configSaveTimer   *time.Timer
+	configSaveLock    sync.Mutex
+	// Held for a whole flush, so the shutdown flush waits for one already
+	// running in the background
+	configFlushLock sync.Mutex
+	// Why each guild's last write failed, until one succeeds, so the next
+	// command to change its config can tell the admin
+	configSaveErrors = make(map[string]error)
+)
+
+func queueConfigSave(guildID string) {
+	configSaveLock.Lock()
+	defer configSaveLock.Unlock()
+
+	dirtyGuildConfigs[guildID] = true
+	if configSaveTimer == nil {
+		configSaveTimer = time.AfterFunc(configSaveDelay, func() {
+			err := flushConfigSaves()
+			if err != nil {
+				slog.Error("Error saving config", "error", err)
+			}
+		})
+	}
+}
+
+// flushConfigSaves writes every guild config changed since the last flush.
+// Guilds that fail are kept queued for the next attempt.
+func flushConfigSaves() error {
+	configFlushLock.Lock()
+	defer configFlushLock.Unlock()
+
+	configSaveLock.Lock()
+	dirty := dirtyGuildConfigs
+	dirtyGuildConfigs = make(map[string]bool)
+	if configSaveTimer != nil {
+		configSaveTimer.Stop()
+		configSaveTimer = nil
+	}
+	configSaveLock.Unlock()
+
+	var errs []error
+	for guildID := range dirty {
+		// Guilds no longer in the config have been reset or removed
+		configMutex.RLock()
+		serverConfig, exists := config.Servers[guildID]
+		data, err := json.Marshal(serverConfig)
+		configMutex.RUnlock()
+		if err == nil && !exists {
+			err = configStore.DeleteGuildConfig(guildID)
+		} else if err == nil {
+			err = configStore.SaveGuildConfig(guildID, data)
+		}
+		configSaveLock.Lock()
+		if err != nil {
+			configSaveErrors[guildID] = err
+		} else {
+			delete(configSaveErrors, guildID)
+		}
+		configSaveLock.Unlock()
+		if err != nil {
+			errs = append(errs, fmt.Errorf("guild %s: %w", guildID, err))
+			queueConfigSave(guildID)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// lastConfigSaveError returns why the guild's config last failed to save, or
+// nil if its last write went through.
+func lastConfigSaveError(guildID string) error {
+	configSaveLock.Lock()
+	defer configSaveLock.Unlock()
+	return configSaveErrors[guildID]
+}
+
+// legacyGuildConfigs reads configs saved by older versions, from both the
+// shared ./data/config.json and the per-guild files in ./data/guilds. Per-guild
+// files win if a guild appears in both.
+func legacyGuildConfigs() (map[string][]byte, error) {
+	configs := make(map[string][]byte)
+
+	data, err := os.ReadFile("./data/config.json")
+	if err == nil {
+		var legacy struct {
+			Servers map[string]json.RawMessage `json:"servers"`
+		}
+		err = json.Unmarshal(data, &legacy)
+		if err != nil {
+			return nil, fmt.Errorf("config.json: %w", err)
+		}
+		for guildID, serverConfig := range legacy.Servers {
+			configs[guildID] = serverConfig
+		}
+	} else if !os.IsNotExist(err) {
+		return nil, err
+	}
+
+	entries, err := os.ReadDir("./data/guilds")
+	if err != nil && !os.IsNotExist(err) {
+		return nil, err
+	}
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join("./data/guilds", entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		if !json.Valid(data) {
+			return nil, fmt.Errorf("%s: invalid JSON", entry.Name())
+		}
+		configs[strings.TrimSuffix(entry.Name(), ".json")] = data
+	}
+	return configs, nil
+}
+
+// openConfigStore opens the config database. The first time it's created, any
+// JSON config is imported so upgrading keeps every guild's settings.
+func openConfigStore(path string) (*store.Store, error) {
+	err := os.MkdirAll(filepath.Dir(path), os.ModePerm)
+	if err != nil {
+		return nil, err
+	}
+
+	_, err = os.Stat(path)
+	isNew := os.IsNotExist(err)
+
+	configStore, err := store.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	if !isNew {
+		return configStore, nil
+	}
+
+	legacy, err := legacyGuildConfigs()
+	if err == nil && len(legacy) > 0 {
+		err = configStore.ImportGuildConfigs(legacy)
+	}
+	if err != nil {
+		// Remove the half-made database so the import is retried next start
+		configStore.Close()
+		os.Remove(path)
+		return nil, fmt.Errorf("importing JSON config: %w", err)
+	}
+	if len(legacy) > 0 {
+		slog.Info("Imported JSON config into the config store", "guilds", len(legacy))
+	}
+	return configStore, nil
+}
+
+// reloadConfig re-reads every guild's config from the store, picking up
+// changes made outside the bot without a restart.
+func reloadConfig(s discordSession, i *discordgo.InteractionCreate) {
+	if !isBotOwner(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Only the bot owner can use this command",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// Write out queued changes first so they aren't overwritten
+	err := flushConfigSaves()
+	if err == nil {
+		err = loadConfig()
+	}
+	if err != nil {
+		slog.Error("Error reloading config", "error", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error reloading config, the previous config is still in use: " + err.Error(),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	configMutex.RLock()
+	guilds := len(config.Servers)
+	configMutex.RUnlock()
+
+	slog.Info("Config reloaded", "guilds", guilds)
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Config reloaded successfully! :white_check_mark: %d guild(s) loaded", guilds),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
@@ -0,0 +1,128 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/ldavidson8/computing-society-mod-bot/store"
+)
+
+func TestSaveConfigSurfacesFlushErrors(t *testing.T) {
+	const guildID = "100000000000000009"
+	useServerConfig(t, guildID, ServerConfig{MaxAttempts: 3})
+
+	previous := configStore
+	t.Cleanup(func() { configStore = previous })
+
+	// A closed store fails every write
+	closed, err := store.Open(filepath.Join(t.TempDir(), "closed.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	closed.Close()
+	configStore = closed
+
+	if err := saveConfig(guildID); err != nil {
+		t.Fatalf("first saveConfig() = %v, want nil before any write has failed", err)
+	}
+	if err := flushConfigSaves(); err == nil {
+		t.Fatal("flushConfigSaves() = nil, want an error from the closed store")
+	}
+	if err := saveConfig(guildID); err == nil {
+		t.Error("saveConfig() = nil after a failed write, want the failure reported")
+	}
+
+	// Once storage works again the error clears
+	working, err := store.Open(filepath.Join(t.TempDir(), "working.db"))
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { working.Close() })
+	configStore = working
+
+	if err := flushConfigSaves(); err != nil {
+		t.Fatalf("flushConfigSaves() = %v", err)
+	}
+	if err := saveConfig(guildID); err != nil {
+		t.Errorf("saveConfig() = %v after a successful write, want nil", err)
+	}
+	if err := flushConfigSaves(); err != nil {
+		t.Fatalf("flushConfigSaves() = %v", err)
+	}
+}
+
+func TestOpenConfigStoreImportsLegacyJSON(t *testing.T) {
+	// Legacy files live under ./data, which TestMain points at a temp dir
+	if err := os.MkdirAll("./data/guilds", 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Remove("./data/config.json")
+		os.RemoveAll("./data/guilds")
+	})
+
+	shared := `{"servers":{"guild-a":{"max_attempts":1},"guild-b":{"max_attempts":2}}}`
+	if err := os.WriteFile("./data/config.json", []byte(shared), 0644); err != nil {
+		t.Fatal(err)
+	}
+	// Per-guild files win over the shared file
+	if err := os.WriteFile("./data/guilds/guild-b.json", []byte(`{"max_attempts":3}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bot.db")
+	imported, err := openConfigStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	configs, err := imported.GuildConfigs()
+	imported.Close()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]byte{
+		"guild-a": []byte(`{"max_attempts":1}`),
+		"guild-b": []byte(`{"max_attempts":3}`),
+	}
+	if !reflect.DeepEqual(configs, want) {
+		t.Errorf("imported configs = %q, want %q", configs, want)
+	}
+
+	// An existing database isn't imported into again
+	if err := os.WriteFile("./data/guilds/guild-c.json", []byte(`{}`), 0644); err != nil {
+		t.Fatal(err)
+	}
+	reopened, err := openConfigStore(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+	configs, err = reopened.GuildConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if _, ok := configs["guild-c"]; ok {
+		t.Errorf("legacy JSON imported into an existing database")
+	}
+}
+
+func TestOpenConfigStoreRejectsInvalidLegacyJSON(t *testing.T) {
+	if err := os.MkdirAll("./data/guilds", 0755); err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { os.RemoveAll("./data/guilds") })
+	if err := os.WriteFile("./data/guilds/guild-a.json", []byte(`{not json`), 0644); err != nil {
+		t.Fatal(err)
+	}
+
+	path := filepath.Join(t.TempDir(), "bot.db")
+	if _, err := openConfigStore(path); err == nil {
+		t.Fatal("openConfigStore() succeeded, want the import error")
+	}
+	// The half-made database is removed so the import is retried
+	if _, err := os.Stat(path); !os.IsNotExist(err) {
+		t.Errorf("database left behind after a failed import: %v", err)
+	}
+}
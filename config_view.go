@@ -0,0 +1,127 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const notSet = "not set"
+
+func channelValue(id string) string {
+	if id == "" {
+		return notSet
+	}
+	return "<#" + id + ">"
+}
+
+func roleValue(id string) string {
+	if id == "" {
+		return notSet
+	}
+	return "<@&" + id + ">"
+}
+
+func durationValue(d time.Duration) string {
+	if d == 0 {
+		return notSet
+	}
+	return d.String()
+}
+
+// timeoutValue describes the verification timeout and whether the auto-kick
+// is paused
+func timeoutValue(serverConfig ServerConfig) string {
+	if serverConfig.AutoKickPaused {
+		return durationValue(serverConfig.VerificationTimeout) + " (paused)"
+	}
+	return durationValue(serverConfig.VerificationTimeout)
+}
+
+// attemptsValue describes the invalid attempt limit and what happens once
+// it's reached
+func attemptsValue(serverConfig ServerConfig) string {
+	if serverConfig.MaxAttempts == 0 {
+		return "0"
+	}
+	if serverConfig.AttemptCooldown > 0 {
+		return fmt.Sprintf("%d (then %s cooldown)", serverConfig.MaxAttempts, serverConfig.AttemptCooldown)
+	}
+	return fmt.Sprintf("%d (then kick)", serverConfig.MaxAttempts)
+}
+
+func textValue(value string) string {
+	if value == "" {
+		return notSet
+	}
+	return value
+}
+
+// configEmbed lays out a guild's settings, one field per setting.
+func configEmbed(serverConfig ServerConfig) *discordgo.MessageEmbed {
+	rateLimit := "disabled"
+	if serverConfig.RateLimitEnabled {
+		rateLimit = "enabled, " + serverConfig.RateLimitDuration.String()
+	}
+
+	verificationMode := serverConfig.VerificationMode
+	if verificationMode == "" {
+		verificationMode = "manual"
+	}
+
+	field := func(name, value string) *discordgo.MessageEmbedField {
+		return &discordgo.MessageEmbedField{Name: name, Value: value, Inline: true}
+	}
+
+	return &discordgo.MessageEmbed{
+		Title: "Server configuration",
+		Fields: []*discordgo.MessageEmbedField{
+			field("Audit channel", channelValue(serverConfig.MemberAuditChannelID)),
+			field("Fallback audit channel", channelValue(serverConfig.FallbackAuditChannelID)),
+			field("Audit threads", fmt.Sprint(serverConfig.UseAuditThreads)),
+			field("Unverified role", roleValue(serverConfig.UnverifiedRoleID)),
+			field("Verified role", roleValue(serverConfig.VerifiedRoleID)),
+			field("Reviewer role", roleValue(serverConfig.ReviewerRoleID)),
+			field("Rate limit", rateLimit),
+			field("Email domains", strings.Join(guildEmailDomains(serverConfig), ", ")),
+			field("Email pattern", textValue(serverConfig.EmailRegexPattern)),
+			field("Student ID pattern", textValue(serverConfig.StudentIDRegex)),
+			field("Allowlisted emails", fmt.Sprint(len(serverConfig.EmailAllowlist))),
+			field("Blocklist entries", fmt.Sprintf("%d (kick %t)", len(serverConfig.Blocklist), serverConfig.BlocklistKick)),
+			field("Verification mode", verificationMode),
+			field("Dry run", fmt.Sprint(dryRun)),
+			field("Denial retries", fmt.Sprint(serverConfig.DenialRetries)),
+			field("Max invalid attempts", attemptsValue(serverConfig)),
+			field("New account age", fmt.Sprintf("flag under %s, kick under %s", durationValue(serverConfig.MinAccountAge), durationValue(serverConfig.AccountAgeCutoff))),
+			field("Appeals", fmt.Sprint(serverConfig.AppealsEnabled)),
+			field("Batch review window", durationValue(serverConfig.BatchReviewWindow)),
+			field("Mod action cooldown", durationValue(serverConfig.ModActionCooldown)),
+			field("Verification timeout", timeoutValue(serverConfig)),
+			field("Warning threshold", fmt.Sprintf("%d (timeout %s)", serverConfig.WarnThreshold, durationValue(serverConfig.WarnTimeout))),
+			field("Verification channel", channelValue(serverConfig.VerificationChannelID)),
+			field("Verification messages", fmt.Sprint(len(serverConfig.VerificationMessages))),
+			field("Welcome voice channel", channelValue(serverConfig.WelcomeVoiceChannelID)),
+			field("Welcome text channel", channelValue(serverConfig.WelcomeChannelID)),
+			field("Milestone channel", channelValue(serverConfig.MilestoneChannelID)),
+			field("Partner server", textValue(serverConfig.PartnerGuildID)),
+			field("Review summary time", textValue(serverConfig.SummaryTime)),
+			field("Membership API", textValue(serverConfig.MembershipAPIURL)),
+		},
+	}
+}
+
+func showConfig(s discordSession, i *discordgo.InteractionCreate) {
+	configMutex.RLock()
+	serverConfig := config.Servers[i.GuildID]
+	configMutex.RUnlock()
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{configEmbed(serverConfig)},
+			Flags:  discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
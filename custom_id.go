@@ -0,0 +1,31 @@
+package main
+
+import (
+	"strings"
+)
+
+// Fields after the action are joined with this, so they mustn't contain it.
+// Snowflake IDs never do.
+const customIDFieldSeparator = ":"
+
+// Discord rejects components whose customID is longer than this
+const maxCustomIDLength = 100
+
+// encodeCustomID builds a button customID of the form action_field1:field2
+func encodeCustomID(action string, fields ...string) string {
+	return action + "_" + strings.Join(fields, customIDFieldSeparator)
+}
+
+// decodeCustomID splits a customID built by encodeCustomID back into its
+// action and fields. Only the first underscore separates the action, so
+// payloads may contain underscores.
+func decodeCustomID(customID string) (string, []string, bool) {
+	if len(customID) > maxCustomIDLength {
+		return "", nil, false
+	}
+	parts := strings.SplitN(customID, "_", 2)
+	if len(parts) != 2 || parts[0] == "" || parts[1] == "" {
+		return "", nil, false
+	}
+	return parts[0], strings.Split(parts[1], customIDFieldSeparator), true
+}
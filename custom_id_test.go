@@ -0,0 +1,56 @@
+package main
+
+import (
+	"reflect"
+	"strings"
+	"testing"
+)
+
+func TestEncodeCustomID(t *testing.T) {
+	tests := []struct {
+		action string
+		fields []string
+		want   string
+	}{
+		{action: "approve", fields: []string{"123"}, want: "approve_123"},
+		{action: "partner", fields: []string{"123", "456"}, want: "partner_123:456"},
+	}
+
+	for _, tt := range tests {
+		got := encodeCustomID(tt.action, tt.fields...)
+		if got != tt.want {
+			t.Errorf("encodeCustomID(%q, %q) = %q, want %q", tt.action, tt.fields, got, tt.want)
+		}
+
+		action, fields, ok := decodeCustomID(got)
+		if !ok || action != tt.action || !reflect.DeepEqual(fields, tt.fields) {
+			t.Errorf("decodeCustomID(%q) = %q, %q, %v, want a round trip", got, action, fields, ok)
+		}
+	}
+}
+
+func TestDecodeCustomID(t *testing.T) {
+	tests := []struct {
+		name       string
+		customID   string
+		wantAction string
+		wantFields []string
+		wantOK     bool
+	}{
+		{name: "single field", customID: "deny_123", wantAction: "deny", wantFields: []string{"123"}, wantOK: true},
+		{name: "underscores in payload", customID: "resetconfig_a_b", wantAction: "resetconfig", wantFields: []string{"a_b"}, wantOK: true},
+		{name: "no separator", customID: "guildchoice"},
+		{name: "missing action", customID: "_123"},
+		{name: "missing fields", customID: "approve_"},
+		{name: "too long", customID: "approve_" + strings.Repeat("1", maxCustomIDLength)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			action, fields, ok := decodeCustomID(tt.customID)
+			if ok != tt.wantOK || action != tt.wantAction || !reflect.DeepEqual(fields, tt.wantFields) {
+				t.Errorf("decodeCustomID(%q) = %q, %q, %v, want %q, %q, %v", tt.customID, action, fields, ok, tt.wantAction, tt.wantFields, tt.wantOK)
+			}
+		})
+	}
+}
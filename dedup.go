@@ -0,0 +1,43 @@
+package main
+
+import (
+	"strings"
+	"sync"
+	"time"
+)
+
+// Repeated submissions within this window are dropped, whatever the guild's
+// rate limit settings
+const duplicateWindow = 30 * time.Second
+
+type recentSubmission struct {
+	Content string
+	At      time.Time
+}
+
+var (
+	recentSubmissions     = make(map[string]recentSubmission)
+	recentSubmissionsLock sync.Mutex
+)
+
+// isDuplicateSubmission reports whether the user sent the same thing moments
+// ago, e.g. a double paste or a repeated gateway event, and remembers this
+// submission otherwise.
+func isDuplicateSubmission(userID, content string, now time.Time) bool {
+	content = strings.TrimSpace(content)
+
+	recentSubmissionsLock.Lock()
+	defer recentSubmissionsLock.Unlock()
+
+	for id, submission := range recentSubmissions {
+		if now.Sub(submission.At) >= duplicateWindow {
+			delete(recentSubmissions, id)
+		}
+	}
+
+	if previous, ok := recentSubmissions[userID]; ok && previous.Content == content {
+		return true
+	}
+	recentSubmissions[userID] = recentSubmission{Content: content, At: now}
+	return false
+}
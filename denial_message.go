@@ -0,0 +1,123 @@
+package main
+
+import (
+	"regexp"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Used until a guild sets its own invite link
+const defaultInviteLink = "https://discord.gg/CEgCy5ejag"
+
+var inviteLinkRegex = regexp.MustCompile(`^https://(discord\.gg|discord\.com/invite)/[A-Za-z0-9-]+$`)
+
+// denialDM builds the message sent to members before a moderator's denial
+// kicks them, substituting the guild's invite link.
+func denialDM(serverConfig ServerConfig) string {
+	message := serverConfig.DenialMessage
+	if message == "" {
+		message = defaultDenialMessage
+	}
+	return withInviteLink(message, serverConfig)
+}
+
+// autoKickDM builds the message sent to members kicked for not verifying in
+// time, which is less personal than a moderator's denial.
+func autoKickDM(serverConfig ServerConfig) string {
+	message := serverConfig.AutoKickMessage
+	if message == "" {
+		message = defaultAutoKickMessage
+	}
+	return withInviteLink(message, serverConfig)
+}
+
+func withInviteLink(message string, serverConfig ServerConfig) string {
+	inviteLink := serverConfig.InviteLink
+	if inviteLink == "" {
+		inviteLink = defaultInviteLink
+	}
+	return strings.ReplaceAll(message, "{invite_link}", inviteLink)
+}
+
+func setDenialMessage(s discordSession, i *discordgo.InteractionCreate) {
+	var message, inviteLink string
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "message":
+			message = strings.TrimSpace(option.StringValue())
+		case "invite_link":
+			inviteLink = strings.TrimSpace(option.StringValue())
+		}
+	}
+	guildID := i.GuildID
+
+	if inviteLink != "" && !inviteLinkRegex.MatchString(inviteLink) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Invalid invite link. Please use a link like https://discord.gg/abc123",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.DenialMessage = message
+	serverConfig.InviteLink = inviteLink
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Denial message set successfully! :white_check_mark: Denied members will see:\n" + denialDM(serverConfig),
+		},
+	})
+}
+
+func setAutoKickMessage(s discordSession, i *discordgo.InteractionCreate) {
+	var message string
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Name == "message" {
+			message = strings.TrimSpace(option.StringValue())
+		}
+	}
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.AutoKickMessage = message
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Auto-kick message set successfully! :white_check_mark: Members kicked for not verifying in time will see:\n" + autoKickDM(serverConfig),
+		},
+	})
+}
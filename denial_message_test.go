@@ -0,0 +1,110 @@
+package main
+
+import (
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestKickMessages(t *testing.T) {
+	tests := []struct {
+		name         string
+		serverConfig ServerConfig
+		wantDenial   string
+		wantAutoKick string
+	}{
+		{
+			name:         "defaults",
+			wantDenial:   withInviteLink(defaultDenialMessage, ServerConfig{}),
+			wantAutoKick: withInviteLink(defaultAutoKickMessage, ServerConfig{}),
+		},
+		{
+			name: "both customised",
+			serverConfig: ServerConfig{
+				DenialMessage:   "Denied, rejoin at {invite_link}",
+				AutoKickMessage: "Timed out, rejoin at {invite_link}",
+				InviteLink:      "https://discord.gg/abc123",
+			},
+			wantDenial:   "Denied, rejoin at https://discord.gg/abc123",
+			wantAutoKick: "Timed out, rejoin at https://discord.gg/abc123",
+		},
+		{
+			name:         "only the denial customised",
+			serverConfig: ServerConfig{DenialMessage: "Denied"},
+			wantDenial:   "Denied",
+			wantAutoKick: withInviteLink(defaultAutoKickMessage, ServerConfig{}),
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := denialDM(tt.serverConfig); got != tt.wantDenial {
+				t.Errorf("denialDM() = %q, want %q", got, tt.wantDenial)
+			}
+			if got := autoKickDM(tt.serverConfig); got != tt.wantAutoKick {
+				t.Errorf("autoKickDM() = %q, want %q", got, tt.wantAutoKick)
+			}
+		})
+	}
+}
+
+// dmTo returns the content of the first DM sent to the user
+func dmTo(s *fakeSession, userID string) string {
+	for _, message := range s.sent {
+		if message.ChannelID == "dm-"+userID {
+			return message.Data.Content
+		}
+	}
+	return ""
+}
+
+func TestKickMessagePerSource(t *testing.T) {
+	const guildID = "100000000000000008"
+	serverConfig := ServerConfig{
+		MemberAuditChannelID: "audit",
+		UnverifiedRoleID:     "unverified",
+		VerificationTimeout:  48 * time.Hour,
+		DenialMessage:        "Denied by a moderator",
+		AutoKickMessage:      "Not verified in time",
+	}
+	useServerConfig(t, guildID, serverConfig)
+
+	t.Run("auto-kick", func(t *testing.T) {
+		const userID = "200000000000000041"
+		now := time.Now()
+		s := &fakeSession{}
+		kickUnverifiedMembers(s, guildID, serverConfig, []*discordgo.Member{{
+			User:     &discordgo.User{ID: userID},
+			Roles:    []string{"unverified"},
+			JoinedAt: now.Add(-72 * time.Hour),
+		}}, now)
+
+		if !s.called("GuildMemberDelete " + guildID + " " + userID) {
+			t.Fatalf("member not kicked (calls %q)", s.calls)
+		}
+		if got := dmTo(s, userID); got != "Not verified in time" {
+			t.Errorf("DM = %q, want the auto-kick message", got)
+		}
+	})
+
+	t.Run("moderator denial", func(t *testing.T) {
+		const userID = "200000000000000042"
+		s := &fakeSession{}
+		handleButton(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:      discordgo.InteractionMessageComponent,
+			GuildID:   guildID,
+			ChannelID: "audit",
+			Message:   &discordgo.Message{ID: "audit-kick-source"},
+			Member:    &discordgo.Member{User: &discordgo.User{ID: "300000000000000003"}},
+			Data:      discordgo.MessageComponentInteractionData{CustomID: encodeCustomID("deny", userID)},
+		}})
+
+		if !s.called("GuildMemberDelete " + guildID + " " + userID) {
+			t.Fatalf("member not kicked (calls %q)", s.calls)
+		}
+		if got := dmTo(s, userID); got != "Denied by a moderator" {
+			t.Errorf("DM = %q, want the denial message", got)
+		}
+	})
+}
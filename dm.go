@@ -0,0 +1,47 @@
+package main
+
+import (
+	"errors"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// errDMsClosed means the user doesn't accept DMs from the bot, usually
+// because of their privacy settings, as opposed to the request failing.
+var errDMsClosed = errors.New("user has DMs closed")
+
+func sendDMComplex(s discordSession, userID string, data *discordgo.MessageSend) error {
+	channel, err := s.UserChannelCreate(userID)
+	if err != nil {
+		return err
+	}
+
+	err = withRetry(func() error {
+		_, err := s.ChannelMessageSendComplex(channel.ID, data)
+		return err
+	})
+	if hasErrorCode(err, discordgo.ErrCodeCannotSendMessagesToThisUser) {
+		return errDMsClosed
+	}
+	return err
+}
+
+// sendDM sends a plain DM, returning errDMsClosed if the user can't receive it.
+func sendDM(s discordSession, userID, content string) error {
+	return sendDMComplex(s, userID, &discordgo.MessageSend{Content: content})
+}
+
+// dmClosedNote is added to audit messages so moderators know the user wasn't
+// told about the decision.
+func dmClosedNote(userID string) string {
+	return "\n⚠️ <@" + userID + "> has DMs closed and couldn't be messaged. Please let them know another way."
+}
+
+// dmFailedNote is dmClosedNote for any DM error, naming the error when it
+// wasn't just closed DMs.
+func dmFailedNote(userID string, err error) string {
+	if errors.Is(err, errDMsClosed) {
+		return dmClosedNote(userID)
+	}
+	return "\n⚠️ <@" + userID + "> couldn't be messaged (" + err.Error() + "). Please let them know another way."
+}
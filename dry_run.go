@@ -0,0 +1,42 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+)
+
+// In dry-run mode role changes and kicks are only logged, so a new deployment
+// can be tried out without touching anyone. DMs and audit messages still go
+// out as normal.
+var dryRun bool
+
+func loadDryRun() {
+	dryRun = os.Getenv("DRY_RUN") == "true"
+	if dryRun {
+		slog.Warn("Dry run enabled: roles won't be changed and nobody will be kicked")
+	}
+}
+
+func memberRoleAdd(s discordSession, guildID, userID, roleID string) error {
+	if dryRun {
+		slog.Info("Dry run: would add role", "guild_id", guildID, "user_id", userID, "role_id", roleID)
+		return nil
+	}
+	return s.GuildMemberRoleAdd(guildID, userID, roleID)
+}
+
+func memberRoleRemove(s discordSession, guildID, userID, roleID string) error {
+	if dryRun {
+		slog.Info("Dry run: would remove role", "guild_id", guildID, "user_id", userID, "role_id", roleID)
+		return nil
+	}
+	return s.GuildMemberRoleRemove(guildID, userID, roleID)
+}
+
+func memberKick(s discordSession, guildID, userID string) error {
+	if dryRun {
+		slog.Info("Dry run: would kick member", "guild_id", guildID, "user_id", userID)
+		return nil
+	}
+	return s.GuildMemberDelete(guildID, userID)
+}
@@ -0,0 +1,107 @@
+package main
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// emailAllowlisted reports whether the email is one the guild has let in
+// regardless of domain, such as an alumnus's personal address
+func emailAllowlisted(email string, allowlist []string) bool {
+	for _, allowed := range allowlist {
+		if strings.EqualFold(email, allowed) {
+			return true
+		}
+	}
+	return false
+}
+
+func allowEmail(s discordSession, i *discordgo.InteractionCreate) {
+	email := strings.ToLower(strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue()))
+	guildID := i.GuildID
+
+	if !emailRegex.MatchString(email) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "That doesn't look like an email address",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	added := !emailAllowlisted(email, serverConfig.EmailAllowlist)
+	if added {
+		serverConfig.EmailAllowlist = append(serverConfig.EmailAllowlist, email)
+	}
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := fmt.Sprintf("%s added to the email allowlist successfully! :white_check_mark:", email)
+	if !added {
+		content = fmt.Sprintf("%s is already on the email allowlist", email)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func disallowEmail(s discordSession, i *discordgo.InteractionCreate) {
+	email := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	var remaining []string
+	for _, allowed := range serverConfig.EmailAllowlist {
+		if !strings.EqualFold(allowed, email) {
+			remaining = append(remaining, allowed)
+		}
+	}
+	removed := len(remaining) != len(serverConfig.EmailAllowlist)
+	serverConfig.EmailAllowlist = remaining
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := fmt.Sprintf("%s removed from the email allowlist successfully! :white_check_mark:", email)
+	if !removed {
+		content = fmt.Sprintf("%s isn't on the email allowlist", email)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
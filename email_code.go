@@ -0,0 +1,114 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net"
+	"net/smtp"
+	"os"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// mailSender delivers verification codes by email, mirroring smsSender.
+type mailSender interface {
+	SendMail(to, subject, body string) error
+}
+
+type smtpSender struct {
+	addr     string
+	host     string
+	username string
+	password string
+	from     string
+}
+
+func (m *smtpSender) SendMail(to, subject, body string) error {
+	var auth smtp.Auth
+	if m.username != "" {
+		auth = smtp.PlainAuth("", m.username, m.password, m.host)
+	}
+	msg := fmt.Sprintf("From: %s\r\nTo: %s\r\nSubject: %s\r\n\r\n%s\r\n", m.from, to, subject, body)
+	return smtp.SendMail(m.addr, auth, m.from, []string{to}, []byte(msg))
+}
+
+// Configured from SMTP_* env vars at startup; nil when email codes are
+// unavailable
+var mailProvider mailSender
+
+func loadMailProvider() {
+	host := os.Getenv("SMTP_HOST")
+	if host == "" {
+		return
+	}
+	port := os.Getenv("SMTP_PORT")
+	if port == "" {
+		port = "587"
+	}
+	mailProvider = &smtpSender{
+		addr:     net.JoinHostPort(host, port),
+		host:     host,
+		username: os.Getenv("SMTP_USERNAME"),
+		password: os.Getenv("SMTP_PASSWORD"),
+		from:     os.Getenv("SMTP_FROM"),
+	}
+}
+
+func startEmailCodeVerification(s discordSession, m *discordgo.MessageCreate, guildID, email string) {
+	if mailProvider == nil {
+		slog.Warn("Guild uses code verification but no SMTP server is configured", "guild_id", guildID)
+		replyToSubmission(s, m, "Sorry, email verification is temporarily unavailable. Please contact a moderator.")
+		return
+	}
+
+	code, err := issueCode(guildID, m.Author.ID, time.Now())
+	if err != nil {
+		slog.Error("Error generating verification code", "error", err)
+		return
+	}
+
+	err = mailProvider.SendMail(email, "Your verification code", fmt.Sprintf("Your verification code is %s. It expires in 10 minutes.", code))
+	if err != nil {
+		slog.Error("Error emailing code", "user_id", m.Author.ID, "error", err)
+		replyToSubmission(s, m, "Sorry, we couldn't send an email to that address. Please check it and try again.")
+		return
+	}
+
+	replyToSubmission(s, m, "We've emailed a 6-digit code to "+email+". Please reply here with the code to finish verifying.")
+}
+
+func setVerificationMode(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	mode := options[0].StringValue()
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.VerificationMode = mode
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := fmt.Sprintf("Verification mode set to %s successfully! :white_check_mark:", mode)
+	if mode == "code" && mailProvider == nil {
+		content += "\nNote: the bot has no SMTP server configured, so codes won't be sent until SMTP_HOST is set"
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
@@ -0,0 +1,191 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Used when a guild hasn't configured any domains, so existing deployments
+// keep accepting UCLan addresses
+const defaultEmailDomain = "uclan.ac.uk"
+
+var domainRegex = regexp.MustCompile(`^([a-z0-9]([a-z0-9-]*[a-z0-9])?\.)+[a-z]{2,}$`)
+
+// Compiled custom email patterns, keyed by pattern
+var (
+	emailPatterns     = make(map[string]*regexp.Regexp)
+	emailPatternsLock sync.Mutex
+)
+
+func compileEmailPattern(pattern string) (*regexp.Regexp, error) {
+	emailPatternsLock.Lock()
+	defer emailPatternsLock.Unlock()
+
+	if re, ok := emailPatterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	emailPatterns[pattern] = re
+	return re, nil
+}
+
+// emailAllowed checks the email against the guild's custom pattern if it has
+// one, or its domain list otherwise
+func emailAllowed(email string, serverConfig ServerConfig) bool {
+	if serverConfig.EmailRegexPattern == "" {
+		return emailDomainAllowed(email, guildEmailDomains(serverConfig))
+	}
+	re, err := compileEmailPattern(serverConfig.EmailRegexPattern)
+	if err != nil {
+		return false
+	}
+	return re.MatchString(email)
+}
+
+func guildEmailDomains(serverConfig ServerConfig) []string {
+	if len(serverConfig.AllowedEmailDomains) == 0 {
+		return []string{defaultEmailDomain}
+	}
+	return serverConfig.AllowedEmailDomains
+}
+
+// emailDomainAllowed reports whether the email is well formed and belongs to
+// one of the guild's domains. Domains are compared case-insensitively.
+func emailDomainAllowed(email string, domains []string) bool {
+	matches := emailRegex.FindStringSubmatch(email)
+	if matches == nil {
+		return false
+	}
+	for _, domain := range domains {
+		if strings.EqualFold(matches[1], domain) {
+			return true
+		}
+	}
+	return false
+}
+
+func exampleEmail(serverConfig ServerConfig) string {
+	return "example@" + guildEmailDomains(serverConfig)[0]
+}
+
+func invalidEmailMessage(serverConfig ServerConfig) string {
+	if serverConfig.EmailRegexPattern != "" {
+		return "Invalid email. Please provide the email address this server asks for."
+	}
+	var endings []string
+	for _, domain := range guildEmailDomains(serverConfig) {
+		endings = append(endings, "@"+domain)
+	}
+	return fmt.Sprintf("Invalid email. Please provide a valid email address ending in %s.", strings.Join(endings, " or "))
+}
+
+func setEmailDomain(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	domain := strings.ToLower(strings.TrimPrefix(strings.TrimSpace(options[0].StringValue()), "@"))
+	guildID := i.GuildID
+
+	if !domainRegex.MatchString(domain) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "That doesn't look like a valid domain, for example uclan.ac.uk",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	added := true
+	for _, existing := range serverConfig.AllowedEmailDomains {
+		if existing == domain {
+			added = false
+			break
+		}
+	}
+	if added {
+		serverConfig.AllowedEmailDomains = append(serverConfig.AllowedEmailDomains, domain)
+	}
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := fmt.Sprintf("Email domain %s added successfully! :white_check_mark:", domain)
+	if !added {
+		content = fmt.Sprintf("Email domain %s is already allowed", domain)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func setEmailPattern(s discordSession, i *discordgo.InteractionCreate) {
+	var pattern string
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		pattern = strings.TrimSpace(options[0].StringValue())
+	}
+	guildID := i.GuildID
+
+	if pattern != "" {
+		_, err := compileEmailPattern(pattern)
+		if err != nil {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("Invalid pattern: %s\nFor example, `^[a-z0-9.]+@(student\\.)?uclan\\.ac\\.uk$`", err),
+				},
+			})
+			return
+		}
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.EmailRegexPattern = pattern
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Email pattern cleared successfully! :white_check_mark: Emails are checked against the allowed domains again."
+	if pattern != "" {
+		content = fmt.Sprintf("Email pattern set successfully! :white_check_mark: Emails must match `%s`", pattern)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
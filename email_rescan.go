@@ -0,0 +1,147 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Room for the member list in a /check_email_domains report, leaving space
+// under Discord's 2000 character limit for the rest of the message
+const maxRescanListLength = 1700
+
+// disallowedVerifications returns the guild's members whose latest decision
+// was an approval with an email the current settings would no longer accept,
+// such as one from a domain that has since been removed. Approvals without
+// an email, e.g. by SMS, are skipped.
+func disallowedVerifications(entries []VerificationLogEntry, guildID string, serverConfig ServerConfig) []VerificationLogEntry {
+	latest := make(map[string]VerificationLogEntry)
+	var order []string
+	for _, entry := range entries {
+		if entry.GuildID != guildID {
+			continue
+		}
+		if _, seen := latest[entry.UserID]; !seen {
+			order = append(order, entry.UserID)
+		}
+		latest[entry.UserID] = entry
+	}
+
+	var mismatches []VerificationLogEntry
+	for _, userID := range order {
+		entry := latest[userID]
+		if entry.Action != "approve" || !strings.Contains(entry.Email, "@") {
+			continue
+		}
+		if emailAllowlisted(entry.Email, serverConfig.EmailAllowlist) || emailAllowed(entry.Email, serverConfig) {
+			continue
+		}
+		mismatches = append(mismatches, entry)
+	}
+	return mismatches
+}
+
+// unverifyMember puts a member back to unverified, swapping their verified
+// role for the unverified one.
+func unverifyMember(s discordSession, guildID, userID string, serverConfig ServerConfig) error {
+	if serverConfig.VerifiedRoleID != "" {
+		err := withRetry(func() error { return memberRoleRemove(s, guildID, userID, serverConfig.VerifiedRoleID) })
+		if err != nil && !hasErrorCode(err, discordgo.ErrCodeUnknownMember) {
+			return err
+		}
+	}
+	if serverConfig.UnverifiedRoleID != "" {
+		err := withRetry(func() error { return memberRoleAdd(s, guildID, userID, serverConfig.UnverifiedRoleID) })
+		if err != nil && !hasErrorCode(err, discordgo.ErrCodeUnknownMember) {
+			return err
+		}
+	}
+	return nil
+}
+
+// checkEmailDomains finds members who verified with an email the server no
+// longer accepts, and either reports them or unverifies them.
+func checkEmailDomains(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
+	action := "report"
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Name == "action" {
+			action = option.StringValue()
+		}
+	}
+
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	verificationLogLock.Lock()
+	mismatches := disallowedVerifications(verificationLog, guildID, serverConfig)
+	verificationLogLock.Unlock()
+
+	if len(mismatches) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Every verified member's email is still allowed :white_check_mark:",
+			},
+		})
+		return
+	}
+
+	// Unverifying many members takes a while, so defer the response
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Error("Error acknowledging interaction", "error", err)
+		return
+	}
+
+	var sb strings.Builder
+	if action == "unverify" {
+		throttle := newBulkThrottle(guildBulkPolicy(guildID))
+		unverified, failed := 0, 0
+		for _, entry := range mismatches {
+			err := unverifyMember(s, guildID, entry.UserID, serverConfig)
+			throttle.done()
+			if err != nil {
+				slog.Error("Error unverifying member", "guild_id", guildID, "user_id", entry.UserID, "error", err)
+				failed++
+				continue
+			}
+			unverified++
+			recordDecision(s, VerificationLogEntry{
+				GuildID:     guildID,
+				UserID:      entry.UserID,
+				Action:      "unverify",
+				ModeratorID: interactionUserID(i),
+				Email:       entry.Email,
+				Time:        time.Now(),
+			})
+		}
+		sb.WriteString(fmt.Sprintf("Unverified %d member(s) whose email is no longer allowed :white_check_mark:", unverified))
+		if failed > 0 {
+			sb.WriteString(fmt.Sprintf(" %d failed (see logs)", failed))
+		}
+	} else {
+		sb.WriteString(fmt.Sprintf("%d verified member(s) used an email that is no longer allowed:", len(mismatches)))
+		for idx, entry := range mismatches {
+			line := fmt.Sprintf("\n- <@%s> (%s)", entry.UserID, entry.Email)
+			if sb.Len()+len(line) > maxRescanListLength {
+				sb.WriteString(fmt.Sprintf("\n+%d more", len(mismatches)-idx))
+				break
+			}
+			sb.WriteString(line)
+		}
+		sb.WriteString("\nRun `/check_email_domains action:unverify` to unverify them.")
+	}
+
+	content := sb.String()
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content:         &content,
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"slices"
+	"testing"
+)
+
+func TestDisallowedVerifications(t *testing.T) {
+	serverConfig := ServerConfig{
+		AllowedEmailDomains: []string{"uclan.ac.uk"},
+		EmailAllowlist:      []string{"guest@example.com"},
+	}
+
+	tests := []struct {
+		name    string
+		entries []VerificationLogEntry
+		want    []string
+	}{
+		{
+			name: "allowed domain",
+			entries: []VerificationLogEntry{
+				{GuildID: "guild", UserID: "a", Action: "approve", Email: "a@uclan.ac.uk"},
+			},
+		},
+		{
+			name: "removed domain",
+			entries: []VerificationLogEntry{
+				{GuildID: "guild", UserID: "a", Action: "approve", Email: "a@old.ac.uk"},
+				{GuildID: "guild", UserID: "b", Action: "approve", Email: "b@UCLan.ac.uk"},
+			},
+			want: []string{"a"},
+		},
+		{
+			name: "allowlisted email",
+			entries: []VerificationLogEntry{
+				{GuildID: "guild", UserID: "a", Action: "approve", Email: "guest@example.com"},
+			},
+		},
+		{
+			name: "no email recorded",
+			entries: []VerificationLogEntry{
+				{GuildID: "guild", UserID: "a", Action: "approve"},
+				{GuildID: "guild", UserID: "b", Action: "approve", Email: "+447700900000"},
+			},
+		},
+		{
+			name: "only the latest decision counts",
+			entries: []VerificationLogEntry{
+				{GuildID: "guild", UserID: "a", Action: "approve", Email: "a@old.ac.uk"},
+				{GuildID: "guild", UserID: "a", Action: "unverify", Email: "a@old.ac.uk"},
+				{GuildID: "guild", UserID: "b", Action: "deny", Email: "b@old.ac.uk"},
+				{GuildID: "guild", UserID: "b", Action: "approve", Email: "b@old.ac.uk"},
+			},
+			want: []string{"b"},
+		},
+		{
+			name: "other guilds ignored",
+			entries: []VerificationLogEntry{
+				{GuildID: "other", UserID: "a", Action: "approve", Email: "a@old.ac.uk"},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var got []string
+			for _, entry := range disallowedVerifications(tt.entries, "guild", serverConfig) {
+				got = append(got, entry.UserID)
+			}
+			if !slices.Equal(got, tt.want) {
+				t.Errorf("mismatches = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
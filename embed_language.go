@@ -0,0 +1,148 @@
+package main
+
+import (
+	"fmt"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Language used for audit embeds in guilds that haven't chosen one
+const defaultEmbedLanguage = "en"
+
+// builtinEmbedLanguages holds the audit embed text for each language, keyed
+// by language code. Keys missing from a language fall back to English.
+var builtinEmbedLanguages = map[string]map[string]string{
+	"en": {
+		"language_name":       "English",
+		"title":               "Verification request",
+		"username":            "Username",
+		"user_id":             "User ID",
+		"email":               "Email",
+		"account_created":     "Account created",
+		"joined_server":       "Joined server",
+		"new_account":         "⚠️ New account",
+		"new_account_warning": "This account was created recently, which is common for throwaway accounts.",
+	},
+	"cy": {
+		"language_name":       "Cymraeg",
+		"title":               "Cais dilysu",
+		"username":            "Enw defnyddiwr",
+		"user_id":             "ID defnyddiwr",
+		"email":               "E-bost",
+		"account_created":     "Cyfrif wedi'i greu",
+		"joined_server":       "Ymunodd â'r gweinydd",
+		"new_account":         "⚠️ Cyfrif newydd",
+		"new_account_warning": "Crëwyd y cyfrif hwn yn ddiweddar, sy'n gyffredin ar gyfer cyfrifon tafladwy.",
+	},
+	"es": {
+		"language_name":       "Español",
+		"title":               "Solicitud de verificación",
+		"username":            "Nombre de usuario",
+		"user_id":             "ID de usuario",
+		"email":               "Correo electrónico",
+		"account_created":     "Cuenta creada",
+		"joined_server":       "Se unió al servidor",
+		"new_account":         "⚠️ Cuenta nueva",
+		"new_account_warning": "Esta cuenta se creó recientemente, algo habitual en las cuentas desechables.",
+	},
+	"fr": {
+		"language_name":       "Français",
+		"title":               "Demande de vérification",
+		"username":            "Nom d'utilisateur",
+		"user_id":             "ID utilisateur",
+		"email":               "E-mail",
+		"account_created":     "Compte créé",
+		"joined_server":       "A rejoint le serveur",
+		"new_account":         "⚠️ Nouveau compte",
+		"new_account_warning": "Ce compte a été créé récemment, ce qui est courant pour les comptes jetables.",
+	},
+}
+
+// The languages in use: the built-in ones plus any from locale files
+var (
+	embedLanguages     = builtinEmbedLanguages
+	embedLanguagesLock sync.RWMutex
+)
+
+// embedText looks up a piece of audit embed text, falling back to English
+// for unknown languages and untranslated keys.
+func embedText(language, key string) string {
+	embedLanguagesLock.RLock()
+	defer embedLanguagesLock.RUnlock()
+
+	if text, ok := embedLanguages[language][key]; ok {
+		return text
+	}
+	return embedLanguages[defaultEmbedLanguage][key]
+}
+
+func embedLanguageExists(language string) bool {
+	embedLanguagesLock.RLock()
+	defer embedLanguagesLock.RUnlock()
+
+	_, exists := embedLanguages[language]
+	return exists
+}
+
+func embedLanguageCodes() []string {
+	embedLanguagesLock.RLock()
+	defer embedLanguagesLock.RUnlock()
+
+	codes := make([]string, 0, len(embedLanguages))
+	for code := range embedLanguages {
+		codes = append(codes, code)
+	}
+	slices.Sort(codes)
+	return codes
+}
+
+// sampleAuditEmbed shows an admin what verification requests will look like,
+// using their own account as the applicant.
+func sampleAuditEmbed(user *discordgo.User, language string) *discordgo.MessageEmbed {
+	return verificationRequestEmbed(user, "student@uclan.ac.uk", "", time.Now(), false, language)
+}
+
+func setEmbedLanguage(s discordSession, i *discordgo.InteractionCreate) {
+	language := strings.ToLower(strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue()))
+	guildID := i.GuildID
+
+	if !embedLanguageExists(language) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("Unknown language %q. Available languages: %s", language, strings.Join(embedLanguageCodes(), ", ")),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.EmbedLanguage = language
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Embed language set to %s successfully! :white_check_mark: Verification requests will look like this:", embedText(language, "language_name")),
+			Embeds:  []*discordgo.MessageEmbed{sampleAuditEmbed(i.Member.User, language)},
+		},
+	})
+}
@@ -0,0 +1,160 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// useEmbedLanguages swaps the languages in use for the test
+func useEmbedLanguages(t *testing.T, languages map[string]map[string]string) {
+	t.Helper()
+	embedLanguagesLock.Lock()
+	previous := embedLanguages
+	embedLanguages = languages
+	embedLanguagesLock.Unlock()
+
+	t.Cleanup(func() {
+		embedLanguagesLock.Lock()
+		embedLanguages = previous
+		embedLanguagesLock.Unlock()
+	})
+}
+
+func TestVerificationRequestEmbedLanguage(t *testing.T) {
+	user := &discordgo.User{ID: "200000000000000115", Username: "student"}
+
+	tests := []struct {
+		name       string
+		language   string
+		wantTitle  string
+		wantFields []string
+	}{
+		{
+			name:       "english",
+			language:   "en",
+			wantTitle:  "Verification request",
+			wantFields: []string{"Username", "User ID", "Email", "Account created", "Joined server", "⚠️ New account"},
+		},
+		{
+			name:       "welsh",
+			language:   "cy",
+			wantTitle:  "Cais dilysu",
+			wantFields: []string{"Enw defnyddiwr", "ID defnyddiwr", "E-bost", "Cyfrif wedi'i greu", "Ymunodd â'r gweinydd", "⚠️ Cyfrif newydd"},
+		},
+		{
+			name:       "not set",
+			wantTitle:  "Verification request",
+			wantFields: []string{"Username", "User ID", "Email", "Account created", "Joined server", "⚠️ New account"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			embed := verificationRequestEmbed(user, "student@uclan.ac.uk", "", time.Now(), true, tt.language)
+
+			if embed.Title != tt.wantTitle {
+				t.Errorf("title = %q, want %q", embed.Title, tt.wantTitle)
+			}
+			if len(embed.Fields) != len(tt.wantFields) {
+				t.Fatalf("got %d fields, want %d", len(embed.Fields), len(tt.wantFields))
+			}
+			for idx, field := range embed.Fields {
+				if field.Name != tt.wantFields[idx] {
+					t.Errorf("field %d = %q, want %q", idx, field.Name, tt.wantFields[idx])
+				}
+			}
+		})
+	}
+}
+
+func TestEmbedTextFallsBackToEnglish(t *testing.T) {
+	useEmbedLanguages(t, map[string]map[string]string{
+		"en":   builtinEmbedLanguages["en"],
+		"test": {"title": "Test title"},
+	})
+
+	if got := embedText("test", "title"); got != "Test title" {
+		t.Errorf("translated key = %q, want %q", got, "Test title")
+	}
+	if got := embedText("test", "email"); got != "Email" {
+		t.Errorf("untranslated key = %q, want the English text", got)
+	}
+	if got := embedText("xx", "email"); got != "Email" {
+		t.Errorf("unknown language = %q, want the English text", got)
+	}
+}
+
+func TestSetEmbedLanguage(t *testing.T) {
+	const guildID = "100000000000000034"
+	admin := &discordgo.User{ID: "300000000000000006", Username: "admin"}
+
+	tests := []struct {
+		name         string
+		language     string
+		wantLanguage string
+		wantContent  string
+		wantTitle    string
+	}{
+		{
+			name:         "known language",
+			language:     " CY ",
+			wantLanguage: "cy",
+			wantContent:  "Embed language set to Cymraeg successfully!",
+			wantTitle:    "Cais dilysu",
+		},
+		{
+			name:         "unknown language",
+			language:     "xx",
+			wantLanguage: "fr",
+			wantContent:  `Unknown language "xx". Available languages: cy, en, es, fr`,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useServerConfig(t, guildID, ServerConfig{EmbedLanguage: "fr"})
+
+			s := &fakeSession{}
+			setEmbedLanguage(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+				Type:    discordgo.InteractionApplicationCommand,
+				GuildID: guildID,
+				Member:  &discordgo.Member{User: admin},
+				Data: discordgo.ApplicationCommandInteractionData{
+					Name: "set_embed_language",
+					Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+						Name:  "language",
+						Type:  discordgo.ApplicationCommandOptionString,
+						Value: tt.language,
+					}},
+				},
+			}})
+
+			if got := getOrCreateServerConfig(guildID).EmbedLanguage; got != tt.wantLanguage {
+				t.Errorf("embed language = %q, want %q", got, tt.wantLanguage)
+			}
+			if len(s.responses) != 1 {
+				t.Fatalf("got %d responses, want 1", len(s.responses))
+			}
+			data := s.responses[0].Data
+			if !strings.Contains(data.Content, tt.wantContent) {
+				t.Errorf("response = %q, want it to contain %q", data.Content, tt.wantContent)
+			}
+
+			if tt.wantTitle == "" {
+				if len(data.Embeds) != 0 {
+					t.Errorf("sample embed shown for a rejected language")
+				}
+				return
+			}
+			if len(data.Embeds) != 1 || data.Embeds[0].Title != tt.wantTitle {
+				t.Fatalf("sample embed = %+v, want one titled %q", data.Embeds, tt.wantTitle)
+			}
+			if data.Embeds[0].Fields[1].Value != admin.ID {
+				t.Errorf("sample embed user ID = %q, want the admin's", data.Embeds[0].Fields[1].Value)
+			}
+		})
+	}
+}
@@ -0,0 +1,351 @@
+package main
+
+import (
+	"bytes"
+	"crypto/hmac"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Event QR codes let members verify in person. Scanning one opens the
+// callback server, which checks the signed token in the link and hands out a
+// single-use code for the member to DM to the bot.
+
+const (
+	defaultEventQRHours = 12
+	maxEventQRHours     = 7 * 24
+	// Longer names don't leave room for the link in the QR code
+	maxEventNameLength = 32
+	// How long a member has to DM the code after scanning
+	eventCodeTTL = 30 * time.Minute
+	// Only the first half of the HMAC is kept, to keep the QR code small
+	eventTokenMACSize = 16
+)
+
+// Easy to read back off a phone, so no 0/O or 1/I
+const eventCodeAlphabet = "ABCDEFGHJKLMNPQRSTUVWXYZ23456789"
+
+var eventCodeRegex = regexp.MustCompile(`^EVENT-[A-Z0-9]{8}$`)
+
+var (
+	errMalformedEventToken = errors.New("malformed event token")
+	errEventTokenSignature = errors.New("event token signature is invalid")
+	errEventTokenExpired   = errors.New("event token has expired")
+)
+
+// eventToken is what an event QR code vouches for: anyone scanning it before
+// it expires was at the event.
+type eventToken struct {
+	GuildID   string `json:"g"`
+	Event     string `json:"e"`
+	ExpiresAt int64  `json:"exp"`
+}
+
+type eventQRSettings struct {
+	Secret  []byte
+	BaseURL string
+}
+
+// Read from EVENT_QR_SECRET and EVENT_BASE_URL at startup. Event QR codes are
+// unavailable when Secret is empty.
+var eventQR eventQRSettings
+
+func loadEventQRSettings() {
+	eventQR = eventQRSettings{
+		Secret:  []byte(os.Getenv("EVENT_QR_SECRET")),
+		BaseURL: strings.TrimSuffix(os.Getenv("EVENT_BASE_URL"), "/"),
+	}
+}
+
+func eventTokenMAC(secret []byte, payload string) []byte {
+	mac := hmac.New(sha256.New, secret)
+	mac.Write([]byte(payload))
+	return mac.Sum(nil)[:eventTokenMACSize]
+}
+
+// signEventToken encodes the token as base64url JSON followed by its HMAC.
+func signEventToken(secret []byte, token eventToken) (string, error) {
+	payloadJSON, err := json.Marshal(token)
+	if err != nil {
+		return "", err
+	}
+	payload := base64.RawURLEncoding.EncodeToString(payloadJSON)
+	return payload + "." + base64.RawURLEncoding.EncodeToString(eventTokenMAC(secret, payload)), nil
+}
+
+// parseEventToken checks the token was signed with secret and hasn't expired.
+func parseEventToken(secret []byte, raw string, now time.Time) (eventToken, error) {
+	payload, signature, found := strings.Cut(raw, ".")
+	if !found {
+		return eventToken{}, errMalformedEventToken
+	}
+
+	mac, err := base64.RawURLEncoding.DecodeString(signature)
+	if err != nil {
+		return eventToken{}, errMalformedEventToken
+	}
+	if !hmac.Equal(mac, eventTokenMAC(secret, payload)) {
+		return eventToken{}, errEventTokenSignature
+	}
+
+	payloadJSON, err := base64.RawURLEncoding.DecodeString(payload)
+	if err != nil {
+		return eventToken{}, errMalformedEventToken
+	}
+	var token eventToken
+	if json.Unmarshal(payloadJSON, &token) != nil || token.GuildID == "" {
+		return eventToken{}, errMalformedEventToken
+	}
+	if now.Unix() >= token.ExpiresAt {
+		return eventToken{}, errEventTokenExpired
+	}
+	return token, nil
+}
+
+func eventVerifyURL(baseURL, token string) string {
+	return baseURL + "/event/verify?" + url.Values{"token": {token}}.Encode()
+}
+
+type eventCode struct {
+	GuildID   string
+	Event     string
+	ExpiresAt time.Time
+}
+
+var (
+	eventCodes     = make(map[string]eventCode)
+	eventCodesLock sync.Mutex
+)
+
+// issueEventCode hands out a code for someone who scanned the event's QR
+// code. Expired codes are dropped at the same time, since a busy event can
+// hand out a lot of them.
+func issueEventCode(guildID, event string, now time.Time) (string, error) {
+	var code strings.Builder
+	code.WriteString("EVENT-")
+	for range 8 {
+		n, err := rand.Int(rand.Reader, big.NewInt(int64(len(eventCodeAlphabet))))
+		if err != nil {
+			return "", err
+		}
+		code.WriteByte(eventCodeAlphabet[n.Int64()])
+	}
+
+	eventCodesLock.Lock()
+	defer eventCodesLock.Unlock()
+	for existing, entry := range eventCodes {
+		if now.After(entry.ExpiresAt) {
+			delete(eventCodes, existing)
+		}
+	}
+	eventCodes[code.String()] = eventCode{GuildID: guildID, Event: event, ExpiresAt: now.Add(eventCodeTTL)}
+	return code.String(), nil
+}
+
+// lookupEventCode finds an unexpired code without using it up.
+func lookupEventCode(code string, now time.Time) (eventCode, bool) {
+	eventCodesLock.Lock()
+	defer eventCodesLock.Unlock()
+
+	entry, exists := eventCodes[code]
+	if !exists || now.After(entry.ExpiresAt) {
+		return eventCode{}, false
+	}
+	return entry, true
+}
+
+// takeEventCode uses up a code, reporting false if someone else got there
+// first.
+func takeEventCode(code string) bool {
+	eventCodesLock.Lock()
+	defer eventCodesLock.Unlock()
+
+	_, exists := eventCodes[code]
+	delete(eventCodes, code)
+	return exists
+}
+
+func eventVerifyHandler() http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if len(eventQR.Secret) == 0 {
+			http.NotFound(w, r)
+			return
+		}
+
+		token, err := parseEventToken(eventQR.Secret, r.URL.Query().Get("token"), time.Now())
+		if errors.Is(err, errEventTokenExpired) {
+			http.Error(w, "This event's QR code has expired. Please ask a moderator to verify you instead.", http.StatusGone)
+			return
+		}
+		if err != nil {
+			slog.Warn("Event token rejected", "error", err)
+			http.Error(w, "This QR code isn't valid.", http.StatusBadRequest)
+			return
+		}
+
+		code, err := issueEventCode(token.GuildID, token.Event, time.Now())
+		if err != nil {
+			slog.Error("Error creating event code", "guild_id", token.GuildID, "error", err)
+			http.Error(w, "Something went wrong. Please scan the QR code again.", http.StatusInternalServerError)
+			return
+		}
+
+		fmt.Fprintf(w, "Thanks for coming to %s!\n\nTo verify, join the Discord server and send this code to the bot in a DM:\n\n%s\n\nThe code works once and expires in %d minutes.", token.Event, code, int(eventCodeTTL.Minutes()))
+	}
+}
+
+// handleEventCode verifies a member who DMs the code from an event QR code.
+func handleEventCode(s discordSession, m *discordgo.MessageCreate) {
+	code := strings.ToUpper(strings.TrimSpace(m.Content))
+	entry, ok := lookupEventCode(code, time.Now())
+	if !ok {
+		s.ChannelMessageSend(m.ChannelID, "That event code is invalid or has expired. Please scan the QR code again to get a new one.")
+		return
+	}
+
+	// Keep the code for after they've joined
+	_, err := s.GuildMember(entry.GuildID, m.Author.ID)
+	if err != nil {
+		s.ChannelMessageSend(m.ChannelID, "Please join the server first, then send the code again.")
+		return
+	}
+
+	if !takeEventCode(code) {
+		s.ChannelMessageSend(m.ChannelID, "That event code has already been used. Please scan the QR code again to get a new one.")
+		return
+	}
+
+	configMutex.RLock()
+	serverConfig := config.Servers[entry.GuildID]
+	configMutex.RUnlock()
+
+	if blockEntry, blocked := blocklistMatch(m.Author.ID, "", serverConfig.Blocklist); blocked {
+		removeBlocklisted(s, entry.GuildID, m.Author.ID, serverConfig, "", blockEntry)
+		s.ChannelMessageSend(m.ChannelID, "You couldn't be verified. Please contact a moderator.")
+		return
+	}
+
+	// Remove unverified role
+	if serverConfig.UnverifiedRoleID != "" {
+		err = withRetry(func() error {
+			return memberRoleRemove(s, entry.GuildID, m.Author.ID, serverConfig.UnverifiedRoleID)
+		})
+		if err != nil {
+			slog.Error("Error removing unverified role", "error", err)
+			s.ChannelMessageSend(m.ChannelID, "Your code was correct but something went wrong removing your unverified role. Please contact a moderator.")
+			return
+		}
+	}
+	grantVerifiedRole(s, entry.GuildID, m.Author.ID)
+
+	slog.Info("User verified at event", "guild_id", entry.GuildID, "user_id", m.Author.ID, "event", entry.Event)
+	removePendingVerification(entry.GuildID, m.Author.ID)
+	recordDecision(s, VerificationLogEntry{
+		GuildID: entry.GuildID,
+		UserID:  m.Author.ID,
+		Action:  "approve",
+		Time:    time.Now(),
+	})
+
+	_, err = sendAuditMessage(s, serverConfig, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("✅ <@%s> was verified in person at **%s** with the event QR code.", m.Author.ID, entry.Event),
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error("Error logging event verification", "guild_id", entry.GuildID, "user_id", m.Author.ID, "error", err)
+	}
+
+	_, err = s.ChannelMessageSend(m.ChannelID, "You're verified and now have access to the server. Welcome! 🎉")
+	if err != nil {
+		slog.Error("Error sending DM", "error", err)
+	}
+
+	welcomeMember(s, entry.GuildID, m.Author.ID)
+}
+
+func createEventQR(s discordSession, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	if len(eventQR.Secret) == 0 || eventQR.BaseURL == "" {
+		respond("Event QR codes aren't set up. Set EVENT_QR_SECRET and EVENT_BASE_URL and restart the bot.")
+		return
+	}
+
+	var name string
+	var hours int64 = defaultEventQRHours
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "name":
+			name = strings.TrimSpace(option.StringValue())
+		case "hours":
+			hours = option.IntValue()
+		}
+	}
+
+	if name == "" || len(name) > maxEventNameLength {
+		respond(fmt.Sprintf("The event name must be between 1 and %d characters", maxEventNameLength))
+		return
+	}
+	if hours < 1 || hours > maxEventQRHours {
+		respond(fmt.Sprintf("The QR code must last between 1 and %d hours", maxEventQRHours))
+		return
+	}
+
+	expiresAt := time.Now().Add(time.Duration(hours) * time.Hour)
+	token, err := signEventToken(eventQR.Secret, eventToken{GuildID: i.GuildID, Event: name, ExpiresAt: expiresAt.Unix()})
+	if err != nil {
+		slog.Error("Error signing event token", "guild_id", i.GuildID, "error", err)
+		return
+	}
+	qr, err := encodeQR(eventVerifyURL(eventQR.BaseURL, token))
+	if err != nil {
+		respond("The link is too long for a QR code. Try a shorter event name.")
+		return
+	}
+	image, err := qr.png(8)
+	if err != nil {
+		slog.Error("Error drawing event QR code", "guild_id", i.GuildID, "error", err)
+		return
+	}
+
+	err = s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Members who scan this at **%s** can verify until <t:%d:f>. Anyone with the code can verify, so only show it at the event.", name, expiresAt.Unix()),
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Files: []*discordgo.File{
+				{
+					Name:        "event-qr.png",
+					ContentType: "image/png",
+					Reader:      bytes.NewReader(image),
+				},
+			},
+		},
+	})
+	if err != nil {
+		slog.Error("Error sending event QR code", "guild_id", i.GuildID, "error", err)
+	}
+}
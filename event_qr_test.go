@@ -0,0 +1,160 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"regexp"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestEventToken(t *testing.T) {
+	secret := []byte("event-secret")
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	token := eventToken{GuildID: "100000000000000035", Event: "Freshers Fair", ExpiresAt: now.Add(time.Hour).Unix()}
+
+	raw, err := signEventToken(secret, token)
+	if err != nil {
+		t.Fatal(err)
+	}
+	payload, signature, _ := strings.Cut(raw, ".")
+
+	tests := []struct {
+		name    string
+		secret  []byte
+		raw     string
+		now     time.Time
+		wantErr error
+	}{
+		{name: "valid", secret: secret, raw: raw, now: now},
+		{name: "wrong secret", secret: []byte("other-secret"), raw: raw, now: now, wantErr: errEventTokenSignature},
+		{name: "edited payload", secret: secret, raw: strings.Replace(raw, payload[:4], "AAAA", 1), now: now, wantErr: errEventTokenSignature},
+		{name: "edited signature", secret: secret, raw: payload + "." + strings.Repeat("A", len(signature)), now: now, wantErr: errEventTokenSignature},
+		{name: "no signature", secret: secret, raw: payload, now: now, wantErr: errMalformedEventToken},
+		{name: "expired", secret: secret, raw: raw, now: now.Add(time.Hour), wantErr: errEventTokenExpired},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseEventToken(tt.secret, tt.raw, tt.now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("error = %v, want %v", err, tt.wantErr)
+			}
+			if tt.wantErr == nil && got != token {
+				t.Errorf("token = %+v, want %+v", got, token)
+			}
+		})
+	}
+}
+
+func TestEventVerify(t *testing.T) {
+	const (
+		guildID = "100000000000000035"
+		userID  = "200000000000000116"
+	)
+	useServerConfig(t, guildID, ServerConfig{MemberAuditChannelID: "audit", UnverifiedRoleID: "unverified", VerifiedRoleID: "verified"})
+
+	previous := eventQR
+	eventQR = eventQRSettings{Secret: []byte("event-secret"), BaseURL: "https://bot.example.com"}
+	t.Cleanup(func() { eventQR = previous })
+
+	raw, err := signEventToken(eventQR.Secret, eventToken{GuildID: guildID, Event: "Freshers Fair", ExpiresAt: time.Now().Add(time.Hour).Unix()})
+	if err != nil {
+		t.Fatal(err)
+	}
+	link, err := url.Parse(eventVerifyURL(eventQR.BaseURL, raw))
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	recorder := httptest.NewRecorder()
+	eventVerifyHandler()(recorder, httptest.NewRequest(http.MethodGet, link.RequestURI(), nil))
+	if recorder.Code != http.StatusOK {
+		t.Fatalf("status = %d, want 200 (%s)", recorder.Code, recorder.Body)
+	}
+	code := regexp.MustCompile(`EVENT-[A-Z0-9]{8}`).FindString(recorder.Body.String())
+	if code == "" {
+		t.Fatalf("no code on the page: %s", recorder.Body)
+	}
+	t.Cleanup(func() { takeEventCode(code) })
+
+	dm := func(s *fakeSession, content string) {
+		memberDM(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ChannelID: "dm-" + userID,
+			Content:   content,
+			Author:    &discordgo.User{ID: userID},
+		}})
+	}
+
+	// Codes are read back off a phone, so case and spacing don't matter
+	s := &fakeSession{}
+	dm(s, " "+strings.ToLower(code)+" ")
+
+	if !s.called("GuildMemberRoleRemove " + guildID + " " + userID + " unverified") {
+		t.Errorf("unverified role not removed (calls %q)", s.calls)
+	}
+	if !s.called("GuildMemberRoleAdd " + guildID + " " + userID + " verified") {
+		t.Errorf("verified role not added (calls %q)", s.calls)
+	}
+	if !s.called("ChannelMessageSend audit") {
+		t.Errorf("verification not logged to the audit channel (calls %q)", s.calls)
+	}
+
+	s = &fakeSession{}
+	dm(s, code)
+	if s.called("GuildMemberRoleAdd " + guildID + " " + userID + " verified") {
+		t.Errorf("code worked twice")
+	}
+
+	t.Run("bad token", func(t *testing.T) {
+		recorder := httptest.NewRecorder()
+		eventVerifyHandler()(recorder, httptest.NewRequest(http.MethodGet, "/event/verify?token="+raw+"x", nil))
+		if recorder.Code != http.StatusBadRequest || strings.Contains(recorder.Body.String(), "EVENT-") {
+			t.Errorf("status = %d, body %q, want the token refused", recorder.Code, recorder.Body)
+		}
+	})
+}
+
+func TestCreateEventQR(t *testing.T) {
+	const guildID = "100000000000000035"
+
+	previous := eventQR
+	eventQR = eventQRSettings{Secret: []byte("event-secret"), BaseURL: "https://bot.example.com"}
+	t.Cleanup(func() { eventQR = previous })
+
+	command := func(name string) *discordgo.InteractionCreate {
+		return &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:    discordgo.InteractionApplicationCommand,
+			GuildID: guildID,
+			Data: discordgo.ApplicationCommandInteractionData{
+				Name: "event_qr",
+				Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+					Name:  "name",
+					Type:  discordgo.ApplicationCommandOptionString,
+					Value: name,
+				}},
+			},
+		}}
+	}
+
+	s := &fakeSession{}
+	createEventQR(s, command("Freshers Fair"))
+	if len(s.responses) != 1 {
+		t.Fatalf("got %d responses, want 1", len(s.responses))
+	}
+	files := s.responses[0].Data.Files
+	if len(files) != 1 || files[0].ContentType != "image/png" {
+		t.Fatalf("files = %+v, want the QR code image", files)
+	}
+
+	s = &fakeSession{}
+	createEventQR(s, command(strings.Repeat("x", maxEventNameLength+1)))
+	if len(s.responses) != 1 || len(s.responses[0].Data.Files) != 0 {
+		t.Errorf("QR code made for an overlong event name")
+	}
+}
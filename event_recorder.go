@@ -0,0 +1,164 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const defaultEventBufferSize = 100
+
+type recordedEvent struct {
+	Type    string
+	GuildID string
+	UserID  string
+	Time    time.Time
+}
+
+// eventRing keeps the most recent events, overwriting the oldest once full.
+type eventRing struct {
+	mu     sync.Mutex
+	events []recordedEvent
+	next   int
+	full   bool
+}
+
+func newEventRing(size int) *eventRing {
+	return &eventRing{events: make([]recordedEvent, size)}
+}
+
+func (r *eventRing) add(event recordedEvent) {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	r.events[r.next] = event
+	r.next = (r.next + 1) % len(r.events)
+	if r.next == 0 {
+		r.full = true
+	}
+}
+
+// snapshot returns the buffered events, oldest first.
+func (r *eventRing) snapshot() []recordedEvent {
+	r.mu.Lock()
+	defer r.mu.Unlock()
+
+	if !r.full {
+		return append([]recordedEvent(nil), r.events[:r.next]...)
+	}
+	return append(append([]recordedEvent(nil), r.events[r.next:]...), r.events[:r.next]...)
+}
+
+var recentEvents = newEventRing(defaultEventBufferSize)
+
+// summarizeEvent pulls the guild and user out of a raw gateway event. Events
+// name the user differently, so each known shape is tried in turn.
+func summarizeEvent(e *discordgo.Event, now time.Time) recordedEvent {
+	var payload struct {
+		GuildID string `json:"guild_id"`
+		UserID  string `json:"user_id"`
+		Author  *struct {
+			ID string `json:"id"`
+		} `json:"author"`
+		User *struct {
+			ID string `json:"id"`
+		} `json:"user"`
+		Member *struct {
+			User *struct {
+				ID string `json:"id"`
+			} `json:"user"`
+		} `json:"member"`
+	}
+	json.Unmarshal(e.RawData, &payload)
+
+	event := recordedEvent{Type: e.Type, GuildID: payload.GuildID, UserID: payload.UserID, Time: now}
+	switch {
+	case payload.Author != nil:
+		event.UserID = payload.Author.ID
+	case payload.Member != nil && payload.Member.User != nil:
+		event.UserID = payload.Member.User.ID
+	case payload.User != nil:
+		event.UserID = payload.User.ID
+	}
+	return event
+}
+
+func formatEvents(events []recordedEvent) string {
+	var sb strings.Builder
+	for _, event := range events {
+		guildID := event.GuildID
+		if guildID == "" {
+			guildID = "-"
+		}
+		userID := event.UserID
+		if userID == "" {
+			userID = "-"
+		}
+		sb.WriteString(fmt.Sprintf("%s %s guild=%s user=%s\n", event.Time.UTC().Format(time.RFC3339), event.Type, guildID, userID))
+	}
+	return sb.String()
+}
+
+func recordEvent(s discordSession, e *discordgo.Event) {
+	recentEvents.add(summarizeEvent(e, time.Now()))
+}
+
+func debugEvents(s discordSession, i *discordgo.InteractionCreate) {
+	if !isBotOwner(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Only the bot owner can use this command",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	events := recentEvents.snapshot()
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Last %d events", len(events)),
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Files: []*discordgo.File{
+				{
+					Name:        "events.txt",
+					ContentType: "text/plain",
+					Reader:      bytes.NewBufferString(formatEvents(events)),
+				},
+			},
+		},
+	})
+	if err != nil {
+		slog.Error("Error sending event dump", "error", err)
+	}
+}
+
+func isBotOwner(i *discordgo.InteractionCreate) bool {
+	ownerID := os.Getenv("OWNER_ID")
+	if ownerID == "" {
+		return false
+	}
+
+	return interactionUserID(i) == ownerID
+}
+
+// interactionUserID returns who triggered an interaction, which Discord puts
+// on Member in guilds and on User in DMs.
+func interactionUserID(i *discordgo.InteractionCreate) string {
+	if i.Member != nil && i.Member.User != nil {
+		return i.Member.User.ID
+	}
+	if i.User != nil {
+		return i.User.ID
+	}
+	return ""
+}
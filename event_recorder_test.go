@@ -0,0 +1,37 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestEventRing(t *testing.T) {
+	tests := []struct {
+		name  string
+		size  int
+		added []string
+		want  []string
+	}{
+		{name: "empty", size: 3, want: nil},
+		{name: "partly full", size: 3, added: []string{"a", "b"}, want: []string{"a", "b"}},
+		{name: "exactly full", size: 3, added: []string{"a", "b", "c"}, want: []string{"a", "b", "c"}},
+		{name: "overwrites oldest", size: 3, added: []string{"a", "b", "c", "d", "e"}, want: []string{"c", "d", "e"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			ring := newEventRing(tt.size)
+			for _, eventType := range tt.added {
+				ring.add(recordedEvent{Type: eventType})
+			}
+
+			var got []string
+			for _, event := range ring.snapshot() {
+				got = append(got, event.Type)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("snapshot() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
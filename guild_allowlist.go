@@ -0,0 +1,206 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// Guilds from the ALLOWED_GUILDS env var, which can't be removed by
+	// command
+	envAllowedGuilds = make(map[string]bool)
+	// Guilds added by the owner with /allow_guild
+	savedAllowedGuilds = make(map[string]bool)
+	allowedGuildsLock  sync.RWMutex
+)
+
+func loadAllowedGuilds() error {
+	allowedGuildsLock.Lock()
+	defer allowedGuildsLock.Unlock()
+
+	for _, guildID := range strings.Split(os.Getenv("ALLOWED_GUILDS"), ",") {
+		if guildID = strings.TrimSpace(guildID); guildID != "" {
+			envAllowedGuilds[guildID] = true
+		}
+	}
+
+	data, err := os.ReadFile("./data/allowed_guilds.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	var guildIDs []string
+	err = json.Unmarshal(data, &guildIDs)
+	if err != nil {
+		return err
+	}
+	for _, guildID := range guildIDs {
+		savedAllowedGuilds[guildID] = true
+	}
+	return nil
+}
+
+// saveAllowedGuilds must be called with allowedGuildsLock held
+func saveAllowedGuilds() error {
+	guildIDs := make([]string, 0, len(savedAllowedGuilds))
+	for guildID := range savedAllowedGuilds {
+		guildIDs = append(guildIDs, guildID)
+	}
+	sort.Strings(guildIDs)
+
+	data, err := json.MarshalIndent(guildIDs, "", "  ")
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll("./data", os.ModePerm)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic("./data/allowed_guilds.json", data, 0644)
+}
+
+// guildAllowed reports whether the bot may stay in the guild. With no
+// allowlist configured every guild is allowed.
+func guildAllowed(guildID string) bool {
+	allowedGuildsLock.RLock()
+	defer allowedGuildsLock.RUnlock()
+
+	if len(envAllowedGuilds) == 0 && len(savedAllowedGuilds) == 0 {
+		return true
+	}
+	return envAllowedGuilds[guildID] || savedAllowedGuilds[guildID]
+}
+
+// isLastAllowedGuild reports whether the guild is the only one on the
+// allowlist, so removing it would leave the allowlist empty and let the bot
+// stay in every guild. Must be called with allowedGuildsLock held.
+func isLastAllowedGuild(guildID string) bool {
+	return len(envAllowedGuilds) == 0 && len(savedAllowedGuilds) == 1 && savedAllowedGuilds[guildID]
+}
+
+// guildCreate fires when the bot joins a guild and for every guild on
+// startup, so it also catches guilds joined while the bot was offline.
+func guildCreate(s discordSession, g *discordgo.GuildCreate) {
+	if guildAllowed(g.ID) {
+		registerJoinedGuild(s, g.ID)
+		go ensureVerificationMessages(s, g.ID)
+		return
+	}
+
+	slog.Info("Leaving guild as it is not on the allowlist", "guild_id", g.ID, "guild_name", g.Name)
+	err := s.GuildLeave(g.ID)
+	if err != nil {
+		slog.Error("Error leaving guild", "guild_id", g.ID, "error", err)
+	}
+}
+
+func allowGuild(s discordSession, i *discordgo.InteractionCreate) {
+	if !isBotOwner(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Only the bot owner can use this command",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	guildID := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+
+	allowedGuildsLock.Lock()
+	savedAllowedGuilds[guildID] = true
+	err := saveAllowedGuilds()
+	allowedGuildsLock.Unlock()
+
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving allowlist: " + err.Error(),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	content := fmt.Sprintf("Guild %s added to the allowlist! :white_check_mark:", guildID)
+	if i.GuildID != "" && !guildAllowed(i.GuildID) {
+		content += "\nWarning: this server isn't on the allowlist, so the bot will leave it on its next restart"
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+func disallowGuild(s discordSession, i *discordgo.InteractionCreate) {
+	if !isBotOwner(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Only the bot owner can use this command",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	guildID := strings.TrimSpace(i.ApplicationCommandData().Options[0].StringValue())
+
+	allowedGuildsLock.Lock()
+	inEnv := envAllowedGuilds[guildID]
+	last := isLastAllowedGuild(guildID)
+	var err error
+	if !last {
+		delete(savedAllowedGuilds, guildID)
+		err = saveAllowedGuilds()
+	}
+	allowedGuildsLock.Unlock()
+
+	var content string
+	switch {
+	case last:
+		content = fmt.Sprintf("Guild %s is the only guild on the allowlist, and an empty allowlist lets the bot stay in any server. Allow another guild first", guildID)
+	case err != nil:
+		content = "Error saving allowlist: " + err.Error()
+	case inEnv:
+		content = fmt.Sprintf("Guild %s is allowed by ALLOWED_GUILDS and can only be removed there", guildID)
+	default:
+		content = fmt.Sprintf("Guild %s removed from the allowlist! :white_check_mark:", guildID)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	// Leave straight away if the bot is in the guild
+	if !last && err == nil && !guildAllowed(guildID) {
+		if _, stateErr := stateOf(s).Guild(guildID); stateErr == nil {
+			slog.Info("Leaving guild as it was removed from the allowlist", "guild_id", guildID)
+			err = s.GuildLeave(guildID)
+			if err != nil {
+				slog.Error("Error leaving guild", "guild_id", guildID, "error", err)
+			}
+		}
+	}
+}
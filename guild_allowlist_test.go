@@ -0,0 +1,81 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestIsLastAllowedGuild(t *testing.T) {
+	tests := []struct {
+		name  string
+		env   []string
+		saved []string
+		want  bool
+	}{
+		{name: "only saved guild", saved: []string{"guild"}, want: true},
+		{name: "another saved guild", saved: []string{"guild", "other"}},
+		{name: "guilds from the env var", env: []string{"other"}, saved: []string{"guild"}},
+		{name: "not on the allowlist", saved: []string{"other"}},
+		{name: "no allowlist"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			allowedGuildsLock.Lock()
+			defer allowedGuildsLock.Unlock()
+
+			previousEnv, previousSaved := envAllowedGuilds, savedAllowedGuilds
+			defer func() { envAllowedGuilds, savedAllowedGuilds = previousEnv, previousSaved }()
+
+			envAllowedGuilds, savedAllowedGuilds = make(map[string]bool), make(map[string]bool)
+			for _, guildID := range tt.env {
+				envAllowedGuilds[guildID] = true
+			}
+			for _, guildID := range tt.saved {
+				savedAllowedGuilds[guildID] = true
+			}
+
+			if got := isLastAllowedGuild("guild"); got != tt.want {
+				t.Errorf("isLastAllowedGuild() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestGuildCreate(t *testing.T) {
+	const (
+		allowedGuildID    = "100000000000000026"
+		disallowedGuildID = "100000000000000027"
+	)
+	allowedGuildsLock.Lock()
+	previousEnv, previousSaved := envAllowedGuilds, savedAllowedGuilds
+	envAllowedGuilds = make(map[string]bool)
+	savedAllowedGuilds = map[string]bool{allowedGuildID: true}
+	allowedGuildsLock.Unlock()
+	t.Cleanup(func() {
+		allowedGuildsLock.Lock()
+		envAllowedGuilds, savedAllowedGuilds = previousEnv, previousSaved
+		allowedGuildsLock.Unlock()
+	})
+
+	tests := []struct {
+		name      string
+		guildID   string
+		wantLeave bool
+	}{
+		{name: "allowed guild", guildID: allowedGuildID},
+		{name: "disallowed guild", guildID: disallowedGuildID, wantLeave: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeSession{}
+			guildCreate(s, &discordgo.GuildCreate{Guild: &discordgo.Guild{ID: tt.guildID, Name: tt.name}})
+
+			if got := s.called("GuildLeave " + tt.guildID); got != tt.wantLeave {
+				t.Errorf("left guild = %v, want %v (calls %q)", got, tt.wantLeave, s.calls)
+			}
+		})
+	}
+}
@@ -0,0 +1,134 @@
+package main
+
+import (
+	"log/slog"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// Which server each user said they're verifying for, and the message
+	// waiting on that answer
+	guildChoices      = make(map[string]string)
+	awaitingGuildPick = make(map[string]*discordgo.MessageCreate)
+	guildChoicesLock  sync.Mutex
+)
+
+// verificationCandidates lists the configured guilds the user shares with the
+// bot and still needs to verify in.
+func verificationCandidates(s discordSession, userID string) []*discordgo.Guild {
+	var candidates []*discordgo.Guild
+	for _, guild := range stateOf(s).Guilds {
+		configMutex.RLock()
+		serverConfig, exists := config.Servers[guild.ID]
+		configMutex.RUnlock()
+		if !exists {
+			continue
+		}
+
+		member, err := s.GuildMember(guild.ID, userID)
+		if err != nil || member == nil {
+			continue
+		}
+		if serverConfig.UnverifiedRoleID != "" && !memberHasRole(member, serverConfig.UnverifiedRoleID) {
+			continue
+		}
+		candidates = append(candidates, guild)
+	}
+	return candidates
+}
+
+// chooseVerificationGuild picks the guild a DM is meant for. If the user
+// could be verifying for several servers and hasn't said which, it asks them
+// and returns an empty ID; the message is processed again once they answer.
+func chooseVerificationGuild(s discordSession, m *discordgo.MessageCreate) string {
+	candidates := verificationCandidates(s, m.Author.ID)
+	switch len(candidates) {
+	case 0:
+		slog.Warn("User has no server to verify for", "user_id", m.Author.ID)
+		return ""
+	case 1:
+		return candidates[0].ID
+	}
+
+	guildChoicesLock.Lock()
+	chosen := guildChoices[m.Author.ID]
+	guildChoicesLock.Unlock()
+	for _, guild := range candidates {
+		if guild.ID == chosen {
+			return chosen
+		}
+	}
+
+	options := make([]discordgo.SelectMenuOption, 0, len(candidates))
+	for _, guild := range candidates {
+		options = append(options, discordgo.SelectMenuOption{
+			Label: guild.Name,
+			Value: guild.ID,
+		})
+	}
+
+	_, err := s.ChannelMessageSendComplex(m.ChannelID, &discordgo.MessageSend{
+		Content: "You're in more than one server that uses this bot. Which one are you verifying for?",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.SelectMenu{
+						CustomID:    "guildchoice",
+						Placeholder: "Choose a server...",
+						Options:     options,
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		slog.Error("Error asking user to choose a server", "user_id", m.Author.ID, "error", err)
+		return ""
+	}
+
+	guildChoicesLock.Lock()
+	awaitingGuildPick[m.Author.ID] = m
+	guildChoicesLock.Unlock()
+	return ""
+}
+
+func handleGuildChoice(s discordSession, i *discordgo.InteractionCreate) {
+	userID := interactionUserID(i)
+	values := i.MessageComponentData().Values
+	if len(values) != 1 {
+		return
+	}
+
+	guildChoicesLock.Lock()
+	guildChoices[userID] = values[0]
+	m, waiting := awaitingGuildPick[userID]
+	delete(awaitingGuildPick, userID)
+	guildChoicesLock.Unlock()
+
+	// The menu has been answered
+	_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    i.ChannelID,
+		ID:         i.Message.ID,
+		Components: &[]discordgo.MessageComponent{},
+	})
+	if err != nil {
+		slog.Error("Error removing server menu", "user_id", userID, "error", err)
+	}
+
+	responseContent := "Thanks! Please send your verification details again."
+	if waiting {
+		responseContent = "Thanks! Continuing with your verification."
+	}
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &responseContent,
+	})
+	if err != nil {
+		slog.Error("Error editing interaction response", "user_id", userID, "error", err)
+	}
+
+	if waiting {
+		continueVerification(s, m)
+	}
+}
@@ -0,0 +1,67 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestGuildChoiceContinuesVerification(t *testing.T) {
+	const (
+		firstGuildID  = "100000000000000015"
+		secondGuildID = "100000000000000016"
+		userID        = "200000000000000092"
+	)
+	useServerConfig(t, firstGuildID, ServerConfig{MemberAuditChannelID: "audit-first"})
+	configMutex.Lock()
+	config.Servers[secondGuildID] = ServerConfig{MemberAuditChannelID: "audit-second"}
+	configMutex.Unlock()
+	t.Cleanup(func() {
+		guildChoicesLock.Lock()
+		delete(guildChoices, userID)
+		delete(awaitingGuildPick, userID)
+		guildChoicesLock.Unlock()
+		removePendingVerification(secondGuildID, userID)
+	})
+
+	state := discordgo.NewState()
+	for _, guildID := range []string{firstGuildID, secondGuildID} {
+		if err := state.GuildAdd(&discordgo.Guild{ID: guildID, Name: "Guild " + guildID}); err != nil {
+			t.Fatal(err)
+		}
+	}
+	s := &fakeSession{state: state}
+
+	processEmailVerification(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "submission-" + userID,
+		ChannelID: "dm-" + userID,
+		Content:   "student@uclan.ac.uk",
+		Author:    &discordgo.User{ID: userID, Username: "student"},
+	}})
+
+	if len(s.sent) != 1 || s.sent[0].ChannelID != "dm-"+userID || len(s.sent[0].Data.Components) == 0 {
+		t.Fatalf("want only the server menu sent, got calls %q", s.calls)
+	}
+
+	// The user answers straight away, well inside the duplicate window
+	handleGuildChoice(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type:      discordgo.InteractionMessageComponent,
+		ChannelID: "dm-" + userID,
+		Message:   &discordgo.Message{ID: "menu"},
+		User:      &discordgo.User{ID: userID},
+		Data: discordgo.MessageComponentInteractionData{
+			CustomID: "guildchoice",
+			Values:   []string{secondGuildID},
+		},
+	}})
+
+	if !s.called("InteractionResponseEdit Thanks! Continuing with your verification.") {
+		t.Errorf("missing continue response (calls %q)", s.calls)
+	}
+	if !s.called("ChannelMessageSend audit-second") {
+		t.Errorf("submission never reached the chosen server's moderators (calls %q)", s.calls)
+	}
+	if s.called("ChannelMessageSend audit-first") {
+		t.Errorf("submission sent to the server that wasn't chosen")
+	}
+}
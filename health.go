@@ -0,0 +1,52 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord asks for a heartbeat roughly every 40 seconds, so a connection
+// that hasn't had an ACK in this long has stopped responding
+const heartbeatStaleAfter = 2 * time.Minute
+
+// sessionHealthy reports whether the gateway connection is up and Discord is
+// still acknowledging our heartbeats
+func sessionHealthy(s *discordgo.Session, now time.Time) error {
+	s.RLock()
+	ready := s.DataReady
+	lastAck := s.LastHeartbeatAck
+	s.RUnlock()
+
+	if !ready {
+		return fmt.Errorf("not connected to Discord")
+	}
+	if now.Sub(lastAck) > heartbeatStaleAfter {
+		return fmt.Errorf("no heartbeat ACK since %s", lastAck.Format(time.RFC3339))
+	}
+	return nil
+}
+
+func startHealthServer(s *discordgo.Session, addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/healthz", func(w http.ResponseWriter, r *http.Request) {
+		err := sessionHealthy(s, time.Now())
+		if err != nil {
+			http.Error(w, err.Error(), http.StatusServiceUnavailable)
+			return
+		}
+		fmt.Fprintln(w, "ok")
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("Health server stopped", "error", err)
+		}
+	}()
+	return server
+}
@@ -0,0 +1,29 @@
+package main
+
+import "sync"
+
+// Messages whose buttons are currently being handled, so two moderators
+// clicking at once can't both approve and deny the same applicant
+var (
+	inFlightMessages = make(map[string]bool)
+	inFlightLock     sync.Mutex
+)
+
+// claimMessage marks the message as being handled, returning false if
+// another interaction already has it.
+func claimMessage(messageID string) bool {
+	inFlightLock.Lock()
+	defer inFlightLock.Unlock()
+
+	if inFlightMessages[messageID] {
+		return false
+	}
+	inFlightMessages[messageID] = true
+	return true
+}
+
+func releaseMessage(messageID string) {
+	inFlightLock.Lock()
+	delete(inFlightMessages, messageID)
+	inFlightLock.Unlock()
+}
@@ -0,0 +1,38 @@
+package main
+
+import (
+	"sync"
+	"sync/atomic"
+	"testing"
+)
+
+func TestClaimMessageConcurrent(t *testing.T) {
+	const messageID = "in-flight-concurrent"
+	t.Cleanup(func() { releaseMessage(messageID) })
+
+	var wins atomic.Int32
+	var wg sync.WaitGroup
+	start := make(chan struct{})
+	for range 50 {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			<-start
+			if claimMessage(messageID) {
+				wins.Add(1)
+			}
+		}()
+	}
+	close(start)
+	wg.Wait()
+
+	if got := wins.Load(); got != 1 {
+		t.Fatalf("%d claims succeeded, want exactly 1", got)
+	}
+
+	// Once released the message can be handled again
+	releaseMessage(messageID)
+	if !claimMessage(messageID) {
+		t.Errorf("claim after release failed")
+	}
+}
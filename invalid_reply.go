@@ -0,0 +1,89 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Window used when counting invalid-email replies to one user
+const invalidReplyWindow = time.Minute
+
+var (
+	invalidReplies   = make(map[string][]time.Time)
+	invalidReplyLock sync.Mutex
+)
+
+// allowInvalidReply reports whether the user should still get an "invalid
+// email" reply. Once they've had limit replies inside the window the bot stops
+// answering, so spamming the DM can't run the bot into its own send limits.
+func allowInvalidReply(userID string, limit int, now time.Time) bool {
+	if limit <= 0 {
+		return true
+	}
+
+	invalidReplyLock.Lock()
+	defer invalidReplyLock.Unlock()
+
+	var recent []time.Time
+	for _, sent := range invalidReplies[userID] {
+		if now.Sub(sent) < invalidReplyWindow {
+			recent = append(recent, sent)
+		}
+	}
+	if len(recent) >= limit {
+		invalidReplies[userID] = recent
+		return false
+	}
+	invalidReplies[userID] = append(recent, now)
+	return true
+}
+
+func setInvalidReplyLimit(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	limit := options[0].IntValue()
+	guildID := i.GuildID
+
+	if limit < 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The limit cannot be negative",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.InvalidReplyLimit = int(limit)
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	slog.Info("Invalid email reply limit set", "guild_id", guildID, "limit", limit)
+	content := "Invalid email replies are no longer limited! :white_check_mark:"
+	if limit > 0 {
+		content = fmt.Sprintf("Users will get at most %d invalid email replies per minute! :white_check_mark:", limit)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
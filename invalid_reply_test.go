@@ -0,0 +1,49 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestAllowInvalidReply(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name  string
+		limit int
+		// Offsets from start of each invalid submission
+		at   []time.Duration
+		want []bool
+	}{
+		{
+			name:  "no limit",
+			limit: 0,
+			at:    []time.Duration{0, time.Second, 2 * time.Second},
+			want:  []bool{true, true, true},
+		},
+		{
+			name:  "stops at the limit",
+			limit: 2,
+			at:    []time.Duration{0, time.Second, 2 * time.Second},
+			want:  []bool{true, true, false},
+		},
+		{
+			name:  "resumes after the window",
+			limit: 1,
+			at:    []time.Duration{0, time.Second, invalidReplyWindow + time.Second},
+			want:  []bool{true, false, true},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			userID := "invalid-reply-" + tt.name
+			for idx, offset := range tt.at {
+				got := allowInvalidReply(userID, tt.limit, start.Add(offset))
+				if got != tt.want[idx] {
+					t.Errorf("reply %d: allowInvalidReply() = %v, want %v", idx+1, got, tt.want[idx])
+				}
+			}
+		})
+	}
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"sync"
+	"time"
+)
+
+// Join events for the same member within this window are treated as one
+const joinDedupWindow = 5 * time.Second
+
+var (
+	recentJoins     = make(map[string]time.Time)
+	recentJoinsLock sync.Mutex
+)
+
+// firstJoinEvent records a join and reports whether it is the first for the
+// member within the dedup window. Duplicates should be ignored.
+func firstJoinEvent(guildID, userID string, now time.Time) bool {
+	recentJoinsLock.Lock()
+	defer recentJoinsLock.Unlock()
+
+	// Drop expired entries so the map doesn't grow with every join
+	for key, joinedAt := range recentJoins {
+		if now.Sub(joinedAt) >= joinDedupWindow {
+			delete(recentJoins, key)
+		}
+	}
+
+	key := guildID + ":" + userID
+	if _, exists := recentJoins[key]; exists {
+		return false
+	}
+	recentJoins[key] = now
+	return true
+}
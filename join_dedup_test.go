@@ -0,0 +1,32 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestFirstJoinEvent(t *testing.T) {
+	start := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name    string
+		guildID string
+		userID  string
+		at      time.Duration
+		want    bool
+	}{
+		{name: "first join", guildID: "g1", userID: "u1", at: 0, want: true},
+		{name: "duplicate event", guildID: "g1", userID: "u1", at: time.Second, want: false},
+		{name: "same user in another guild", guildID: "g2", userID: "u1", at: time.Second, want: true},
+		{name: "another user", guildID: "g1", userID: "u2", at: 2 * time.Second, want: true},
+		{name: "rejoin after the window", guildID: "g1", userID: "u1", at: joinDedupWindow, want: true},
+	}
+
+	// Cases run in order, each building on the joins recorded before it
+	for _, tt := range tests {
+		got := firstJoinEvent(tt.guildID, tt.userID, start.Add(tt.at))
+		if got != tt.want {
+			t.Errorf("%s: firstJoinEvent() = %v, want %v", tt.name, got, tt.want)
+		}
+	}
+}
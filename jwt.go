@@ -0,0 +1,344 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ldavidson8/computing-society-mod-bot/store"
+)
+
+// How long fetched signing keys are trusted before being refreshed
+const jwksCacheTTL = time.Hour
+
+// Tokens with a key ID we don't know trigger a refetch at most this often,
+// so made-up key IDs can't hammer the institution's JWKS endpoint
+const jwksRefetchInterval = time.Minute
+
+var (
+	errMalformedToken   = errors.New("malformed token")
+	errUnsupportedAlg   = errors.New("unsupported signing algorithm")
+	errUnknownKey       = errors.New("token signed by an unknown key")
+	errBadSignature     = errors.New("invalid signature")
+	errTokenExpired     = errors.New("token has expired")
+	errTokenNotYetValid = errors.New("token is not valid yet")
+	errWrongIssuer      = errors.New("token was issued by a different institution")
+	errWrongAudience    = errors.New("token was issued for a different service")
+	errTokenReused      = errors.New("token has already been used to verify another account")
+	errWrongAffiliation = errors.New("token does not have the required affiliation")
+)
+
+type jwtHeader struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+type jwtClaims struct {
+	Issuer    string          `json:"iss"`
+	Subject   string          `json:"sub"`
+	RawAud    json.RawMessage `json:"aud"`
+	TokenID   string          `json:"jti"`
+	Email     string          `json:"email"`
+	ExpiresAt int64           `json:"exp"`
+	NotBefore int64           `json:"nbf"`
+	RawAffil  json.RawMessage `json:"affiliation"`
+
+	// Microsoft identity platform claims
+	TenantID            string `json:"tid"`
+	PreferredUsername   string `json:"preferred_username"`
+	EmailDomainVerified bool   `json:"xms_edov"`
+}
+
+// affiliations returns the affiliation claim, which institutions issue either
+// as a single string or a list.
+func (c *jwtClaims) affiliations() []string {
+	var single string
+	if json.Unmarshal(c.RawAffil, &single) == nil {
+		return []string{single}
+	}
+	var list []string
+	if json.Unmarshal(c.RawAffil, &list) == nil {
+		return list
+	}
+	return nil
+}
+
+// audiences returns the aud claim, which may be a single string or a list.
+func (c *jwtClaims) audiences() []string {
+	var single string
+	if json.Unmarshal(c.RawAud, &single) == nil {
+		return []string{single}
+	}
+	var list []string
+	if json.Unmarshal(c.RawAud, &list) == nil {
+		return list
+	}
+	return nil
+}
+
+type jwksCacheEntry struct {
+	keys      map[string]*rsa.PublicKey
+	fetchedAt time.Time
+	// When a fetch was last started, successful or not
+	attemptedAt time.Time
+}
+
+var (
+	jwksCache     = make(map[string]jwksCacheEntry)
+	jwksCacheLock sync.Mutex
+	jwksClient    = &http.Client{Timeout: 10 * time.Second}
+)
+
+// looksLikeJWT reports whether a DM is a pasted token rather than an email.
+func looksLikeJWT(content string) bool {
+	return strings.HasPrefix(content, "eyJ") && strings.Count(content, ".") == 2
+}
+
+func fetchJWKS(url string) (map[string]*rsa.PublicKey, error) {
+	resp, err := jwksClient.Get(url)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("fetching JWKS: unexpected status %s", resp.Status)
+	}
+
+	var jwks struct {
+		Keys []struct {
+			Kty string `json:"kty"`
+			Kid string `json:"kid"`
+			N   string `json:"n"`
+			E   string `json:"e"`
+		} `json:"keys"`
+	}
+	err = json.NewDecoder(resp.Body).Decode(&jwks)
+	if err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]*rsa.PublicKey)
+	for _, key := range jwks.Keys {
+		if key.Kty != "RSA" {
+			continue
+		}
+		n, err := base64.RawURLEncoding.DecodeString(key.N)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.Kid, err)
+		}
+		e, err := base64.RawURLEncoding.DecodeString(key.E)
+		if err != nil {
+			return nil, fmt.Errorf("key %s: %w", key.Kid, err)
+		}
+		keys[key.Kid] = &rsa.PublicKey{
+			N: new(big.Int).SetBytes(n),
+			E: int(new(big.Int).SetBytes(e).Int64()),
+		}
+	}
+	return keys, nil
+}
+
+// jwksKey looks up a signing key, refreshing the cached key set when it is
+// stale or doesn't contain the requested key ID. Refreshes are limited to one
+// per jwksRefetchInterval and happen outside the lock, so a slow endpoint
+// doesn't hold up tokens whose keys are already cached.
+func jwksKey(url, kid string) (*rsa.PublicKey, error) {
+	now := time.Now()
+
+	jwksCacheLock.Lock()
+	entry := jwksCache[url]
+	key, known := entry.keys[kid]
+	if known && now.Sub(entry.fetchedAt) < jwksCacheTTL {
+		jwksCacheLock.Unlock()
+		return key, nil
+	}
+	if now.Sub(entry.attemptedAt) < jwksRefetchInterval {
+		jwksCacheLock.Unlock()
+		if known {
+			return key, nil
+		}
+		return nil, errUnknownKey
+	}
+	entry.attemptedAt = now
+	jwksCache[url] = entry
+	jwksCacheLock.Unlock()
+
+	keys, err := fetchJWKS(url)
+	if err != nil {
+		if known {
+			slog.Warn("Error refreshing JWKS, using cached keys", "url", url, "error", err)
+			return key, nil
+		}
+		return nil, err
+	}
+
+	jwksCacheLock.Lock()
+	entry = jwksCache[url]
+	entry.keys = keys
+	entry.fetchedAt = now
+	jwksCache[url] = entry
+	jwksCacheLock.Unlock()
+
+	key, ok := keys[kid]
+	if !ok {
+		return nil, errUnknownKey
+	}
+	return key, nil
+}
+
+// validateInstitutionJWT checks an RS256 token's signature and claims. The
+// audience must be given so tokens meant for other services are refused; an
+// empty affiliation accepts any affiliation.
+func validateInstitutionJWT(token string, keyFunc func(kid string) (*rsa.PublicKey, error), issuer, audience, affiliation string, now time.Time) (*jwtClaims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, errMalformedToken
+	}
+
+	headerJSON, err := base64.RawURLEncoding.DecodeString(parts[0])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	var header jwtHeader
+	if json.Unmarshal(headerJSON, &header) != nil {
+		return nil, errMalformedToken
+	}
+	if header.Alg != "RS256" {
+		return nil, errUnsupportedAlg
+	}
+
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+
+	key, err := keyFunc(header.Kid)
+	if err != nil {
+		return nil, err
+	}
+	digest := sha256.Sum256([]byte(parts[0] + "." + parts[1]))
+	if rsa.VerifyPKCS1v15(key, crypto.SHA256, digest[:], signature) != nil {
+		return nil, errBadSignature
+	}
+
+	claimsJSON, err := base64.RawURLEncoding.DecodeString(parts[1])
+	if err != nil {
+		return nil, errMalformedToken
+	}
+	var claims jwtClaims
+	if json.Unmarshal(claimsJSON, &claims) != nil {
+		return nil, errMalformedToken
+	}
+
+	if claims.ExpiresAt == 0 || now.Unix() >= claims.ExpiresAt {
+		return nil, errTokenExpired
+	}
+	if claims.NotBefore != 0 && now.Unix() < claims.NotBefore {
+		return nil, errTokenNotYetValid
+	}
+	if claims.Issuer != issuer {
+		return nil, errWrongIssuer
+	}
+	if audience == "" || !slices.Contains(claims.audiences(), audience) {
+		return nil, errWrongAudience
+	}
+	if affiliation != "" {
+		matched := false
+		for _, value := range claims.affiliations() {
+			if strings.EqualFold(value, affiliation) {
+				matched = true
+				break
+			}
+		}
+		if !matched {
+			return nil, errWrongAffiliation
+		}
+	}
+	return &claims, nil
+}
+
+func verifyWithJWT(s discordSession, m *discordgo.MessageCreate, guildID string, serverConfig ServerConfig) {
+	if serverConfig.JWKSURL == "" {
+		replyToSubmission(s, m, "Token verification isn't enabled for this server. Please provide your university email instead.")
+		return
+	}
+	// Set up before audiences were required; refuse rather than accept
+	// tokens meant for any service
+	if serverConfig.JWTAudience == "" {
+		slog.Warn("Token verification has no audience set; an admin needs to run /set_jwt_verification again", "guild_id", guildID)
+		replyToSubmission(s, m, "Token verification isn't fully set up on this server yet. Please provide your university email instead.")
+		return
+	}
+
+	keyFunc := func(kid string) (*rsa.PublicKey, error) {
+		return jwksKey(serverConfig.JWKSURL, kid)
+	}
+	claims, err := validateInstitutionJWT(m.Content, keyFunc, serverConfig.JWTIssuer, serverConfig.JWTAudience, serverConfig.JWTAffiliation, time.Now())
+	if err == nil {
+		err = claimTokenIdentity(guildID, m.Author.ID, claims)
+	}
+	if err != nil {
+		slog.Warn("Token verification failed", "user_id", m.Author.ID, "error", err)
+
+		reason := "please try again later"
+		for _, known := range []error{errMalformedToken, errUnsupportedAlg, errUnknownKey, errBadSignature, errTokenExpired, errTokenNotYetValid, errWrongIssuer, errWrongAudience, errWrongAffiliation, errTokenReused} {
+			if errors.Is(err, known) {
+				reason = known.Error()
+				break
+			}
+		}
+		replyToSubmission(s, m, "Sorry, that token could not be verified: "+reason)
+		return
+	}
+
+	// Remove unverified role
+	if serverConfig.UnverifiedRoleID != "" {
+		err = withRetry(func() error { return memberRoleRemove(s, guildID, m.Author.ID, serverConfig.UnverifiedRoleID) })
+		if err != nil {
+			slog.Error("Error removing unverified role", "error", err)
+			replyToSubmission(s, m, "Your token was valid but something went wrong removing your unverified role. Please contact a moderator.")
+			return
+		}
+	}
+	grantVerifiedRole(s, guildID, m.Author.ID)
+
+	recordDecision(s, VerificationLogEntry{
+		GuildID: guildID,
+		UserID:  m.Author.ID,
+		Action:  "approve",
+		Time:    time.Now(),
+	})
+
+	replyToSubmission(s, m, "Your token has been verified and you now have access to the server. Welcome! 🎉")
+}
+
+// claimTokenIdentity records the token's ID and subject against the member,
+// so a leaked token can't verify anyone else. The same person re-verifying,
+// for example after rejoining, is let through on their subject but a token
+// ID is only ever accepted once.
+func claimTokenIdentity(guildID, userID string, claims *jwtClaims) error {
+	if claims.Subject == "" {
+		return errMalformedToken
+	}
+	err := configStore.ClaimToken(guildID, "sub", claims.Subject, userID)
+	if err == nil && claims.TokenID != "" {
+		err = configStore.ClaimToken(guildID, "jti", claims.TokenID, "")
+	}
+	if errors.Is(err, store.ErrTokenClaimed) {
+		return errTokenReused
+	}
+	return err
+}
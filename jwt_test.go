@@ -0,0 +1,184 @@
+package main
+
+import (
+	"crypto"
+	"crypto/rand"
+	"crypto/rsa"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"testing"
+	"time"
+)
+
+// signTestJWT builds an RS256 token with the given header and claims
+func signTestJWT(t *testing.T, key *rsa.PrivateKey, header, claims map[string]any) string {
+	t.Helper()
+	encode := func(v any) string {
+		data, err := json.Marshal(v)
+		if err != nil {
+			t.Fatal(err)
+		}
+		return base64.RawURLEncoding.EncodeToString(data)
+	}
+
+	signingInput := encode(header) + "." + encode(claims)
+	digest := sha256.Sum256([]byte(signingInput))
+	signature, err := rsa.SignPKCS1v15(rand.Reader, key, crypto.SHA256, digest[:])
+	if err != nil {
+		t.Fatal(err)
+	}
+	return signingInput + "." + base64.RawURLEncoding.EncodeToString(signature)
+}
+
+func TestValidateInstitutionJWT(t *testing.T) {
+	key, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	otherKey, err := rsa.GenerateKey(rand.Reader, 2048)
+	if err != nil {
+		t.Fatal(err)
+	}
+	keyFunc := func(kid string) (*rsa.PublicKey, error) {
+		if kid != "key1" {
+			return nil, errUnknownKey
+		}
+		return &key.PublicKey, nil
+	}
+
+	const (
+		issuer   = "https://idp.uclan.ac.uk"
+		audience = "mod-bot"
+	)
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	header := map[string]any{"alg": "RS256", "kid": "key1"}
+
+	// validClaims returns a token body that passes every check, with the
+	// given claims changed
+	validClaims := func(changes map[string]any) map[string]any {
+		claims := map[string]any{
+			"iss":         issuer,
+			"sub":         "student-1",
+			"aud":         audience,
+			"email":       "student@uclan.ac.uk",
+			"exp":         now.Add(time.Hour).Unix(),
+			"affiliation": "student",
+		}
+		for name, value := range changes {
+			if value == nil {
+				delete(claims, name)
+			} else {
+				claims[name] = value
+			}
+		}
+		return claims
+	}
+
+	tests := []struct {
+		name        string
+		token       string
+		audience    string
+		affiliation string
+		wantErr     error
+	}{
+		{
+			name:        "valid",
+			token:       signTestJWT(t, key, header, validClaims(nil)),
+			audience:    audience,
+			affiliation: "student",
+		},
+		{
+			name:        "audience list and affiliation list",
+			token:       signTestJWT(t, key, header, validClaims(map[string]any{"aud": []string{"other", audience}, "affiliation": []string{"staff", "Student"}})),
+			audience:    audience,
+			affiliation: "student",
+		},
+		{
+			name:     "any affiliation",
+			token:    signTestJWT(t, key, header, validClaims(map[string]any{"affiliation": nil})),
+			audience: audience,
+		},
+		{name: "not a JWT", token: "not.a.jwt", audience: audience, wantErr: errMalformedToken},
+		{name: "two parts", token: "abc.def", audience: audience, wantErr: errMalformedToken},
+		{
+			name:     "wrong algorithm",
+			token:    signTestJWT(t, key, map[string]any{"alg": "HS256", "kid": "key1"}, validClaims(nil)),
+			audience: audience,
+			wantErr:  errUnsupportedAlg,
+		},
+		{
+			name:     "unknown key",
+			token:    signTestJWT(t, key, map[string]any{"alg": "RS256", "kid": "key2"}, validClaims(nil)),
+			audience: audience,
+			wantErr:  errUnknownKey,
+		},
+		{
+			name:     "signed by another key",
+			token:    signTestJWT(t, otherKey, header, validClaims(nil)),
+			audience: audience,
+			wantErr:  errBadSignature,
+		},
+		{
+			name:     "expired",
+			token:    signTestJWT(t, key, header, validClaims(map[string]any{"exp": now.Unix()})),
+			audience: audience,
+			wantErr:  errTokenExpired,
+		},
+		{
+			name:     "no expiry",
+			token:    signTestJWT(t, key, header, validClaims(map[string]any{"exp": nil})),
+			audience: audience,
+			wantErr:  errTokenExpired,
+		},
+		{
+			name:     "not valid yet",
+			token:    signTestJWT(t, key, header, validClaims(map[string]any{"nbf": now.Add(time.Minute).Unix()})),
+			audience: audience,
+			wantErr:  errTokenNotYetValid,
+		},
+		{
+			name:     "other issuer",
+			token:    signTestJWT(t, key, header, validClaims(map[string]any{"iss": "https://idp.example.com"})),
+			audience: audience,
+			wantErr:  errWrongIssuer,
+		},
+		{
+			name:     "other audience",
+			token:    signTestJWT(t, key, header, validClaims(map[string]any{"aud": "other"})),
+			audience: audience,
+			wantErr:  errWrongAudience,
+		},
+		{
+			name:     "no audience claim",
+			token:    signTestJWT(t, key, header, validClaims(map[string]any{"aud": nil})),
+			audience: audience,
+			wantErr:  errWrongAudience,
+		},
+		{
+			name:    "no audience configured",
+			token:   signTestJWT(t, key, header, validClaims(nil)),
+			wantErr: errWrongAudience,
+		},
+		{
+			name:        "wrong affiliation",
+			token:       signTestJWT(t, key, header, validClaims(map[string]any{"affiliation": "staff"})),
+			audience:    audience,
+			affiliation: "student",
+			wantErr:     errWrongAffiliation,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			claims, err := validateInstitutionJWT(tt.token, keyFunc, issuer, tt.audience, tt.affiliation, now)
+			if !errors.Is(err, tt.wantErr) {
+				t.Fatalf("err = %v, want %v", err, tt.wantErr)
+			}
+			if err == nil && claims.Email != "student@uclan.ac.uk" {
+				t.Errorf("email = %q, want student@uclan.ac.uk", claims.Email)
+			}
+		})
+	}
+}
@@ -0,0 +1,143 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"maps"
+	"os"
+	"path/filepath"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+// Locale files, one per language code such as cy.json, each a JSON object of
+// embed text keyed like builtinEmbedLanguages. They add languages or
+// override the built-in text.
+const localesDir = "./locales"
+
+// Editors often save a file in several steps, so reloads wait this long for
+// changes to settle
+const localeReloadDelay = 500 * time.Millisecond
+
+// loadLocaleFiles merges the locale files in dir over the built-in languages.
+// A missing directory just means there is nothing to add.
+func loadLocaleFiles(dir string) (map[string]map[string]string, error) {
+	languages := make(map[string]map[string]string, len(builtinEmbedLanguages))
+	for code, texts := range builtinEmbedLanguages {
+		languages[code] = maps.Clone(texts)
+	}
+
+	entries, err := os.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return languages, nil
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	for _, entry := range entries {
+		if entry.IsDir() || filepath.Ext(entry.Name()) != ".json" {
+			continue
+		}
+		data, err := os.ReadFile(filepath.Join(dir, entry.Name()))
+		if err != nil {
+			return nil, err
+		}
+		var texts map[string]string
+		err = json.Unmarshal(data, &texts)
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", entry.Name(), err)
+		}
+
+		code := strings.ToLower(strings.TrimSuffix(entry.Name(), ".json"))
+		if languages[code] == nil {
+			languages[code] = make(map[string]string, len(texts))
+		}
+		maps.Copy(languages[code], texts)
+	}
+	return languages, nil
+}
+
+// reloadLocales swaps in the languages from dir. If any file is invalid the
+// languages already in use are kept.
+func reloadLocales(dir string) error {
+	languages, err := loadLocaleFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	embedLanguagesLock.Lock()
+	embedLanguages = languages
+	embedLanguagesLock.Unlock()
+	return nil
+}
+
+// localeWatcher reloads the locale files after they change on disk, once the
+// changes have settled.
+type localeWatcher struct {
+	dir    string
+	delay  time.Duration
+	reload func(dir string) error
+
+	mu    sync.Mutex
+	timer *time.Timer
+}
+
+func (w *localeWatcher) handleEvent(event fsnotify.Event) {
+	if filepath.Ext(event.Name) != ".json" || !event.Has(fsnotify.Write|fsnotify.Create|fsnotify.Remove|fsnotify.Rename) {
+		return
+	}
+
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	// Start the wait again, so a burst of changes causes one reload
+	if w.timer != nil {
+		w.timer.Stop()
+	}
+	w.timer = time.AfterFunc(w.delay, func() {
+		err := w.reload(w.dir)
+		if err != nil {
+			slog.Error("Error reloading locales", "dir", w.dir, "error", err)
+			return
+		}
+		slog.Info("Reloaded locales", "dir", w.dir)
+	})
+}
+
+// watchLocales reloads the locale files in dir whenever they change, until
+// the returned watcher is closed.
+func watchLocales(dir string) (*fsnotify.Watcher, error) {
+	watcher, err := fsnotify.NewWatcher()
+	if err != nil {
+		return nil, err
+	}
+	err = watcher.Add(dir)
+	if err != nil {
+		watcher.Close()
+		return nil, err
+	}
+
+	w := &localeWatcher{dir: dir, delay: localeReloadDelay, reload: reloadLocales}
+	go func() {
+		for {
+			select {
+			case event, ok := <-watcher.Events:
+				if !ok {
+					return
+				}
+				w.handleEvent(event)
+			case err, ok := <-watcher.Errors:
+				if !ok {
+					return
+				}
+				slog.Error("Error watching locales", "dir", dir, "error", err)
+			}
+		}
+	}()
+	return watcher, nil
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"os"
+	"path/filepath"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/fsnotify/fsnotify"
+)
+
+func writeLocale(t *testing.T, dir, name, content string) string {
+	t.Helper()
+	path := filepath.Join(dir, name)
+	if err := os.WriteFile(path, []byte(content), 0644); err != nil {
+		t.Fatal(err)
+	}
+	return path
+}
+
+func TestLoadLocaleFiles(t *testing.T) {
+	dir := t.TempDir()
+	writeLocale(t, dir, "cy.json", `{"title": "Cais i ddilysu"}`)
+	writeLocale(t, dir, "DE.json", `{"language_name": "Deutsch", "title": "Verifizierungsanfrage"}`)
+	writeLocale(t, dir, "notes.txt", `not a locale`)
+
+	languages, err := loadLocaleFiles(dir)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	if got := languages["cy"]["title"]; got != "Cais i ddilysu" {
+		t.Errorf("overridden title = %q", got)
+	}
+	if got := languages["cy"]["email"]; got != "E-bost" {
+		t.Errorf("built-in text not kept alongside the override: %q", got)
+	}
+	if got := languages["de"]["title"]; got != "Verifizierungsanfrage" {
+		t.Errorf("added language title = %q", got)
+	}
+	if got := builtinEmbedLanguages["cy"]["title"]; got != "Cais dilysu" {
+		t.Errorf("built-in languages changed to %q", got)
+	}
+
+	// No directory means just the built-in languages
+	languages, err = loadLocaleFiles(filepath.Join(dir, "missing"))
+	if err != nil || len(languages) != len(builtinEmbedLanguages) {
+		t.Errorf("missing dir = %d languages, %v, want the built-in ones", len(languages), err)
+	}
+}
+
+func TestReloadLocalesKeepsLanguagesOnError(t *testing.T) {
+	useEmbedLanguages(t, builtinEmbedLanguages)
+	dir := t.TempDir()
+	writeLocale(t, dir, "de.json", `{"title": "Verifizierungsanfrage"}`)
+	writeLocale(t, dir, "fr.json", `{"title": `)
+
+	if err := reloadLocales(dir); err == nil {
+		t.Fatal("reloadLocales() succeeded with an invalid file")
+	}
+	if embedLanguageExists("de") {
+		t.Errorf("languages partly reloaded despite the invalid file")
+	}
+}
+
+func TestLocaleWatcherDebounces(t *testing.T) {
+	var reloads atomic.Int32
+	w := &localeWatcher{
+		dir:   "locales",
+		delay: 20 * time.Millisecond,
+		reload: func(string) error {
+			reloads.Add(1)
+			return nil
+		},
+	}
+
+	// A burst of saves, plus events that shouldn't cause a reload at all
+	for range 5 {
+		w.handleEvent(fsnotify.Event{Name: "locales/cy.json", Op: fsnotify.Write})
+	}
+	w.handleEvent(fsnotify.Event{Name: "locales/cy.json", Op: fsnotify.Chmod})
+	w.handleEvent(fsnotify.Event{Name: "locales/cy.json.swp", Op: fsnotify.Write})
+
+	time.Sleep(100 * time.Millisecond)
+	if got := reloads.Load(); got != 1 {
+		t.Fatalf("reloaded %d times after a burst of changes, want 1", got)
+	}
+
+	w.handleEvent(fsnotify.Event{Name: "locales/de.json", Op: fsnotify.Remove})
+	time.Sleep(100 * time.Millisecond)
+	if got := reloads.Load(); got != 2 {
+		t.Errorf("reloaded %d times after a later change, want 2", got)
+	}
+}
+
+func TestLocaleWatcherReloadsOnChange(t *testing.T) {
+	useEmbedLanguages(t, builtinEmbedLanguages)
+	dir := t.TempDir()
+	w := &localeWatcher{dir: dir, delay: 10 * time.Millisecond, reload: reloadLocales}
+
+	path := writeLocale(t, dir, "de.json", `{"language_name": "Deutsch", "title": "Verifizierungsanfrage"}`)
+	w.handleEvent(fsnotify.Event{Name: path, Op: fsnotify.Create})
+
+	deadline := time.Now().Add(time.Second)
+	for !embedLanguageExists("de") && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := embedText("de", "title"); got != "Verifizierungsanfrage" {
+		t.Errorf("title after the change = %q, want the new file's", got)
+	}
+}
@@ -0,0 +1,30 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+	"strings"
+)
+
+// parseLogLevel maps LOG_LEVEL to a slog level, defaulting to info.
+func parseLogLevel(value string) slog.Level {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug
+	case "warn", "warning":
+		return slog.LevelWarn
+	case "error":
+		return slog.LevelError
+	default:
+		return slog.LevelInfo
+	}
+}
+
+// setupLogging installs a structured logger. The standard log package is
+// routed through it too, so the remaining log.Fatal calls share the format.
+func setupLogging() {
+	handler := slog.NewTextHandler(os.Stderr, &slog.HandlerOptions{
+		Level: parseLogLevel(os.Getenv("LOG_LEVEL")),
+	})
+	slog.SetDefault(slog.New(handler))
+}
@@ -1,12 +1,19 @@
 package main
 
 import (
+	"context"
 	"encoding/json"
+	"errors"
 	"fmt"
 	"log"
+	"log/slog"
+	"net/http"
+	"net/url"
 	"os"
 	"os/signal"
 	"regexp"
+	"runtime"
+	"strconv"
 	"strings"
 	"sync"
 	"syscall"
@@ -17,10 +24,73 @@ import (
 )
 
 type ServerConfig struct {
-	MemberAuditChannelID string        `json:"member_audit_channel_id"`
-	UnverifiedRoleID     string        `json:"unverified_role_id"`
-	RateLimitEnabled     bool          `json:"rate_limit_enabled"`
-	RateLimitDuration    time.Duration `json:"rate_limit_duration"`
+	MemberAuditChannelID   string              `json:"member_audit_channel_id"`
+	UnverifiedRoleID       string              `json:"unverified_role_id"`
+	RateLimitEnabled       bool                `json:"rate_limit_enabled"`
+	RateLimitDuration      time.Duration       `json:"rate_limit_duration"`
+	DenialRetries          int                 `json:"denial_retries"`
+	BatchReviewWindow      time.Duration       `json:"batch_review_window"`
+	JWTIssuer              string              `json:"jwt_issuer"`
+	JWKSURL                string              `json:"jwks_url"`
+	JWTAffiliation         string              `json:"jwt_affiliation"`
+	JWTAudience            string              `json:"jwt_audience"`
+	ModActionCooldown      time.Duration       `json:"mod_action_cooldown"`
+	QuietAuditResults      bool                `json:"quiet_audit_results"`
+	QueueRateLimited       bool                `json:"queue_rate_limited"`
+	AuditReactions         []string            `json:"audit_reactions"`
+	AzureTenantID          string              `json:"azure_tenant_id"`
+	SummaryTime            string              `json:"summary_time"`
+	SummaryTimezone        string              `json:"summary_timezone"`
+	SummaryRoleID          string              `json:"summary_role_id"`
+	SummaryUserIDs         []string            `json:"summary_user_ids"`
+	MembershipAPIURL       string              `json:"membership_api_url"`
+	MembershipAPIToken     string              `json:"membership_api_token"`
+	MembershipMode         string              `json:"membership_mode"`
+	SMSVerificationEnabled bool                `json:"sms_verification_enabled"`
+	VerificationFields     []VerificationField `json:"verification_fields"`
+	MilestoneChannelID     string              `json:"milestone_channel_id"`
+	MilestoneInterval      int                 `json:"milestone_interval"`
+	PreventSelfApproval    bool                `json:"prevent_self_approval"`
+	PartnerGuildID         string              `json:"partner_guild_id"`
+	PartnerRoleID          string              `json:"partner_role_id"`
+	BulkBatchSize          int                 `json:"bulk_batch_size"`
+	BulkBatchInterval      time.Duration       `json:"bulk_batch_interval"`
+	WelcomeVoiceChannelID  string              `json:"welcome_voice_channel_id"`
+	WelcomeChannelID       string              `json:"welcome_channel_id"`
+	InvalidReplyLimit      int                 `json:"invalid_reply_limit"`
+	AllowedEmailDomains    []string            `json:"allowed_email_domains"`
+	VerificationMode       string              `json:"verification_mode"`
+	WelcomeMessage         string              `json:"welcome_message"`
+	DenialMessage          string              `json:"denial_message"`
+	AutoKickMessage        string              `json:"auto_kick_message"`
+	InviteLink             string              `json:"invite_link"`
+	VerificationTimeout    time.Duration       `json:"verification_timeout"`
+	AutoKickPaused         bool                `json:"auto_kick_paused"`
+	VerificationChannelID  string              `json:"verification_channel_id"`
+	FallbackAuditChannelID string              `json:"fallback_audit_channel_id"`
+	WarnThreshold          int                 `json:"warn_threshold"`
+	WarnTimeout            time.Duration       `json:"warn_timeout"`
+	ConfirmationMessage    string              `json:"confirmation_message"`
+	CooldownMessage        string              `json:"cooldown_message"`
+	VerificationMessages   map[string]string   `json:"verification_messages"`
+	MaxAttempts            int                 `json:"max_attempts"`
+	AttemptCooldown        time.Duration       `json:"attempt_cooldown"`
+	AttemptCooldownMessage string              `json:"attempt_cooldown_message"`
+	MinAccountAge          time.Duration       `json:"min_account_age"`
+	AccountAgeCutoff       time.Duration       `json:"account_age_cutoff"`
+	ReviewerRoleID         string              `json:"reviewer_role_id"`
+	MuteReviewerPing       bool                `json:"mute_reviewer_ping"`
+	StudentIDRegex         string              `json:"student_id_regex"`
+	EmailRegexPattern      string              `json:"email_regex_pattern"`
+	UseAuditThreads        bool                `json:"use_audit_threads"`
+	AuditThreadArchive     int                 `json:"audit_thread_archive"`
+	ArchiveDecidedThreads  bool                `json:"archive_decided_threads"`
+	EmailAllowlist         []string            `json:"email_allowlist"`
+	Blocklist              []string            `json:"blocklist"`
+	BlocklistKick          bool                `json:"blocklist_kick"`
+	VerifiedRoleID         string              `json:"verified_role_id"`
+	AppealsEnabled         bool                `json:"appeals_enabled"`
+	EmbedLanguage          string              `json:"embed_language"`
 }
 
 type Config struct {
@@ -33,54 +103,178 @@ var (
 )
 
 var (
-	emailRegex    = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@uclan\.ac\.uk$`)
+	emailRegex    = regexp.MustCompile(`^[a-zA-Z0-9._%+-]+@([a-zA-Z0-9.-]+)$`)
+	userIDRegex   = regexp.MustCompile(`\d{17,20}`)
+	phoneRegex    = regexp.MustCompile(`^\+[1-9]\d{7,14}$`)
+	codeRegex     = regexp.MustCompile(`^\d{6}$`)
+	tenantIDRegex = regexp.MustCompile(`^[0-9a-fA-F]{8}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{4}-[0-9a-fA-F]{12}$`)
 	rateLimitMap  = make(map[string]time.Time)
 	rateLimitLock sync.Mutex
 )
 
+const (
+	approvalMessage        = "You have been approved to join the UCLan Computing Society server. Welcome! 🎉"
+	defaultDenialMessage   = "Oops! You need to verify your identity with a UCLan email address to access the UCLan Computing Society server. This is to ensure only society members have access to the server and ensure we keep a safe and civil community.\n\nAs you did not verify your email, you were kicked from the server. You can rejoin and retry verification using this link: {invite_link}. Thank you 🙂"
+	defaultAutoKickMessage = "You were removed from the UCLan Computing Society server because your account wasn't verified in time. You can rejoin and verify using this link: {invite_link}"
+)
+
+// Number of recent decisions used to estimate review turnaround
+const turnaroundSampleSize = 20
+
+var (
+	denialRetryCounts = make(map[string]int)
+	denialRetryLock   sync.Mutex
+)
+
 func loadConfig() error {
-	data, err := os.ReadFile("./data/config.json")
+	stored, err := configStore.GuildConfigs()
 	if err != nil {
-		if os.IsNotExist(err) {
-			config = Config{Servers: make(map[string]ServerConfig)}
-			return nil
-		}
 		return err
 	}
+
+	servers := make(map[string]ServerConfig, len(stored))
+	for guildID, data := range stored {
+		var serverConfig ServerConfig
+		err = json.Unmarshal(data, &serverConfig)
+		if err != nil {
+			return fmt.Errorf("guild %s: %w", guildID, err)
+		}
+		servers[guildID] = serverConfig
+	}
+
 	configMutex.Lock()
 	defer configMutex.Unlock()
-	return json.Unmarshal(data, &config)
+	config = Config{Servers: servers}
+	return nil
 }
 
-func saveConfig() error {
-	configMutex.RLock()
-	defer configMutex.RUnlock()
-	data, err := json.MarshalIndent(config, "", "  ")
-	if err != nil {
-		return err
+// getOrCreateServerConfig returns the guild's config, adding an empty one for
+// guilds that haven't been set up yet so they get the default behaviour. The
+// new entry isn't saved until an admin changes a setting.
+func getOrCreateServerConfig(guildID string) ServerConfig {
+	configMutex.Lock()
+	defer configMutex.Unlock()
+	return getOrCreateServerConfigLocked(guildID)
+}
+
+// getOrCreateServerConfigLocked is getOrCreateServerConfig for callers that
+// already hold configMutex for writing.
+func getOrCreateServerConfigLocked(guildID string) ServerConfig {
+	serverConfig, exists := config.Servers[guildID]
+	if !exists {
+		config.Servers[guildID] = serverConfig
 	}
+	return serverConfig
+}
 
-	err = os.MkdirAll("./data", os.ModePerm)
+// saveConfig queues the guild's config to be written. Writes are coalesced
+// and happen in the background, see flushConfigSaves, so a failure shows up
+// on the next change after it.
+func saveConfig(guildID string) error {
+	configMutex.RLock()
+	_, err := json.Marshal(config.Servers[guildID])
+	configMutex.RUnlock()
 	if err != nil {
 		return err
 	}
-	return os.WriteFile("./data/config.json", data, 0644)
+	queueConfigSave(guildID)
+	if err := lastConfigSaveError(guildID); err != nil {
+		return fmt.Errorf("the change is active but saving it to storage keeps failing, so it will be lost on restart (%w)", err)
+	}
+	return nil
 }
 
 var (
-	commandHandlers = map[string]func(s *discordgo.Session, i *discordgo.InteractionCreate){
-		"set_member_audit_channel": setMemberAuditChannel,
-		"set_unverified_role":      setUnverifiedRole,
-		"enable_rate_limit":        enableRateLimit,
-		"disable_rate_limit":       disableRateLimit,
-		"set_rate_limit":           setRateLimit,
-		"check_rate_limit":         checkRateLimit,
+	commandHandlers = map[string]func(s discordSession, i *discordgo.InteractionCreate){
+		"set_member_audit_channel":     adminOnly(setMemberAuditChannel),
+		"set_unverified_role":          adminOnly(setUnverifiedRole),
+		"enable_rate_limit":            adminOnly(enableRateLimit),
+		"disable_rate_limit":           adminOnly(disableRateLimit),
+		"set_rate_limit":               adminOnly(setRateLimit),
+		"check_rate_limit":             checkRateLimit,
+		"set_denial_retries":           adminOnly(setDenialRetries),
+		"mod_leaderboard":              modLeaderboard,
+		"migrate_unverified_role":      adminOnly(migrateUnverifiedRole),
+		"set_batch_review_window":      adminOnly(setBatchReviewWindow),
+		"set_jwt_verification":         adminOnly(setJWTVerification),
+		"disable_jwt_verification":     adminOnly(disableJWTVerification),
+		"set_mod_action_cooldown":      adminOnly(setModActionCooldown),
+		"debug_events":                 debugEvents,
+		"set_audit_result_mode":        adminOnly(setAuditResultMode),
+		"set_rate_limit_queueing":      adminOnly(setRateLimitQueueing),
+		"set_audit_reactions":          adminOnly(setAuditReactions),
+		"set_embed_language":           adminOnly(setEmbedLanguage),
+		"set_azure_verification":       adminOnly(setAzureVerification),
+		"disable_azure_verification":   adminOnly(disableAzureVerification),
+		"verify_microsoft":             verifyMicrosoft,
+		"event_qr":                     adminOnly(createEventQR),
+		"set_review_summary":           adminOnly(setReviewSummary),
+		"disable_review_summary":       adminOnly(disableReviewSummary),
+		"update_config":                adminOnly(updateConfig),
+		"training_request":             trainingRequest,
+		"set_membership_api":           adminOnly(setMembershipAPI),
+		"disable_membership_api":       adminOnly(disableMembershipAPI),
+		"version":                      showVersion,
+		"set_sms_verification":         adminOnly(setSMSVerification),
+		"allow_guild":                  allowGuild,
+		"disallow_guild":               disallowGuild,
+		"add_verification_field":       adminOnly(addVerificationField),
+		"remove_verification_field":    adminOnly(removeVerificationField),
+		"set_milestone_announcements":  adminOnly(setMilestoneAnnouncements),
+		"set_self_approval":            adminOnly(setSelfApproval),
+		"set_partner_verification":     adminOnly(setPartnerVerification),
+		"disable_partner_verification": adminOnly(disablePartnerVerification),
+		"bulk_policy":                  showBulkPolicy,
+		"set_bulk_policy":              adminOnly(setBulkPolicy),
+		"set_welcome_channels":         adminOnly(setWelcomeChannels),
+		"set_invalid_reply_limit":      adminOnly(setInvalidReplyLimit),
+		"set_email_domain":             adminOnly(setEmailDomain),
+		"set_verification_mode":        adminOnly(setVerificationMode),
+		"verify_user":                  verifyUser,
+		"set_welcome_message":          adminOnly(setWelcomeMessage),
+		"set_denial_message":           adminOnly(setDenialMessage),
+		"set_auto_kick_message":        adminOnly(setAutoKickMessage),
+		"config":                       showConfig,
+		"set_verification_timeout":     adminOnly(setVerificationTimeout),
+		"reload_config":                reloadConfig,
+		"set_verification_channel":     adminOnly(setVerificationChannel),
+		"warn":                         warnUser,
+		"warnings":                     listWarnings,
+		"set_warn_threshold":           adminOnly(setWarnThreshold),
+		"purge":                        purgeMessages,
+		"set_confirmation_message":     adminOnly(setConfirmationMessage),
+		"set_email_pattern":            adminOnly(setEmailPattern),
+		"set_audit_threads":            adminOnly(setAuditThreads),
+		"stats":                        showStats,
+		"allow_email":                  adminOnly(allowEmail),
+		"disallow_email":               adminOnly(disallowEmail),
+		"block":                        adminOnly(blockEntry),
+		"unblock":                      adminOnly(unblockEntry),
+		"set_blocklist_kick":           adminOnly(setBlocklistKick),
+		"apply_unverified_role":        adminOnly(applyUnverifiedRole),
+		"set_verified_role":            adminOnly(setVerifiedRole),
+		"resend_welcome":               resendWelcome,
+		"set_appeals":                  adminOnly(setAppeals),
+		"set_cooldown_message":         adminOnly(setCooldownMessage),
+		"setup_verification_message":   adminOnly(setupVerificationMessage),
+		"remove_verification_message":  adminOnly(removeVerificationMessage),
+		"set_max_attempts":             adminOnly(setMaxAttempts),
+		"set_attempt_cooldown":         adminOnly(setAttemptCooldown),
+		"set_account_age":              adminOnly(setAccountAge),
+		"pending_verifications":        listPendingVerifications,
+		"set_reviewer_role":            adminOnly(setReviewerRole),
+		"reset_config":                 adminOnly(resetConfig),
+		"set_student_id_pattern":       adminOnly(setStudentIDPattern),
+		"check_email_domains":          adminOnly(checkEmailDomains),
+		"pause_auto_kick":              adminOnly(pauseAutoKick),
+		"resume_auto_kick":             adminOnly(resumeAutoKick),
 	}
 
 	commands = []*discordgo.ApplicationCommand{
 		{
-			Name:        "set_member_audit_channel",
-			Description: "Set the member audit channel",
+			Name:                     "set_member_audit_channel",
+			Description:              "Set the member audit channel",
+			DefaultMemberPermissions: &adminPermission,
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionChannel,
@@ -88,11 +282,18 @@ var (
 					Description: "The channel to use for member audits",
 					Required:    true,
 				},
+				{
+					Type:        discordgo.ApplicationCommandOptionChannel,
+					Name:        "fallback",
+					Description: "Channel to use if the audit channel can't be reached",
+					Required:    false,
+				},
 			},
 		},
 		{
-			Name:        "set_unverified_role",
-			Description: "Set the unverified role",
+			Name:                     "set_unverified_role",
+			Description:              "Set the unverified role",
+			DefaultMemberPermissions: &adminPermission,
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionRole,
@@ -103,22 +304,27 @@ var (
 			},
 		},
 		{
-			Name:        "enable_rate_limit",
-			Description: "Enable rate limiting for email verification",
+			Name:                     "enable_rate_limit",
+			Description:              "Enable rate limiting for email verification",
+			DefaultMemberPermissions: &adminPermission,
 		},
 		{
-			Name:        "disable_rate_limit",
-			Description: "Disable rate limiting for email verification",
+			Name:                     "disable_rate_limit",
+			Description:              "Disable rate limiting for email verification",
+			DefaultMemberPermissions: &adminPermission,
 		},
 		{
-			Name:        "set_rate_limit",
-			Description: "Set the rate limit for email verification",
+			Name:                     "set_rate_limit",
+			Description:              "Set the rate limit for email verification",
+			DefaultMemberPermissions: &adminPermission,
 			Options: []*discordgo.ApplicationCommandOption{
 				{
 					Type:        discordgo.ApplicationCommandOptionInteger,
 					Name:        "minutes",
 					Description: "The number of minutes to set the rate limit to",
 					Required:    true,
+					MinValue:    &minRateLimitMinutes,
+					MaxValue:    maxRateLimitMinutes,
 				},
 			},
 		},
@@ -126,331 +332,2852 @@ var (
 			Name:        "check_rate_limit",
 			Description: "Check the current rate limit status",
 		},
-	}
-)
-
-func main() {
-	// Recover from panics
-	defer func() {
-		if r := recover(); r != nil {
-			log.Fatalf("Bot crashed: %v", r)
-		}
-	}()
-
-	// Load config
-	err := loadConfig()
-	if err != nil {
-		log.Fatalf("Error loading config: %v", err)
-		// If the config doesn't exist, it's not a fatal error
-	}
-
-	// Load .env file
-	err = godotenv.Load()
-	if err != nil {
-		log.Fatal("Error loading .env file")
-	}
-	log.Println("Successfully loaded .env file")
-
-	// Get the token from the .env file
-	token := os.Getenv("DISCORD_TOKEN")
-	if token == "" {
-		log.Fatal("No token provided. Please set DISCORD_BOT_TOKEN in .env")
-	}
-	log.Println("Successfully retrieved bot token")
-
-	// Create a new Discord session using the provided bot token.
-	client, err := discordgo.New("Bot " + token)
-	if err != nil {
-		log.Fatal("Error creating Discord session:", err)
-	}
-	log.Println("Successfully created Discord session")
-
-	// Register handlers for different interaction types
-	client.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
-		switch i.Type {
-		case discordgo.InteractionApplicationCommand:
-			// Handle slash commands
-			if h, ok := commandHandlers[i.ApplicationCommandData().Name]; ok {
-				h(s, i)
-			}
-		case discordgo.InteractionMessageComponent:
-			// Handle button interactions
-			handleButton(s, i)
-		}
-	})
-
-	// Register the messageCreate func as a callback for MessageCreate events.
-	client.AddHandler(guildMemberAdd)
-	client.AddHandler(memberDM)
-
-	// Set required intents
-	client.Identify.Intents = discordgo.IntentsGuildMessages |
-		discordgo.IntentGuildMembers |
-		discordgo.IntentDirectMessages |
-		discordgo.IntentGuilds
-
-	// Retrieve the guild ID from the .env file
-	guildId := os.Getenv("GUILD_ID")
-	if guildId == "" {
-		log.Println("Deploying commands globally as no guild ID is provided.")
-	} else {
-		log.Printf("Deploying commands to guild ID: %s\n", guildId)
-	}
-
-	// Open a websocket connection to Discord and begin listening.
-	err = client.Open()
-	if err != nil {
-		log.Fatalf("Error opening connection: %v", err)
-		return
-	}
-
-	// Register slash commands
-	registeredCommands, err := client.ApplicationCommandBulkOverwrite(client.State.User.ID, guildId, commands)
-	if err != nil {
-		log.Fatalf("Error registering slash commands: %v", err)
-	}
-	log.Printf("Registered %d commands", len(registeredCommands))
-
-	log.Println("Bot is now running. Press CTRL-C to exit.")
-	sc := make(chan os.Signal, 1)
-	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
-	<-sc
-
-	log.Println("Shutting down...")
-	client.Close()
-}
-
-func memberDM(s *discordgo.Session, m *discordgo.MessageCreate) {
-	// Ignore all messages created by the bot itself
-	if m.Author.ID == s.State.User.ID {
-		return
-	}
-
-	// Check if the message is a DM
-	channel, err := s.Channel(m.ChannelID)
-	if err != nil {
-		fmt.Println("Error getting channel:", err)
-		return
-	}
-
-	if channel.Type == discordgo.ChannelTypeDM {
-		// Process email verification
-		processEmailVerification(s, m)
-		return
-	}
-}
-
-func processEmailVerification(s *discordgo.Session, m *discordgo.MessageCreate) {
-	configMutex.RLock()
-	serverConfig, exists := config.Servers[m.GuildID]
-	configMutex.RUnlock()
-
-	if !exists {
-		log.Printf("No config found for guild %s", m.GuildID)
-		return
-	}
-
-	if serverConfig.RateLimitEnabled {
-		rateLimitLock.Lock()
-		lastTime, exists := rateLimitMap[m.Author.ID]
-		now := time.Now()
-		if exists && now.Sub(lastTime) < serverConfig.RateLimitDuration {
-			rateLimitLock.Unlock()
-			s.ChannelMessageSend(m.ChannelID, fmt.Sprintf("Please wait %d minutes before sending another verification request.", serverConfig.RateLimitDuration/time.Minute))
-			return
-		}
-		rateLimitMap[m.Author.ID] = now
-		rateLimitLock.Unlock()
-	}
-
-	// Validate email
-	if !emailRegex.MatchString(m.Content) {
-		s.ChannelMessageSend(m.ChannelID, "Invalid email. Please provide a valid UCLan email.")
-		return
-	}
-
-	guilds := s.State.Guilds
-	var guildID string
-	for _, guild := range guilds {
-		member, err := s.GuildMember(guild.ID, m.Author.ID)
-		if err == nil && member != nil {
-			guildID = guild.ID
-			break
-		}
-	}
-
-	if guildID == "" {
-		log.Println("User is not in any guild")
-		return
-	}
-
-	configMutex.RLock()
-	serverConfig = config.Servers[guildID]
-	configMutex.RUnlock()
-
-	// Approval button
-	approveButton := discordgo.Button{
-		Label:    "Approve",
-		Style:    discordgo.SuccessButton,
-		CustomID: "approve_" + m.Author.ID,
-	}
-
-	// Denial button
-	denyButton := discordgo.Button{
-		Label:    "Deny",
-		Style:    discordgo.DangerButton,
-		CustomID: "deny_" + m.Author.ID,
-	}
+		{
+			Name:                     "set_denial_retries",
+			Description:              "Set how many times a denied user may resubmit before being kicked",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "retries",
+					Description: "The number of retries to allow (0 kicks on first denial)",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "mod_leaderboard",
+			Description: "Show which moderators have handled the most verifications",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "days",
+					Description: "How many days back to count (defaults to 30)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "migrate_unverified_role",
+			Description:              "Replace the unverified role and move every member holding it to the new role",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "old_role",
+					Description: "The role members currently hold",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "new_role",
+					Description: "The role to use as the unverified role from now on",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_batch_review_window",
+			Description:              "Group verification requests arriving close together into one review message",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "seconds",
+					Description: "How long to collect requests before posting them (0 disables batching)",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_jwt_verification",
+			Description:              "Let members verify by pasting a token issued by the university",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "issuer",
+					Description: "The expected token issuer (iss claim)",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "audience",
+					Description: "This bot's client ID with the institution (aud claim)",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "jwks_url",
+					Description: "The HTTPS URL of the institution's signing keys (JWKS)",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "affiliation",
+					Description: "Required affiliation claim, e.g. student",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "disable_jwt_verification",
+			Description:              "Stop accepting university-issued tokens for verification",
+			DefaultMemberPermissions: &adminPermission,
+		},
+		{
+			Name:                     "set_mod_action_cooldown",
+			Description:              "Warn moderators who act on the same member again within this time",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "seconds",
+					Description: "The cooldown in seconds (0 disables it)",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "debug_events",
+			Description: "Dump the most recent events the bot processed (owner only)",
+		},
+		{
+			Name:                     "set_audit_result_mode",
+			Description:              "Choose whether verification outcomes are shown on the audit message",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Show the outcome publicly, or only note that the request was handled",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "public", Value: "public"},
+						{Name: "quiet", Value: "quiet"},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "set_rate_limit_queueing",
+			Description:              "Queue rate-limited verification requests instead of rejecting them",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Whether to queue requests sent during the cooldown",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_audit_reactions",
+			Description:              "Set emojis the bot reacts with on each new verification request",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "emojis",
+					Description: "Space-separated emojis, or leave empty to clear",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "set_embed_language",
+			Description:              "Set the language of verification request embeds in the audit channel",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "language",
+					Description: "Language code, e.g. en or cy",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_azure_verification",
+			Description:              "Let members verify by signing in with their university Microsoft account",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "tenant_id",
+					Description: "The university's Azure AD tenant ID",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "disable_azure_verification",
+			Description:              "Stop offering Microsoft sign-in for verification",
+			DefaultMemberPermissions: &adminPermission,
+		},
+		{
+			Name:        "verify_microsoft",
+			Description: "Verify by signing in with your university Microsoft account",
+		},
+		{
+			Name:                     "event_qr",
+			Description:              "Create a QR code members can scan at an event to verify",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "Name of the event",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "hours",
+					Description: "How many hours the QR code works for (defaults to 12)",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "set_review_summary",
+			Description:              "DM moderators a daily summary of pending verification requests",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "time",
+					Description: "When to send the summary, as HH:MM in 24-hour time",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "timezone",
+					Description: "The timezone for the time, e.g. Europe/London (defaults to Europe/London)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "role",
+					Description: "DM every member with this role",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "users",
+					Description: "Mentions or IDs of additional users to DM",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "disable_review_summary",
+			Description:              "Stop sending the daily summary of pending verification requests",
+			DefaultMemberPermissions: &adminPermission,
+		},
+		{
+			Name:                     "update_config",
+			Description:              "Update several settings at once from a JSON object",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "json",
+					Description: `The settings to change, e.g. {"rate_limit_enabled": true}`,
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionAttachment,
+					Name:        "file",
+					Description: "A JSON file with the settings to change",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "training_request",
+			Description:              "Post a practice verification request for training new moderators",
+			DefaultMemberPermissions: &moderatePermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The user to show as the applicant (defaults to you)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "email",
+					Description: "The email to show on the request",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "set_membership_api",
+			Description:              "Check applicants against the society's membership API",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "url",
+					Description: "The membership API endpoint, called with ?email=<email>",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "Flag the result for moderators, or approve members automatically",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "flag", Value: "flag"},
+						{Name: "auto", Value: "auto"},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "token",
+					Description: "Bearer token sent to the API",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "disable_membership_api",
+			Description:              "Stop checking applicants against the membership API",
+			DefaultMemberPermissions: &adminPermission,
+		},
+		{
+			Name:        "version",
+			Description: "Show the bot's version and build information",
+		},
+		{
+			Name:                     "set_sms_verification",
+			Description:              "Let members verify with a code texted to their phone",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Whether phone verification is allowed",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "allow_guild",
+			Description: "Add a server to the list the bot may join (owner only)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "guild_id",
+					Description: "The ID of the server to allow",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "disallow_guild",
+			Description: "Remove a server from the allowlist and leave it (owner only)",
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "guild_id",
+					Description: "The ID of the server to remove",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "add_verification_field",
+			Description:              "Ask members for an extra detail, such as their course, after their email",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "The name of the field, e.g. Course",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "pattern",
+					Description: "A regular expression the value must match (defaults to anything)",
+					Required:    false,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "example",
+					Description: "An example value shown to members",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "remove_verification_field",
+			Description:              "Stop asking members for an extra detail",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "name",
+					Description: "The name of the field to remove",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_milestone_announcements",
+			Description:              "Announce when the number of verified members reaches a milestone",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "every",
+					Description: "Announce every this many verified members (0 disables announcements)",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionChannel,
+					Name:        "channel",
+					Description: "The channel to post announcements in",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "set_self_approval",
+			Description:              "Choose whether moderators may handle their own verification requests",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "allowed",
+					Description: "Whether moderators may approve or deny themselves",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_partner_verification",
+			Description:              "Let members verified in a partner server skip verification here",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "partner_guild_id",
+					Description: "The ID of the partner server (the bot must be in it)",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "partner_role_id",
+					Description: "The ID of the verified role in the partner server",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "disable_partner_verification",
+			Description:              "Stop offering verification through a partner server",
+			DefaultMemberPermissions: &adminPermission,
+		},
+		{
+			Name:        "bulk_policy",
+			Description: "Show how bulk operations like role migrations are paced",
+		},
+		{
+			Name:                     "set_bulk_policy",
+			Description:              "Set the batch size and delay used by bulk operations",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "batch_size",
+					Description: "How many API calls to make before pausing",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "interval_ms",
+					Description: "How long to pause between batches, in milliseconds",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_welcome_channels",
+			Description:              "Greet approved members in voice or text (leave both empty to disable)",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "voice_channel",
+					Description:  "Voice channel to move approved members into if they're connected",
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildVoice},
+				},
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "text_channel",
+					Description:  "Text channel to greet approved members in",
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+		{
+			Name:                     "set_invalid_reply_limit",
+			Description:              "Limit how many invalid email replies a user gets per minute",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "limit",
+					Description: "Replies allowed per minute (0 removes the limit)",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_email_domain",
+			Description:              "Allow verification emails from another domain",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "domain",
+					Description: "The email domain to accept, e.g. uclan.ac.uk",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_verification_mode",
+			Description:              "Choose between moderator approval and emailed codes",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "mode",
+					Description: "How email submissions are verified",
+					Required:    true,
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "Moderator approval", Value: "manual"},
+						{Name: "Emailed code", Value: "code"},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "verify_user",
+			Description:              "Manually verify a member, e.g. if their DMs are closed",
+			DefaultMemberPermissions: &moderatePermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The member to verify",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_welcome_message",
+			Description:              "Set the DM sent to new members (leave empty to reset)",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "message",
+					Description: "The message; {email_domain} and {server_name} are filled in when sent",
+				},
+			},
+		},
+		{
+			Name:                     "set_denial_message",
+			Description:              "Set the DM sent to denied members before they're kicked",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "message",
+					Description: "The message; {invite_link} is filled in when sent (leave empty for the default)",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "invite_link",
+					Description: "Invite link for rejoining the server, e.g. https://discord.gg/abc123",
+				},
+			},
+		},
+		{
+			Name:                     "set_auto_kick_message",
+			Description:              "Set the DM sent to members kicked for not verifying in time",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "message",
+					Description: "The message; {invite_link} is filled in when sent (leave empty for the default)",
+				},
+			},
+		},
+		{
+			Name:        "config",
+			Description: "Show this server's current configuration",
+		},
+		{
+			Name:                     "set_verification_timeout",
+			Description:              "Kick members who haven't verified within a time limit",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "hours",
+					Description: "Hours new members have to verify (0 disables the timeout)",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:        "reload_config",
+			Description: "Reload every server's config from storage (owner only)",
+		},
+		{
+			Name:                     "set_verification_channel",
+			Description:              "Post a Verify button that lets members verify without DMs",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The channel to post the Verify button in",
+					Required:     true,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+		{
+			Name:                     "warn",
+			Description:              "Give a member a warning",
+			DefaultMemberPermissions: &moderatePermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The member to warn",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "reason",
+					Description: "Why they're being warned",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "warnings",
+			Description:              "List a member's warnings",
+			DefaultMemberPermissions: &moderatePermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The member to look up",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_warn_threshold",
+			Description:              "Time out members automatically once they reach a number of warnings",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "warnings",
+					Description: "Number of warnings that triggers a timeout (0 disables it)",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "minutes",
+					Description: "How long the timeout lasts, up to 28 days",
+					Required:    true,
+					MaxValue:    40320,
+				},
+			},
+		},
+		{
+			Name:                     "purge",
+			Description:              "Delete recent messages in this channel",
+			DefaultMemberPermissions: &manageMessagesPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "count",
+					Description: "How many messages to delete (1-100)",
+					Required:    true,
+					MinValue:    &purgeMinCount,
+					MaxValue:    100,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "Only delete this user's messages, from the last 100",
+					Required:    false,
+				},
+			},
+		},
+		{
+			Name:                     "set_confirmation_message",
+			Description:              "Set the message members get once their verification request is sent to moderators",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "message",
+					Description: "The message (leave empty for the default)",
+				},
+			},
+		},
+		{
+			Name:                     "set_email_pattern",
+			Description:              "Match emails against a regular expression instead of the domain list",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "pattern",
+					Description: "Go regular expression, matched against the lowercased email (leave empty to use domains)",
+				},
+			},
+		},
+		{
+			Name:                     "set_audit_threads",
+			Description:              "Open a discussion thread on each verification request",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Whether to create threads",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "archive_after",
+					Description: "How long an idle thread stays open (defaults to 1 day)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "1 hour", Value: 60},
+						{Name: "1 day", Value: 1440},
+						{Name: "3 days", Value: 4320},
+						{Name: "1 week", Value: 10080},
+					},
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "archive_on_decision",
+					Description: "Archive a request's thread once it's approved or denied (defaults to false)",
+				},
+			},
+		},
+		{
+			Name:        "stats",
+			Description: "Show this server's verification stats",
+		},
+		{
+			Name:                     "allow_email",
+			Description:              "Let an email address verify even if it isn't on an allowed domain",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "email",
+					Description: "The email address to allow",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "disallow_email",
+			Description:              "Remove an email address from the allowlist",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "email",
+					Description: "The email address to remove",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "block",
+			Description:              "Automatically deny verification for an email address or user ID",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "entry",
+					Description: "The email address or user ID to block",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "unblock",
+			Description:              "Remove an email address or user ID from the blocklist",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "entry",
+					Description: "The email address or user ID to unblock",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_blocklist_kick",
+			Description:              "Choose whether blocklisted users are kicked when they try to verify",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Kick blocklisted users",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "apply_unverified_role",
+			Description:              "Give the unverified role to existing members and send them the verification prompt",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "verified_role",
+					Description: "Treat members without this role as unverified (by default, members with no roles)",
+				},
+			},
+		},
+		{
+			Name:                     "set_verified_role",
+			Description:              "Set a role given to members when they're verified",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "role",
+					Description: "The verified role (leave empty to stop granting one)",
+				},
+			},
+		},
+		{
+			Name:                     "resend_welcome",
+			Description:              "Send the verification prompt again to a member who missed it",
+			DefaultMemberPermissions: &moderatePermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionUser,
+					Name:        "user",
+					Description: "The member to send it to",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_appeals",
+			Description:              "Let denied members request a review before they're kicked",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "enabled",
+					Description: "Whether to offer appeals",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_cooldown_message",
+			Description:              "Set the message rate limited members get; {remaining} is replaced with the time left",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "message",
+					Description: "The message (leave empty for the default)",
+				},
+			},
+		},
+		{
+			Name:                     "setup_verification_message",
+			Description:              "Post a Verify message that's kept at hand and re-posted if deleted",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The channel to post the Verify message in",
+					Required:     true,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+		{
+			Name:                     "remove_verification_message",
+			Description:              "Remove a Verify message posted with /setup_verification_message",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:         discordgo.ApplicationCommandOptionChannel,
+					Name:         "channel",
+					Description:  "The channel the Verify message is in",
+					Required:     true,
+					ChannelTypes: []discordgo.ChannelType{discordgo.ChannelTypeGuildText},
+				},
+			},
+		},
+		{
+			Name:                     "set_max_attempts",
+			Description:              "Kick members after this many invalid verification attempts",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "limit",
+					Description: "Invalid attempts allowed (0 never kicks)",
+					Required:    true,
+				},
+			},
+		},
+		{
+			Name:                     "set_attempt_cooldown",
+			Description:              "Put members who use up their invalid attempts in a cooldown instead of kicking them",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "minutes",
+					Description: "How long they must wait before trying again (0 kicks them instead)",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "message",
+					Description: "Message sent when the cooldown starts, {remaining} is replaced with the time left",
+				},
+			},
+		},
+		{
+			Name:                     "set_account_age",
+			Description:              "Flag or kick members whose Discord accounts are very new",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "flag_days",
+					Description: "Flag verification requests from accounts younger than this (0 turns it off)",
+					Required:    true,
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionInteger,
+					Name:        "deny_days",
+					Description: "Kick accounts younger than this when they join (0 or empty turns it off)",
+				},
+			},
+		},
+		{
+			Name:                     "pending_verifications",
+			Description:              "List verification requests waiting for review",
+			DefaultMemberPermissions: &moderatePermission,
+		},
+		{
+			Name:                     "set_reviewer_role",
+			Description:              "Set a role to mention on each new verification request",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionRole,
+					Name:        "role",
+					Description: "The role to mention (leave empty to stop mentioning one)",
+				},
+				{
+					Type:        discordgo.ApplicationCommandOptionBoolean,
+					Name:        "ping",
+					Description: "Whether the mention notifies the role (defaults to true)",
+				},
+			},
+		},
+		{
+			Name:                     "reset_config",
+			Description:              "Delete all of this server's settings",
+			DefaultMemberPermissions: &adminPermission,
+		},
+		{
+			Name:                     "set_student_id_pattern",
+			Description:              "Ask members for a student ID matching a regular expression after their email",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "pattern",
+					Description: "The pattern student IDs must match (leave empty to stop asking)",
+				},
+			},
+		},
+		{
+			Name:                     "check_email_domains",
+			Description:              "Find verified members whose email is no longer allowed",
+			DefaultMemberPermissions: &adminPermission,
+			Options: []*discordgo.ApplicationCommandOption{
+				{
+					Type:        discordgo.ApplicationCommandOptionString,
+					Name:        "action",
+					Description: "List the members, or put them back to unverified (defaults to report)",
+					Choices: []*discordgo.ApplicationCommandOptionChoice{
+						{Name: "report", Value: "report"},
+						{Name: "unverify", Value: "unverify"},
+					},
+				},
+			},
+		},
+		{
+			Name:                     "pause_auto_kick",
+			Description:              "Stop kicking unverified members for now, keeping the timeout",
+			DefaultMemberPermissions: &adminPermission,
+		},
+		{
+			Name:                     "resume_auto_kick",
+			Description:              "Start kicking unverified members again after /pause_auto_kick",
+			DefaultMemberPermissions: &adminPermission,
+		},
+	}
+)
+
+func main() {
+	// Recover from panics
+	defer func() {
+		if r := recover(); r != nil {
+			log.Fatalf("Bot crashed: %v", r)
+		}
+	}()
+
+	// Load .env file. Containers usually pass settings in the environment
+	// instead, so a missing file is only fatal if the token isn't set either.
+	err := godotenv.Load()
+	if err != nil && os.Getenv("DISCORD_TOKEN") == "" {
+		log.Fatal("Error loading .env file: ", err)
+	}
+	setupLogging()
+	if err != nil {
+		slog.Warn("Could not load .env file, using the existing environment", "error", err)
+	} else {
+		slog.Info("Successfully loaded .env file")
+	}
+	slog.Info(versionString(version, buildCommit(), runtime.Version()))
+
+	loadDryRun()
+	loadSMSProvider()
+	loadMailProvider()
+
+	// Open the config database, importing any JSON config from older versions
+	configStore, err = openConfigStore("./data/bot.db")
+	if err != nil {
+		log.Fatalf("Error opening config store: %v", err)
+	}
+	defer configStore.Close()
+
+	// Load config
+	err = loadConfig()
+	if err != nil {
+		log.Fatalf("Error loading config: %v", err)
+		// If the config doesn't exist, it's not a fatal error
+	}
+
+	// Load translations for audit embeds, optionally picking up edits
+	// without a restart
+	err = reloadLocales(localesDir)
+	if err != nil {
+		slog.Error("Error loading locales, using the built-in languages", "dir", localesDir, "error", err)
+	}
+	if os.Getenv("WATCH_LOCALES") == "true" {
+		watcher, err := watchLocales(localesDir)
+		if err != nil {
+			slog.Error("Error watching locales", "dir", localesDir, "error", err)
+		} else {
+			defer watcher.Close()
+		}
+	}
+
+	// Load rate limit cooldowns, which are pruned against the config
+	err = loadRateLimits()
+	if err != nil {
+		log.Fatalf("Error loading rate limits: %v", err)
+	}
+
+	// Load requests still waiting for review
+	err = loadPendingVerifications()
+	if err != nil {
+		log.Fatalf("Error loading pending verifications: %v", err)
+	}
+
+	// Load guild allowlist
+	err = loadAllowedGuilds()
+	if err != nil {
+		log.Fatalf("Error loading guild allowlist: %v", err)
+	}
+
+	// Load verification log
+	err = loadVerificationLog()
+	if err != nil {
+		log.Fatalf("Error loading verification log: %v", err)
+	}
+
+	// Get the token from the .env file or the environment
+	token := os.Getenv("DISCORD_TOKEN")
+	if token == "" {
+		log.Fatal("No token provided. Please set DISCORD_TOKEN in .env or the environment")
+	}
+	slog.Info("Successfully retrieved bot token")
+
+	// Create a new Discord session using the provided bot token.
+	client, err := discordgo.New("Bot " + token)
+	if err != nil {
+		log.Fatal("Error creating Discord session:", err)
+	}
+	slog.Info("Successfully created Discord session")
+
+	// Register handlers for different interaction types
+	client.AddHandler(func(s *discordgo.Session, i *discordgo.InteractionCreate) {
+		switch i.Type {
+		case discordgo.InteractionApplicationCommand:
+			// Handle slash commands
+			if h, ok := commandHandlers[i.ApplicationCommandData().Name]; ok {
+				h(s, i)
+				// Settings may have switched features on or off
+				syncGuildCommands(s, i.GuildID)
+			}
+		case discordgo.InteractionMessageComponent:
+			// The verify button has to answer with a modal, so it can't go
+			// through handleButton's deferred response
+			if i.MessageComponentData().CustomID == "verifymodal" {
+				openVerificationModal(s, i)
+				return
+			}
+			// Handle button interactions
+			handleButton(s, i)
+			syncGuildCommands(s, i.GuildID)
+		case discordgo.InteractionModalSubmit:
+			if i.ModalSubmitData().CustomID == "verifymodal" {
+				handleVerificationModal(s, i)
+			}
+		}
+	})
+
+	// Keep the most recent events around for debugging
+	if size, err := strconv.Atoi(os.Getenv("EVENT_BUFFER_SIZE")); err == nil && size > 0 {
+		recentEvents = newEventRing(size)
+	}
+	client.AddHandler(func(s *discordgo.Session, e *discordgo.Event) { recordEvent(s, e) })
+
+	// discordgo picks handlers by their exact signature, so the handlers,
+	// which take the discordSession interface, are wrapped
+	client.AddHandler(func(s *discordgo.Session, g *discordgo.GuildCreate) { guildCreate(s, g) })
+	client.AddHandler(func(s *discordgo.Session, g *discordgo.GuildDelete) { guildDelete(s, g) })
+	client.AddHandler(func(s *discordgo.Session, m *discordgo.GuildMemberAdd) { guildMemberAdd(s, m) })
+	client.AddHandler(func(s *discordgo.Session, m *discordgo.MessageCreate) { memberDM(s, m) })
+	client.AddHandler(func(s *discordgo.Session, m *discordgo.MessageDelete) { verificationMessageDeleted(s, m) })
+
+	// Set required intents
+	client.Identify.Intents = discordgo.IntentsGuildMessages |
+		discordgo.IntentGuildMembers |
+		discordgo.IntentDirectMessages |
+		discordgo.IntentGuilds |
+		discordgo.IntentGuildVoiceStates
+
+	// Retrieve the guild ID from the .env file
+	guildId := os.Getenv("GUILD_ID")
+	if guildId == "" {
+		slog.Info("Deploying commands to each guild as no guild ID is provided.")
+	} else {
+		slog.Info("Deploying commands to guild", "guild_id", guildId)
+	}
+
+	// Report unhealthy until the connection is up, so orchestrators can tell
+	// a stuck start from a running bot
+	var healthServer *http.Server
+	if port := os.Getenv("HEALTH_PORT"); port != "" {
+		healthServer = startHealthServer(client, ":"+port)
+		slog.Info("Health server listening", "port", port)
+	}
+
+	// Open a websocket connection to Discord and begin listening.
+	err = client.Open()
+	if err != nil {
+		log.Fatalf("Error opening connection: %v", err)
+		return
+	}
+
+	// Refuse to run with channels or roles that no longer exist
+	err = validateConfig(client)
+	if err != nil {
+		client.Close()
+		log.Fatalf("Config refers to missing channels or roles:\n%v", err)
+	}
+
+	// Read before registering commands, which hides /event_qr without it
+	loadEventQRSettings()
+
+	// Register slash commands
+	err = registerCommands(client, guildId)
+	if err != nil {
+		log.Fatalf("Error registering slash commands: %v", err)
+	}
+
+	// Start the callback server for Microsoft verification and event QR codes
+	loadAzureSettings()
+	var oauthServer *http.Server
+	if azure.ClientID != "" || len(eventQR.Secret) != 0 {
+		addr := os.Getenv("OAUTH_LISTEN_ADDR")
+		if addr == "" {
+			addr = ":8080"
+		}
+		oauthServer = startCallbackServer(client, addr)
+		slog.Info("Callback server listening", "addr", addr)
+	}
+
+	go runReviewSummaries(client)
+	go runRateLimitSaves()
+	go runRateLimitSweeps()
+	go runVerificationTimeouts(client)
+	go runVerificationLogCompaction()
+
+	// Expose verification counters for Prometheus
+	var metricsServer *http.Server
+	if port := os.Getenv("METRICS_PORT"); port != "" {
+		metricsServer = startMetricsServer(":" + port)
+		slog.Info("Metrics server listening", "port", port)
+	}
+
+	slog.Info("Bot is now running. Press CTRL-C to exit.")
+	sc := make(chan os.Signal, 1)
+	signal.Notify(sc, syscall.SIGINT, syscall.SIGTERM, os.Interrupt)
+	<-sc
+
+	slog.Info("Shutting down...")
+	if oauthServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		oauthServer.Shutdown(ctx)
+		cancel()
+	}
+	if metricsServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		metricsServer.Shutdown(ctx)
+		cancel()
+	}
+	if healthServer != nil {
+		ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+		healthServer.Shutdown(ctx)
+		cancel()
+	}
+	err = saveRateLimits()
+	if err != nil {
+		slog.Error("Error saving rate limits", "error", err)
+	}
+	err = flushConfigSaves()
+	if err != nil {
+		slog.Error("Error saving config", "error", err)
+	}
+
+	// Optionally remove our commands so stale ones don't linger
+	if os.Getenv("CLEANUP_COMMANDS_ON_EXIT") == "true" {
+		unregisterCommands(client)
+	}
+	client.Close()
+}
+
+func memberDM(s discordSession, m *discordgo.MessageCreate) {
+	// Ignore all messages created by the bot itself
+	if m.Author.ID == botUserID(s) {
+		return
+	}
+
+	if isDM(s, m) {
+		// A six-digit reply is a verification code
+		if codeRegex.MatchString(m.Content) && hasPendingCode(m.Author.ID) {
+			handleCodeSubmission(s, m)
+			return
+		}
+
+		// A code from an event QR code
+		if eventCodeRegex.MatchString(strings.ToUpper(strings.TrimSpace(m.Content))) {
+			handleEventCode(s, m)
+			return
+		}
+
+		// A member part way through verifying is sending their student ID
+		if hasPendingStudentID(m.Author.ID) {
+			handleStudentIDSubmission(s, m)
+			return
+		}
+
+		// Process email verification
+		processEmailVerification(s, m)
+		return
+	}
+}
+
+// isDM reports whether a message was sent in a DM. Guild messages carry their
+// guild ID, so only messages without one need their channel looked up, and
+// the state cache is tried before asking the API.
+func isDM(s discordSession, m *discordgo.MessageCreate) bool {
+	if m.GuildID != "" {
+		return false
+	}
+
+	channel, err := stateOf(s).Channel(m.ChannelID)
+	if err != nil {
+		channel, err = s.Channel(m.ChannelID)
+		if err != nil {
+			slog.Error("Error getting channel", "user_id", m.Author.ID, "error", err)
+			return false
+		}
+	}
+	return channel.Type == discordgo.ChannelTypeDM
+}
+
+func processEmailVerification(s discordSession, m *discordgo.MessageCreate) {
+	// The first copy has already been handled
+	if isDuplicateSubmission(m.Author.ID, m.Content, time.Now()) {
+		slog.Debug("Ignoring duplicate verification submission", "user_id", m.Author.ID)
+		return
+	}
+	continueVerification(s, m)
+}
+
+// continueVerification handles a submission that has already passed the
+// duplicate check. Submissions parked while the user picks a server, or
+// queued until their cooldown ends, are replayed through here so they aren't
+// mistaken for duplicates of themselves.
+func continueVerification(s discordSession, m *discordgo.MessageCreate) {
+	m.Content = cleanSubmission(m.Content)
+
+	// DMs carry no guild, so find the server the user is verifying for.
+	// Modal submissions already know it.
+	guildID := m.GuildID
+	if guildID == "" {
+		guildID = chooseVerificationGuild(s, m)
+	}
+	if guildID == "" {
+		return
+	}
+
+	serverConfig := getOrCreateServerConfig(guildID)
+
+	logger := slog.With("guild_id", guildID, "user_id", m.Author.ID)
+	incrementMetric("verifications_requested_total", guildID)
+
+	// Members who used up their attempts wait out the cooldown first
+	if remaining := attemptCooldownRemaining(guildID, m.Author.ID, time.Now()); remaining > 0 {
+		if allowInvalidReply(m.Author.ID, serverConfig.InvalidReplyLimit, time.Now()) {
+			replyToSubmission(s, m, attemptCooldownMessage(serverConfig.AttemptCooldownMessage, remaining))
+		}
+		return
+	}
+
+	if serverConfig.RateLimitEnabled {
+		rateLimitLock.Lock()
+		lastTime, exists := rateLimitMap[rateLimitKey(guildID, m.Author.ID)]
+		now := time.Now()
+		if exists && now.Sub(lastTime) < serverConfig.RateLimitDuration {
+			rateLimitLock.Unlock()
+			incrementMetric("verifications_rate_limited_total", guildID)
+
+			// Hold on to the submission until the cooldown ends
+			if serverConfig.QueueRateLimited {
+				// Pin the submission to this guild so it isn't asked about again
+				m.GuildID = guildID
+				if !queueSubmission(guildID, m.Author.ID, m) {
+					time.AfterFunc(serverConfig.RateLimitDuration-now.Sub(lastTime), func() {
+						if queued, ok := takeQueuedSubmission(guildID, m.Author.ID); ok {
+							continueVerification(s, queued)
+						}
+					})
+				}
+				replyToSubmission(s, m, "You're sending verification requests too quickly, so your latest one has been queued. It will be processed automatically once your cooldown ends.")
+				return
+			}
+
+			replyToSubmission(s, m, cooldownMessage(serverConfig.CooldownMessage, serverConfig.RateLimitDuration-now.Sub(lastTime)))
+			return
+		}
+		rateLimitMap[rateLimitKey(guildID, m.Author.ID)] = now
+		rateLimitLock.Unlock()
+	}
+
+	// Validate email, unless the user pasted an institution token or a
+	// phone number instead
+	email, details := splitSubmission(m.Content)
+	isJWT := looksLikeJWT(m.Content)
+	isPhone := phoneRegex.MatchString(m.Content)
+
+	// Rate limited submissions never get this far, so they don't count as
+	// invalid attempts
+	if !isJWT && len(email) > maxEmailLength {
+		if recordFailedAttempt(guildID, m.Author.ID, serverConfig.MaxAttempts) {
+			handleAttemptsUsedUp(s, m, guildID, serverConfig)
+			return
+		}
+		replyToSubmission(s, m, "That's too long to be an email address. Please send just your email on the first line.")
+		return
+	}
+
+	// Known bad actors never reach the moderators
+	if entry, blocked := blocklistMatch(m.Author.ID, email, serverConfig.Blocklist); blocked {
+		denyBlocklisted(s, m, guildID, serverConfig, email, entry)
+		return
+	}
+
+	allowlisted := emailAllowlisted(email, serverConfig.EmailAllowlist)
+	if allowlisted && !emailAllowed(email, serverConfig) {
+		logger.Info("Email allowlist bypassed the email format check", "email", email)
+	}
+	if !allowlisted && !isJWT && !isPhone && !emailAllowed(email, serverConfig) {
+		if recordFailedAttempt(guildID, m.Author.ID, serverConfig.MaxAttempts) {
+			handleAttemptsUsedUp(s, m, guildID, serverConfig)
+			return
+		}
+		if !allowInvalidReply(m.Author.ID, serverConfig.InvalidReplyLimit, time.Now()) {
+			logger.Info("Not replying to invalid email, reply limit reached")
+			return
+		}
+		replyToSubmission(s, m, invalidEmailMessage(serverConfig))
+		return
+	}
+	resetFailedAttempts(guildID, m.Author.ID)
+
+	if isJWT {
+		verifyWithJWT(s, m, guildID, serverConfig)
+		return
+	}
+
+	if isPhone {
+		startSMSVerification(s, m, guildID, serverConfig)
+		return
+	}
+
+	// Code mode skips the moderators and emails the user a code instead
+	if serverConfig.VerificationMode == "code" {
+		startEmailCodeVerification(s, m, guildID, email)
+		return
+	}
+
+	// Everything below needs moderators to review the request
+	if serverConfig.MemberAuditChannelID == "" {
+		logger.Warn("Verification request received but no member audit channel is set; an admin needs to run /set_member_audit_channel")
+		replyToSubmission(s, m, "Sorry, verification isn't set up on this server yet. Please try again later or contact a moderator.")
+		return
+	}
+
+	// Parse any extra details the server asks for
+	var fieldValues []fieldValue
+	if len(serverConfig.VerificationFields) > 0 {
+		var err error
+		fieldValues, err = parseVerificationFields(details, serverConfig.VerificationFields)
+		if err != nil {
+			replyToSubmission(s, m, fmt.Sprintf("Sorry, %s. %s", err, verificationPrompt(exampleEmail(serverConfig), serverConfig.VerificationFields)))
+			return
+		}
+	}
+
+	// Societies that check student numbers ask for one before the request
+	// goes to the moderators
+	if serverConfig.StudentIDRegex != "" {
+		askForStudentID(s, m, guildID, email, fieldValues)
+		return
+	}
+
+	submitForReview(s, m, guildID, serverConfig, email, fieldValues)
+}
+
+// submitForReview sends a validated request to the moderators, either in a
+// batch or as its own audit message.
+func submitForReview(s discordSession, m *discordgo.MessageCreate, guildID string, serverConfig ServerConfig, email string, fieldValues []fieldValue) {
+	logger := slog.With("guild_id", guildID, "user_id", m.Author.ID)
+	details := formatFieldValues(fieldValues)
+
+	var membership string
+	if serverConfig.MembershipAPIURL != "" {
+		var autoApprove bool
+		membership, autoApprove = lookupMembership(serverConfig, m.Author.ID, email)
+		if autoApprove {
+			autoApproveMember(s, m, guildID, serverConfig, email, details, membership)
+			return
+		}
+	}
+
+	// Collect requests into a single review message during busy periods
+	if serverConfig.BatchReviewWindow > 0 {
+		if membership != "" {
+			details += "\n" + membership
+		}
+		queueBatchApplicant(s, guildID, serverConfig, batchApplicant{
+			UserID:   m.Author.ID,
+			Username: m.Author.Username,
+			Email:    email,
+			Details:  details,
+		})
+		notifyPending(s, m, guildID, email)
+		return
+	}
+
+	// Approval button
+	approveButton := discordgo.Button{
+		Label:    "Approve",
+		Style:    discordgo.SuccessButton,
+		CustomID: encodeCustomID("approve", m.Author.ID),
+	}
+
+	// Denial button
+	denyButton := discordgo.Button{
+		Label:    "Deny",
+		Style:    discordgo.DangerButton,
+		CustomID: encodeCustomID("deny", m.Author.ID),
+	}
+
+	// Create action row
+	actionRow := discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{approveButton, denyButton},
+	}
+
+	// Send verification request to member audit channel
+	newAccount := isNewAccount(m.Author.ID, serverConfig.MinAccountAge, time.Now())
+	content := fmt.Sprintf("<@%s> has requested verification", m.Author.ID)
+	if newAccount {
+		content = "⚠️ **New account** - " + content
+	}
+	if membership != "" {
+		content += "\n" + membership
+	}
+	mention, allowedMentions := reviewerMention(serverConfig)
+	auditMessage, err := sendAuditMessage(s, serverConfig, &discordgo.MessageSend{
+		Content:         mention + content,
+		Embeds:          []*discordgo.MessageEmbed{verificationRequestEmbed(m.Author, email, details, memberJoinedAt(s, guildID, m.Author.ID), newAccount, serverConfig.EmbedLanguage)},
+		Components:      []discordgo.MessageComponent{actionRow},
+		AllowedMentions: allowedMentions,
+	})
+
+	if err != nil {
+		logger.Error("Error sending message to audit channel", "error", err)
+		replyToSubmission(s, m, "Sorry, verification is temporarily unavailable. Please try again later or contact a moderator.")
+		return
+	}
+	addAuditReactions(s, auditMessage, serverConfig.AuditReactions)
+	if serverConfig.UseAuditThreads {
+		startAuditThread(s, serverConfig, auditMessage, m.Author.Username)
+	}
+
+	notifyPending(s, m, guildID, email)
+}
+
+// addAuditReactions marks a new audit message with the guild's triage emojis
+func addAuditReactions(s discordSession, message *discordgo.Message, emojis []string) {
+	for _, emoji := range emojis {
+		err := s.MessageReactionAdd(message.ChannelID, message.ID, emoji)
+		if err != nil {
+			slog.Error("Error adding reaction to audit message", "emoji", emoji, "error", err)
+		}
+	}
+}
+
+// parseEmojis splits a list of emojis, converting custom emoji mentions like
+// <:name:id> into the name:id form the reactions API expects.
+func parseEmojis(input string) []string {
+	var emojis []string
+	for _, field := range strings.Fields(input) {
+		field = strings.TrimSuffix(strings.TrimPrefix(field, "<"), ">")
+		field = strings.TrimPrefix(field, "a:")
+		field = strings.TrimPrefix(field, ":")
+		if field != "" {
+			emojis = append(emojis, field)
+		}
+	}
+	return emojis
+}
+
+// notifyPending queues the request and lets the user know roughly how long
+// review will take
+func notifyPending(s discordSession, m *discordgo.MessageCreate, guildID, email string) {
+	pendingAhead := addPendingVerification(guildID, m.Author.ID, email)
+	sendAuditEvent(auditEvent{
+		GuildID:   guildID,
+		UserID:    m.Author.ID,
+		Action:    "request",
+		Timestamp: time.Now(),
+	})
+	verificationLogLock.Lock()
+	turnaround := averageTurnaround(verificationLog, guildID, turnaroundSampleSize)
+	verificationLogLock.Unlock()
+
+	configMutex.RLock()
+	confirmation := config.Servers[guildID].ConfirmationMessage
+	configMutex.RUnlock()
+
+	replyToSubmission(s, m, waitingMessage(confirmation, pendingAhead, turnaround))
+}
+
+func handleButton(s discordSession, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+
+	if err != nil {
+		slog.Error("Error acknowledging interaction", "error", err)
+		return
+	}
+
+	if i.Type != discordgo.InteractionMessageComponent {
+		slog.Warn("Received non-component interaction", "type", i.Type)
+		return
+	}
+
+	// Only one interaction per message at a time; the lock is released once
+	// the message has been edited
+	if !claimMessage(i.Message.ID) {
+		busyContent := "This request is already being processed by another moderator."
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &busyContent,
+		})
+		return
+	}
+	defer releaseMessage(i.Message.ID)
+
+	customID := i.MessageComponentData().CustomID
+	slog.Debug("Received button interaction", "guild_id", i.GuildID, "custom_id", customID)
+
+	// The user picked which server they're verifying for
+	if customID == "guildchoice" {
+		handleGuildChoice(s, i)
+		return
+	}
+
+	// Select menus on batched review messages carry the action in the
+	// customID and the selected users in the values
+	if strings.HasPrefix(customID, "batch") {
+		handleBatchSelect(s, i, strings.TrimPrefix(customID, "batch"))
+		return
+	}
+
+	action, fields, ok := decodeCustomID(customID)
+	if !ok {
+		slog.Warn("Invalid button customID format", "custom_id", customID)
+		return
+	}
+
+	userID := fields[0]
+	logger := slog.With("guild_id", i.GuildID, "user_id", userID)
+
+	// Partner and appeal buttons carry a guild rather than a user
+	if action == "partner" {
+		handlePartnerVerification(s, i, userID)
+		return
+	}
+	if action == "appeal" {
+		handleAppeal(s, i, userID)
+		return
+	}
+	if action == "resetconfig" {
+		handleResetConfig(s, i, userID)
+		return
+	}
+
+	// Training requests never touch the applicant
+	if strings.HasPrefix(action, "training") {
+		handleTrainingDecision(s, i, strings.TrimPrefix(action, "training"), userID)
+		return
+	}
+
+	responseContent, ok := processDecision(s, i, action, userID)
+	if !ok {
+		return
+	}
+	responseContent = auditResultContent(i.GuildID, userID, responseContent) + moderatorAttribution(i)
+
+	// Update the original message to remove buttons and show the result
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    i.ChannelID,
+		ID:         i.Message.ID,
+		Content:    &responseContent,
+		Components: &[]discordgo.MessageComponent{},
+	})
+	if err != nil {
+		logger.Error("Error editing original message", "error", err)
+	}
+	archiveAuditThread(s, i.GuildID, i.Message)
+
+	// Edit the deferred response
+	completionMessage := "Action completed successfully"
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &completionMessage,
+	})
+	if err != nil {
+		logger.Error("Error editing interaction response", "error", err)
+	}
+}
+
+// approveMember welcomes the user and lifts the unverified role. The user is
+// approved even if the DM fails; the DM error is returned so the caller can
+// let moderators know they weren't told.
+func approveMember(s discordSession, guildID, userID string) error {
+	logger := slog.With("guild_id", guildID, "user_id", userID)
+
+	// Send DM to the approved user
+	dmErr := sendDM(s, userID, approvalMessage)
+	if errors.Is(dmErr, errDMsClosed) {
+		logger.Warn("Could not DM approved user, DMs are closed")
+	} else if dmErr != nil {
+		logger.Error("Error sending DM", "error", dmErr)
+	}
+
+	// Remove unverified role
+	configMutex.RLock()
+	unverifiedRoleID := config.Servers[guildID].UnverifiedRoleID
+	configMutex.RUnlock()
+	if unverifiedRoleID != "" {
+		err := withRetry(func() error { return memberRoleRemove(s, guildID, userID, unverifiedRoleID) })
+		if err != nil {
+			logger.Error("Error removing unverified role", "error", err)
+		}
+	}
+	grantVerifiedRole(s, guildID, userID)
+
+	// Reset any retries used on earlier denials
+	resetDenialRetries(guildID, userID)
+
+	welcomeMember(s, guildID, userID)
+	return dmErr
+}
+
+// auditResultContent hides the outcome of a decision from the audit channel
+// for servers that prefer to keep it quiet.
+func auditResultContent(guildID, userID, responseContent string) string {
+	configMutex.RLock()
+	quiet := config.Servers[guildID].QuietAuditResults
+	configMutex.RUnlock()
+
+	if quiet {
+		return fmt.Sprintf("Verification request from <@%s> handled.", userID)
+	}
+	return responseContent
+}
+
+// moderatorAttribution names who handled a request and when, using a Discord
+// timestamp so it shows in each reader's timezone
+func moderatorAttribution(i *discordgo.InteractionCreate) string {
+	return fmt.Sprintf("\nHandled by <@%s> at <t:%d:f>", interactionUserID(i), time.Now().Unix())
+}
+
+// processDecision carries out an approve or deny decision for a single user
+// and returns the outcome to show on the audit message. If it fails, the
+// deferred interaction response has already been updated with the error.
+func processDecision(s discordSession, i *discordgo.InteractionCreate, action, userID string) (string, bool) {
+	logger := slog.With("guild_id", i.GuildID, "user_id", userID)
+
+	logger.Debug("Processing action", "action", action)
+
+	configMutex.RLock()
+	cooldown := config.Servers[i.GuildID].ModActionCooldown
+	preventSelfApproval := config.Servers[i.GuildID].PreventSelfApproval
+	configMutex.RUnlock()
+
+	moderatorID := interactionUserID(i)
+	if preventSelfApproval && moderatorID == userID {
+		selfContent := "You can't approve or deny your own verification request. Please ask another moderator."
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &selfContent,
+		})
+		return "", false
+	}
+
+	// Flag acting on the same member twice in quick succession, in case
+	// it was a mistake
+	cooldownWarning := modActionCooldownWarning(i.GuildID, userID, cooldown, time.Now())
+
+	var responseContent string
+	switch action {
+	case "approve":
+		// Handle approval
+		responseContent = fmt.Sprintf("<@%s> has been approved! Welcome to the server! 🎉", userID)
+
+		if err := approveMember(s, i.GuildID, userID); err != nil {
+			responseContent += dmFailedNote(userID, err)
+		}
+	case "deny":
+		configMutex.RLock()
+		maxRetries := config.Servers[i.GuildID].DenialRetries
+		configMutex.RUnlock()
+
+		// Give the user another chance instead of kicking them
+		if remaining, ok := useDenialRetry(i.GuildID, userID, maxRetries); ok {
+			guildName := "the server"
+			if guild, err := stateOf(s).Guild(i.GuildID); err == nil {
+				guildName = guild.Name
+			}
+			dmErr := sendDM(s, userID, denialRetryDM(guildName, remaining))
+			if dmErr != nil {
+				logger.Error("Error sending DM", "error", dmErr)
+			}
+
+			responseContent = fmt.Sprintf("<@%s> has been denied and may resubmit (%d retries remaining).", userID, remaining)
+			if errors.Is(dmErr, errDMsClosed) {
+				responseContent += dmClosedNote(userID)
+			}
+			break
+		}
+
+		// Send DM to the denied user before removing them
+		configMutex.RLock()
+		denial := denialDM(config.Servers[i.GuildID])
+		appealsEnabled := config.Servers[i.GuildID].AppealsEnabled
+		configMutex.RUnlock()
+
+		// With appeals on, the kick waits until the member has had a
+		// chance to ask for a review
+		var dmErr error
+		if appealsEnabled && appealAvailable(i.GuildID, userID) {
+			dmErr = offerAppeal(s, i.GuildID, userID, denial)
+			if dmErr == nil {
+				responseContent = fmt.Sprintf("<@%s> has been denied. They have %d minutes to request a review before being removed.", userID, int(appealGracePeriod/time.Minute))
+				break
+			}
+		} else {
+			dmErr = sendDM(s, userID, denial)
+		}
+		if dmErr != nil {
+			logger.Error("Error sending DM", "error", dmErr)
+		}
+
+		// Kick the member. If they've already left there's nothing to do.
+		err := withRetry(func() error { return memberKick(s, i.GuildID, userID) })
+		alreadyLeft := hasErrorCode(err, discordgo.ErrCodeUnknownMember)
+		if err != nil && !alreadyLeft {
+			logger.Error("Error kicking user", "error", err)
+			errorContent := "Error processing denial"
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+				Content: &errorContent,
+			})
+			return "", false
+		}
+
+		resetDenialRetries(i.GuildID, userID)
+		responseContent = fmt.Sprintf("<@%s> has been denied and removed from the server.", userID)
+		if alreadyLeft {
+			logger.Info("Denied user had already left the server")
+			responseContent = fmt.Sprintf("<@%s> has been denied. They had already left the server.", userID)
+		} else if errors.Is(dmErr, errDMsClosed) {
+			responseContent += dmClosedNote(userID)
+		}
+	default:
+		logger.Warn("Unknown action", "action", action)
+		unknownContent := "Unknown action"
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &unknownContent,
+		})
+		return "", false
+	}
+
+	recordModAction(i.GuildID, userID, time.Now())
+	responseContent += cooldownWarning
+
+	// Record the decision, the moderator who made it and the email the
+	// member verified with
+	pending, _ := removePendingVerification(i.GuildID, userID)
+	recordDecision(s, VerificationLogEntry{
+		GuildID:     i.GuildID,
+		UserID:      userID,
+		Action:      action,
+		ModeratorID: moderatorID,
+		Email:       pending.Email,
+		Time:        time.Now(),
+		SubmittedAt: pending.SubmittedAt,
+	})
+	if action == "approve" && pending.Email != "" {
+		responseContent += fmt.Sprintf("\nVerified email: %s", pending.Email)
+	}
+
+	return responseContent, true
+}
+
+func setMemberAuditChannel(s discordSession, i *discordgo.InteractionCreate) {
+	var channelID, fallbackID string
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "channel":
+			channelID = option.ChannelValue(sessionOf(s)).ID
+		case "fallback":
+			fallbackID = option.ChannelValue(sessionOf(s)).ID
+		}
+	}
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.MemberAuditChannelID = channelID
+	serverConfig.FallbackAuditChannelID = fallbackID
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := fmt.Sprintf("Member audit channel set successfully! :white_check_mark: <#%s>", channelID)
+	if fallbackID != "" {
+		content += fmt.Sprintf(" (fallback <#%s>)", fallbackID)
+	}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func setUnverifiedRole(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	roleID := options[0].RoleValue(sessionOf(s), i.GuildID).ID
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.UnverifiedRoleID = roleID
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Verified role set successfully! :white_check_mark: <@&%s>", roleID),
+		},
+	})
+}
+
+func enableRateLimit(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.RateLimitEnabled = true
+	if serverConfig.RateLimitDuration == 0 {
+		serverConfig.RateLimitDuration = 5 * time.Minute // Default to 5 minutes
+	}
+
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Rate limiting enabled successfully! :white_check_mark:",
+		},
+	})
+}
+
+func disableRateLimit(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.RateLimitEnabled = false
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Rate limiting disabled successfully! :white_check_mark:",
+		},
+	})
+}
+
+func setRateLimit(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	minutes := options[0].IntValue()
+	guildID := i.GuildID
+
+	if minutes < 1 || minutes > maxRateLimitMinutes {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("The rate limit must be between 1 and %d minutes", maxRateLimitMinutes),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.RateLimitDuration = time.Duration(minutes) * time.Minute
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Rate limit set to %d minutes successfully! :white_check_mark:", minutes),
+		},
+	})
+}
+
+func checkRateLimit(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
+
+	serverConfig := getOrCreateServerConfig(guildID)
+
+	var content string
+	if serverConfig.RateLimitEnabled {
+		content = fmt.Sprintf("Rate limit is enabled with a duration of %d minutes", int(serverConfig.RateLimitDuration/time.Minute))
+	} else {
+		content = "Rate limit is disabled"
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func setDenialRetries(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	retries := options[0].IntValue()
+	guildID := i.GuildID
+
+	if retries < 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Retries cannot be negative",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.DenialRetries = int(retries)
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Denied users may now resubmit %d time(s) before being kicked! :white_check_mark:", retries),
+		},
+	})
+}
+
+func modLeaderboard(s discordSession, i *discordgo.InteractionCreate) {
+	days := int64(30)
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Name == "days" {
+			days = option.IntValue()
+		}
+	}
+
+	if days < 1 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Days must be at least 1",
+			},
+		})
+		return
+	}
+
+	since := time.Now().AddDate(0, 0, -int(days))
+	verificationLogLock.Lock()
+	tallies := tallyModerators(verificationLog, i.GuildID, since)
+	verificationLogLock.Unlock()
+
+	var content string
+	if len(tallies) == 0 {
+		content = fmt.Sprintf("No verifications have been handled in the last %d days", days)
+	} else {
+		var sb strings.Builder
+		sb.WriteString(fmt.Sprintf("**Moderator leaderboard (last %d days)**\n", days))
+		for rank, tally := range tallies {
+			sb.WriteString(fmt.Sprintf("%d. <@%s> - %d approved, %d denied\n", rank+1, tally.ModeratorID, tally.Approved, tally.Denied))
+		}
+		content = sb.String()
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
+
+func migrateUnverifiedRole(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	oldRoleID := options[0].RoleValue(sessionOf(s), i.GuildID).ID
+	newRoleID := options[1].RoleValue(sessionOf(s), i.GuildID).ID
+	guildID := i.GuildID
+
+	if oldRoleID == newRoleID {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The old and new roles must be different",
+			},
+		})
+		return
+	}
+
+	// Migrating a large server takes a while, so defer the response
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Error("Error acknowledging interaction", "error", err)
+		return
+	}
+
+	throttle := newBulkThrottle(guildBulkPolicy(guildID))
+	migrated, failed := 0, 0
+	after := ""
+	for {
+		members, err := s.GuildMembers(guildID, after, 1000)
+		if err != nil {
+			slog.Error("Error fetching members", "guild_id", guildID, "error", err)
+			errorContent := "Error fetching server members: " + err.Error()
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+				Content: &errorContent,
+			})
+			return
+		}
+
+		for _, member := range members {
+			swapped, err := swapMemberRole(s, guildID, member, oldRoleID, newRoleID)
+			if err != nil {
+				slog.Error("Error migrating role", "user_id", member.User.ID, "error", err)
+				failed++
+			} else if swapped {
+				migrated++
+			}
+			if swapped || err != nil {
+				throttle.done()
+			}
+		}
+
+		if len(members) < 1000 {
+			break
+		}
+		after = members[len(members)-1].User.ID
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.UnverifiedRoleID = newRoleID
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	content := fmt.Sprintf("Unverified role migrated to <@&%s>! :white_check_mark: %d member(s) moved", newRoleID, migrated)
+	if failed > 0 {
+		content += fmt.Sprintf(", %d failed (see logs)", failed)
+	}
+
+	err = saveConfig(guildID)
+	if err != nil {
+		content += "\nError saving config: " + err.Error()
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+}
+
+func setBatchReviewWindow(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	seconds := options[0].IntValue()
+	guildID := i.GuildID
+
+	if seconds < 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The batch window cannot be negative",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.BatchReviewWindow = time.Duration(seconds) * time.Second
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Batch review disabled successfully! :white_check_mark:"
+	if seconds > 0 {
+		content = fmt.Sprintf("Verification requests will be batched every %d seconds! :white_check_mark:", seconds)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func setJWTVerification(s discordSession, i *discordgo.InteractionCreate) {
+	var issuer, audience, jwksURL, affiliation string
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "issuer":
+			issuer = option.StringValue()
+		case "audience":
+			audience = option.StringValue()
+		case "jwks_url":
+			jwksURL = option.StringValue()
+		case "affiliation":
+			affiliation = option.StringValue()
+		}
+	}
+	guildID := i.GuildID
+
+	parsedURL, err := url.Parse(jwksURL)
+	if err != nil || parsedURL.Scheme != "https" || parsedURL.Host == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The JWKS URL must be a valid https:// URL",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.JWTIssuer = issuer
+	serverConfig.JWTAudience = audience
+	serverConfig.JWKSURL = jwksURL
+	serverConfig.JWTAffiliation = affiliation
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err = saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Token verification enabled for issuer %s successfully! :white_check_mark:", issuer),
+		},
+	})
+}
+
+func disableJWTVerification(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.JWTIssuer = ""
+	serverConfig.JWTAudience = ""
+	serverConfig.JWKSURL = ""
+	serverConfig.JWTAffiliation = ""
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Token verification disabled successfully! :white_check_mark:",
+		},
+	})
+}
+
+func setModActionCooldown(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	seconds := options[0].IntValue()
+	guildID := i.GuildID
+
+	if seconds < 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The cooldown cannot be negative",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.ModActionCooldown = time.Duration(seconds) * time.Second
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Moderation action cooldown disabled successfully! :white_check_mark:"
+	if seconds > 0 {
+		content = fmt.Sprintf("Moderators acting on the same member again within %d seconds will be warned! :white_check_mark:", seconds)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func setAuditResultMode(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	mode := options[0].StringValue()
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.QuietAuditResults = mode == "quiet"
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Audit results will now be %s! :white_check_mark:", mode),
+		},
+	})
+}
+
+func setRateLimitQueueing(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	enabled := options[0].BoolValue()
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.QueueRateLimited = enabled
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Rate-limited requests will now be rejected! :white_check_mark:"
+	if enabled {
+		content = "Rate-limited requests will now be queued! :white_check_mark:"
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func setAuditReactions(s discordSession, i *discordgo.InteractionCreate) {
+	var emojis []string
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Name == "emojis" {
+			emojis = parseEmojis(option.StringValue())
+		}
+	}
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.AuditReactions = emojis
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Audit reactions cleared successfully! :white_check_mark:"
+	if len(emojis) > 0 {
+		content = fmt.Sprintf("Audit reactions set to %d emoji(s) successfully! :white_check_mark:", len(emojis))
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func setAzureVerification(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	tenantID := strings.TrimSpace(options[0].StringValue())
+	guildID := i.GuildID
+
+	if !tenantIDRegex.MatchString(tenantID) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The tenant ID should be a GUID like 00000000-0000-0000-0000-000000000000",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.AzureTenantID = strings.ToLower(tenantID)
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Microsoft verification enabled successfully! :white_check_mark: Members can now run /verify_microsoft"
+	if azure.ClientID == "" {
+		content += "\nNote: the bot has no AZURE_CLIENT_ID set, so sign-in won't work until it is configured"
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func disableAzureVerification(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.AzureTenantID = ""
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Microsoft verification disabled successfully! :white_check_mark:",
+		},
+	})
+}
+
+func setReviewSummary(s discordSession, i *discordgo.InteractionCreate) {
+	var summaryTime, roleID string
+	var userIDs []string
+	timezone := "Europe/London"
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "time":
+			summaryTime = strings.TrimSpace(option.StringValue())
+		case "timezone":
+			timezone = strings.TrimSpace(option.StringValue())
+		case "role":
+			roleID = option.RoleValue(sessionOf(s), i.GuildID).ID
+		case "users":
+			userIDs = userIDRegex.FindAllString(option.StringValue(), -1)
+		}
+	}
+	guildID := i.GuildID
+
+	var validationError string
+	if _, err := time.Parse("15:04", summaryTime); err != nil {
+		validationError = "The time should be in 24-hour HH:MM format, e.g. 09:00"
+	} else if _, err := time.LoadLocation(timezone); err != nil {
+		validationError = fmt.Sprintf("Unknown timezone %s. Use a name like Europe/London", timezone)
+	} else if roleID == "" && len(userIDs) == 0 {
+		validationError = "Please choose a role or some users to send the summary to"
+	}
+	if validationError != "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: validationError,
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.SummaryTime = summaryTime
+	serverConfig.SummaryTimezone = timezone
+	serverConfig.SummaryRoleID = roleID
+	serverConfig.SummaryUserIDs = userIDs
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Review summary will be sent daily at %s (%s) successfully! :white_check_mark:", summaryTime, timezone),
+		},
+	})
+}
 
-	// Create action row
-	actionRow := discordgo.ActionsRow{
-		Components: []discordgo.MessageComponent{approveButton, denyButton},
-	}
+func disableReviewSummary(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
 
-	// Send verification request to member audit channel
-	_, err := s.ChannelMessageSendComplex(serverConfig.MemberAuditChannelID, &discordgo.MessageSend{
-		Content:    fmt.Sprintf("User %s#%s has requested verification with email %s", m.Author.Username, m.Author.Discriminator, m.Content),
-		Components: []discordgo.MessageComponent{actionRow},
-	})
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.SummaryTime = ""
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
 
+	err := saveConfig(guildID)
 	if err != nil {
-		log.Printf("Error sending message to audit channel: %v", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
 	}
-}
 
-func handleButton(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
-		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Flags: discordgo.MessageFlagsEphemeral,
+			Content: "Review summary disabled successfully! :white_check_mark:",
 		},
 	})
+}
 
-	if err != nil {
-		log.Printf("Error acknowledging interaction: %v", err)
-		return
+func setMembershipAPI(s discordSession, i *discordgo.InteractionCreate) {
+	var apiURL, mode, token string
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "url":
+			apiURL = strings.TrimSpace(option.StringValue())
+		case "mode":
+			mode = option.StringValue()
+		case "token":
+			token = option.StringValue()
+		}
 	}
+	guildID := i.GuildID
 
-	if i.Type != discordgo.InteractionMessageComponent {
-		log.Printf("Received non-component interaction: %v", i.Type)
+	parsedURL, err := url.Parse(apiURL)
+	if err != nil || (parsedURL.Scheme != "https" && parsedURL.Scheme != "http") || parsedURL.Host == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The membership API URL must be a valid http:// or https:// URL",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
 		return
 	}
 
-	customID := i.MessageComponentData().CustomID
-	log.Printf("Received button interaction: %s", customID)
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.MembershipAPIURL = apiURL
+	serverConfig.MembershipAPIToken = token
+	serverConfig.MembershipMode = mode
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
 
-	// Split by underscore to properly separate action and userID
-	parts := strings.Split(customID, "_")
-	if len(parts) != 2 {
-		log.Printf("Invalid button customID format: %s", customID)
+	err = saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
 		return
 	}
 
-	action := parts[0]
-	userID := parts[1]
+	// Ephemeral so the API token isn't echoed to the channel
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Membership checks enabled in %s mode successfully! :white_check_mark:", mode),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
 
-	log.Printf("Processing %s action for user %s", action, userID)
+func disableMembershipAPI(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
 
-	var responseContent string
-	switch action {
-	case "approve":
-		// Handle approval
-		responseContent = fmt.Sprintf("<@%s> has been approved! Welcome to the server! 🎉", userID)
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.MembershipAPIURL = ""
+	serverConfig.MembershipAPIToken = ""
+	serverConfig.MembershipMode = ""
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
 
-		// Send DM to the approved user
-		dmChannel, err := s.UserChannelCreate(userID)
-		if err != nil {
-			log.Printf("Error creating DM channel: %v", err)
-			return
-		} else {
-			approvalMessage := "You have been approved to join the UCLan Computing Society server. Welcome! 🎉"
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
 
-			_, err = s.ChannelMessageSend(dmChannel.ID, approvalMessage)
-			if err != nil {
-				log.Printf("Error sending DM: %v", err)
-			}
-		}
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Membership checks disabled successfully! :white_check_mark:",
+		},
+	})
+}
 
-		// Remove unverified role
-		configMutex.RLock()
-		if serverConfig, exists := config.Servers[i.GuildID]; exists && serverConfig.UnverifiedRoleID != "" {
-			err := s.GuildMemberRoleRemove(i.GuildID, userID, serverConfig.UnverifiedRoleID)
-			if err != nil {
-				log.Printf("Error removing unverified role: %v", err)
-			}
-		}
-		configMutex.RUnlock()
-	case "deny":
-		// Send DM to the denied user before removing them
-		dmChannel, err := s.UserChannelCreate(userID)
-		if err != nil {
-			log.Printf("Error creating DM channel: %v", err)
-			return
-		} else {
-			denialMessage := "Oops! You need to verify your identity with a UCLan email address to access the UCLan Computing Society server. This is to ensure only society members have access to the server and ensure we keep a safe and civil community.\n\nAs you did not verify your email, you were kicked from the server. You can rejoin and retry verification using this link: https://discord.gg/CEgCy5ejag. Thank you 🙂"
+func setSMSVerification(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	enabled := options[0].BoolValue()
+	guildID := i.GuildID
 
-			_, err = s.ChannelMessageSend(dmChannel.ID, denialMessage)
-			if err != nil {
-				log.Printf("Error sending DM: %v", err)
-			}
-		}
-		// Kick the member
-		err = s.GuildMemberDelete(i.GuildID, userID)
-		if err != nil {
-			log.Printf("Error kicking user %s: %v", userID, err)
-			errorContent := "Error processing denial"
-			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-				Content: &errorContent,
-			})
-			return
-		}
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.SMSVerificationEnabled = enabled
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
 
-		responseContent = fmt.Sprintf("<@%s> has been denied and removed from the server.", userID)
-	default:
-		log.Printf("Unknown action: %s", action)
-		unknownContent := "Unknown action"
-		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-			Content: &unknownContent,
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
 		})
 		return
 	}
 
-	// Update the original message to remove buttons and show the result
-	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
-		Channel:    i.ChannelID,
-		ID:         i.Message.ID,
-		Content:    &responseContent,
-		Components: &[]discordgo.MessageComponent{},
-	})
-	if err != nil {
-		log.Printf("Error editing original message: %v", err)
+	content := "Phone verification disabled successfully! :white_check_mark:"
+	if enabled {
+		content = "Phone verification enabled successfully! :white_check_mark: Members can DM the bot their number in international format, e.g. +447700900123"
+		if smsProvider == nil {
+			content += "\nNote: the bot has no SMS provider configured, so texts won't be sent until TWILIO_ACCOUNT_SID is set"
+		}
 	}
 
-	// Edit the deferred response
-	completionMessage := "Action completed successfully"
-	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
-		Content: &completionMessage,
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
 	})
-	if err != nil {
-		log.Printf("Error editing interaction response: %v", err)
-	}
 }
 
-func setMemberAuditChannel(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
-	channelID := options[0].ChannelValue(s).ID
+func addVerificationField(s discordSession, i *discordgo.InteractionCreate) {
+	var field VerificationField
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "name":
+			field.Name = strings.TrimSpace(option.StringValue())
+		case "pattern":
+			field.Pattern = option.StringValue()
+		case "example":
+			field.Example = option.StringValue()
+		}
+	}
 	guildID := i.GuildID
 
+	if _, err := regexp.Compile(field.Pattern); err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Invalid pattern: " + err.Error(),
+			},
+		})
+		return
+	}
+
 	configMutex.Lock()
-	if _, exists := config.Servers[guildID]; !exists {
-		config.Servers[guildID] = ServerConfig{}
+	// Adding a field with an existing name replaces it
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	var fields []VerificationField
+	for _, existing := range serverConfig.VerificationFields {
+		if !strings.EqualFold(existing.Name, field.Name) {
+			fields = append(fields, existing)
+		}
 	}
-	serverConfig := config.Servers[guildID]
-	serverConfig.MemberAuditChannelID = channelID
+	serverConfig.VerificationFields = append(fields, field)
 	config.Servers[guildID] = serverConfig
 	configMutex.Unlock()
 
-	err := saveConfig()
+	err := saveConfig(guildID)
 	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -464,26 +3191,40 @@ func setMemberAuditChannel(s *discordgo.Session, i *discordgo.InteractionCreate)
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("Member audit channel set successfully! :white_check_mark: <#%s>", channelID),
+			Content: fmt.Sprintf("Verification field %s added successfully! :white_check_mark: Members will now be asked:\n%s", field.Name, verificationPrompt(exampleEmail(serverConfig), serverConfig.VerificationFields)),
 		},
 	})
 }
 
-func setUnverifiedRole(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func removeVerificationField(s discordSession, i *discordgo.InteractionCreate) {
 	options := i.ApplicationCommandData().Options
-	roleID := options[0].RoleValue(s, i.GuildID).ID
+	name := strings.TrimSpace(options[0].StringValue())
 	guildID := i.GuildID
 
 	configMutex.Lock()
-	if _, exists := config.Servers[guildID]; !exists {
-		config.Servers[guildID] = ServerConfig{}
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	var fields []VerificationField
+	for _, existing := range serverConfig.VerificationFields {
+		if !strings.EqualFold(existing.Name, name) {
+			fields = append(fields, existing)
+		}
 	}
-	serverConfig := config.Servers[guildID]
-	serverConfig.UnverifiedRoleID = roleID
+	removed := len(fields) != len(serverConfig.VerificationFields)
+	serverConfig.VerificationFields = fields
 	config.Servers[guildID] = serverConfig
 	configMutex.Unlock()
 
-	err := saveConfig()
+	if !removed {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: fmt.Sprintf("There is no verification field called %s", name),
+			},
+		})
+		return
+	}
+
+	err := saveConfig(guildID)
 	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -497,29 +3238,42 @@ func setUnverifiedRole(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("Verified role set successfully! :white_check_mark: <@&%s>", roleID),
+			Content: fmt.Sprintf("Verification field %s removed successfully! :white_check_mark:", name),
 		},
 	})
 }
 
-func enableRateLimit(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	guildID := i.GuildID
-
-	configMutex.Lock()
-	if _, exists := config.Servers[guildID]; !exists {
-		config.Servers[guildID] = ServerConfig{}
+func setMilestoneAnnouncements(s discordSession, i *discordgo.InteractionCreate) {
+	var interval int64
+	var channelID string
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "every":
+			interval = option.IntValue()
+		case "channel":
+			channelID = option.ChannelValue(sessionOf(s)).ID
+		}
 	}
+	guildID := i.GuildID
 
-	serverConfig := config.Servers[guildID]
-	serverConfig.RateLimitEnabled = true
-	if serverConfig.RateLimitDuration == 0 {
-		serverConfig.RateLimitDuration = 5 * time.Minute // Default to 5 minutes
+	if interval < 0 || (interval > 0 && channelID == "") {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Please give a positive milestone interval and a channel, or 0 to disable announcements",
+			},
+		})
+		return
 	}
 
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.MilestoneInterval = int(interval)
+	serverConfig.MilestoneChannelID = channelID
 	config.Servers[guildID] = serverConfig
 	configMutex.Unlock()
 
-	err := saveConfig()
+	err := saveConfig(guildID)
 	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -530,28 +3284,31 @@ func enableRateLimit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
+	content := "Milestone announcements disabled successfully! :white_check_mark:"
+	if interval > 0 {
+		content = fmt.Sprintf("Milestones will be announced every %d verified members in <#%s>! :white_check_mark:", interval, channelID)
+	}
+
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: "Rate limiting enabled successfully! :white_check_mark:",
+			Content: content,
 		},
 	})
 }
 
-func disableRateLimit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func setSelfApproval(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	allowed := options[0].BoolValue()
 	guildID := i.GuildID
 
 	configMutex.Lock()
-	if _, exists := config.Servers[guildID]; !exists {
-		config.Servers[guildID] = ServerConfig{}
-	}
-
-	serverConfig := config.Servers[guildID]
-	serverConfig.RateLimitEnabled = false
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.PreventSelfApproval = !allowed
 	config.Servers[guildID] = serverConfig
 	configMutex.Unlock()
 
-	err := saveConfig()
+	err := saveConfig(guildID)
 	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -562,30 +3319,58 @@ func disableRateLimit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 		return
 	}
 
+	content := "Moderators can no longer handle their own verification requests! :white_check_mark:"
+	if allowed {
+		content = "Moderators can now handle their own verification requests! :white_check_mark:"
+	}
+
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: "Rate limiting disabled successfully! :white_check_mark:",
+			Content: content,
 		},
 	})
 }
 
-func setRateLimit(s *discordgo.Session, i *discordgo.InteractionCreate) {
-	options := i.ApplicationCommandData().Options
-	minutes := options[0].IntValue()
+func setPartnerVerification(s discordSession, i *discordgo.InteractionCreate) {
+	var partnerGuildID, partnerRoleID string
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "partner_guild_id":
+			partnerGuildID = strings.TrimSpace(option.StringValue())
+		case "partner_role_id":
+			partnerRoleID = strings.TrimSpace(option.StringValue())
+		}
+	}
 	guildID := i.GuildID
 
-	configMutex.Lock()
-	if _, exists := config.Servers[guildID]; !exists {
-		config.Servers[guildID] = ServerConfig{}
+	// The cross-check only works if the bot can see the partner server
+	var validationError string
+	if partnerGuildID == guildID {
+		validationError = "The partner server must be a different server"
+	} else if _, err := stateOf(s).Guild(partnerGuildID); err != nil {
+		validationError = "The bot isn't in that server. Invite it to the partner server first"
+	} else if _, err := stateOf(s).Role(partnerGuildID, partnerRoleID); err != nil {
+		validationError = "That role doesn't exist in the partner server"
+	}
+	if validationError != "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: validationError,
+			},
+		})
+		return
 	}
 
-	serverConfig := config.Servers[guildID]
-	serverConfig.RateLimitDuration = time.Duration(minutes) * time.Minute
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.PartnerGuildID = partnerGuildID
+	serverConfig.PartnerRoleID = partnerRoleID
 	config.Servers[guildID] = serverConfig
 	configMutex.Unlock()
 
-	err := saveConfig()
+	err := saveConfig(guildID)
 	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
@@ -599,71 +3384,135 @@ func setRateLimit(s *discordgo.Session, i *discordgo.InteractionCreate) {
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: fmt.Sprintf("Rate limit set to %d minutes successfully! :white_check_mark:", minutes),
+			Content: "Partner verification enabled successfully! :white_check_mark:",
 		},
 	})
 }
 
-func checkRateLimit(s *discordgo.Session, i *discordgo.InteractionCreate) {
+func disablePartnerVerification(s discordSession, i *discordgo.InteractionCreate) {
 	guildID := i.GuildID
 
-	configMutex.RLock()
-	serverConfig, exists := config.Servers[guildID]
-	configMutex.RUnlock()
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.PartnerGuildID = ""
+	serverConfig.PartnerRoleID = ""
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
 
-	if !exists {
+	err := saveConfig(guildID)
+	if err != nil {
 		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 			Type: discordgo.InteractionResponseChannelMessageWithSource,
 			Data: &discordgo.InteractionResponseData{
-				Content: "No rate limit configured for this server",
+				Content: "Error saving config: " + err.Error(),
 			},
 		})
 		return
 	}
 
-	var content string
-	if serverConfig.RateLimitEnabled {
-		content = fmt.Sprintf("Rate limit is enabled with a duration of %d minutes", int(serverConfig.RateLimitDuration/time.Minute))
-	} else {
-		content = "Rate limit is disabled"
-	}
-
 	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
 		Type: discordgo.InteractionResponseChannelMessageWithSource,
 		Data: &discordgo.InteractionResponseData{
-			Content: content,
+			Content: "Partner verification disabled successfully! :white_check_mark:",
 		},
 	})
 }
 
-func guildMemberAdd(s *discordgo.Session, m *discordgo.GuildMemberAdd) {
-	configMutex.RLock()
-	serverConfig, exists := config.Servers[m.GuildID]
-	configMutex.RUnlock()
+// swapMemberRole gives the member newRoleID in place of oldRoleID. It reports
+// false without making any calls if the member doesn't hold oldRoleID.
+func swapMemberRole(s discordSession, guildID string, member *discordgo.Member, oldRoleID, newRoleID string) (bool, error) {
+	if !memberHasRole(member, oldRoleID) {
+		return false, nil
+	}
 
-	if !exists {
-		log.Printf("No config found for guild %s", m.GuildID)
+	err := memberRoleAdd(s, guildID, member.User.ID, newRoleID)
+	if err != nil {
+		return false, err
+	}
+
+	err = memberRoleRemove(s, guildID, member.User.ID, oldRoleID)
+	if err != nil {
+		return false, err
+	}
+	return true, nil
+}
+
+func memberHasRole(member *discordgo.Member, roleID string) bool {
+	for _, id := range member.Roles {
+		if id == roleID {
+			return true
+		}
+	}
+	return false
+}
+
+// denialRetryDM tells a denied user they can try again, naming the guild
+// rather than assuming which university it belongs to.
+func denialRetryDM(guildName string, remaining int) string {
+	return fmt.Sprintf("Your verification request for %s was denied. Please reply with a valid email address to try again. You have %d attempt(s) remaining.", guildName, remaining)
+}
+
+// useDenialRetry consumes one of the user's retries, returning how many are
+// left. ok is false once the user has run out and should be kicked.
+func useDenialRetry(guildID, userID string, maxRetries int) (remaining int, ok bool) {
+	key := guildID + ":" + userID
+
+	denialRetryLock.Lock()
+	defer denialRetryLock.Unlock()
+
+	used := denialRetryCounts[key]
+	if used >= maxRetries {
+		return 0, false
+	}
+	denialRetryCounts[key] = used + 1
+	return maxRetries - used - 1, true
+}
+
+func resetDenialRetries(guildID, userID string) {
+	denialRetryLock.Lock()
+	delete(denialRetryCounts, guildID+":"+userID)
+	denialRetryLock.Unlock()
+}
+
+func guildMemberAdd(s discordSession, m *discordgo.GuildMemberAdd) {
+	logger := slog.With("guild_id", m.GuildID, "user_id", m.User.ID)
+
+	// Ignore duplicated join events from quick leave/rejoin races
+	if !firstJoinEvent(m.GuildID, m.User.ID, time.Now()) {
+		logger.Debug("Ignoring duplicate join event")
+		return
+	}
+
+	serverConfig := getOrCreateServerConfig(m.GuildID)
+
+	// Throwaway accounts below the hard cutoff are turned away outright
+	now := time.Now()
+	if isNewAccount(m.User.ID, serverConfig.AccountAgeCutoff, now) {
+		denyNewAccount(s, m.GuildID, m.User.ID, serverConfig)
 		return
 	}
+	if isNewAccount(m.User.ID, serverConfig.MinAccountAge, now) {
+		logger.Info("New account joined", "account_age", accountAge(m.User.ID, now).Round(time.Minute))
+	}
 
 	// Apply Unverified role
 	if serverConfig.UnverifiedRoleID != "" {
-		err := s.GuildMemberRoleAdd(m.GuildID, m.User.ID, serverConfig.UnverifiedRoleID)
-		if err != nil {
-			log.Printf("Error adding role to user %s: %v", m.User.ID, err)
+		err := withRetry(func() error { return memberRoleAdd(s, m.GuildID, m.User.ID, serverConfig.UnverifiedRoleID) })
+		if hasErrorCode(err, discordgo.ErrCodeUnknownRole) {
+			logger.Error("Configured unverified role no longer exists", "role_id", serverConfig.UnverifiedRoleID)
+			warnMissingUnverifiedRole(s, m.GuildID, serverConfig, time.Now())
+		} else if err != nil {
+			logger.Error("Error adding unverified role", "error", err)
 		}
 	} else {
-		log.Printf("No unverified role configured for guild %s", m.GuildID)
+		logger.Warn("No unverified role configured")
 	}
 
 	// Send DM to new member
-	channel, err := s.UserChannelCreate(m.User.ID)
-	if err != nil {
-		fmt.Printf("Error creating DM channel: %v\n", err)
-		return
-	}
-	_, err = s.ChannelMessageSend(channel.ID, "Welcome! Please provide your university email for verification. For example:```example@uclan.ac.uk```")
-	if err != nil {
-		fmt.Println("Error sending DM:", err)
+	err := sendWelcomeDM(s, m.GuildID, m.User.ID, serverConfig)
+	if errors.Is(err, errDMsClosed) {
+		logger.Warn("Could not send welcome DM, DMs are closed")
+	} else if err != nil {
+		logger.Error("Error sending DM", "error", err)
 	}
 }
@@ -0,0 +1,778 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"os"
+	"slices"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type sentMessage struct {
+	ChannelID string
+	Data      *discordgo.MessageSend
+}
+
+// fakeSession records the calls made to it instead of talking to Discord
+type fakeSession struct {
+	mu    sync.Mutex
+	calls []string
+
+	// Messages sent to channels and edits made to existing ones
+	sent  []sentMessage
+	edits []*discordgo.MessageEdit
+
+	// Responses to interactions
+	responses []*discordgo.InteractionResponse
+
+	kickErr error
+	// Returned when opening a DM channel
+	dmErr error
+	// Returned when removing a role
+	roleRemoveErr error
+	// Roles members hold, by guild ID
+	memberRoles map[string][]string
+
+	// Guilds and members the bot can see, if the test needs any
+	state *discordgo.State
+}
+
+func (f *fakeSession) sessionState() *discordgo.State {
+	return f.state
+}
+
+func (f *fakeSession) record(call string) {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	f.calls = append(f.calls, call)
+}
+
+func (f *fakeSession) called(call string) bool {
+	f.mu.Lock()
+	defer f.mu.Unlock()
+	for _, c := range f.calls {
+		if c == call {
+			return true
+		}
+	}
+	return false
+}
+
+func (f *fakeSession) ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	return f.ChannelMessageSendComplex(channelID, &discordgo.MessageSend{Content: content})
+}
+
+func (f *fakeSession) ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.record("ChannelMessageSend " + channelID)
+	f.mu.Lock()
+	f.sent = append(f.sent, sentMessage{ChannelID: channelID, Data: data})
+	f.mu.Unlock()
+	return &discordgo.Message{ID: "message", ChannelID: channelID, Content: data.Content}, nil
+}
+
+func (f *fakeSession) ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.record("ChannelMessageEdit " + m.Channel + " " + m.ID)
+	f.mu.Lock()
+	f.edits = append(f.edits, m)
+	f.mu.Unlock()
+	return &discordgo.Message{ID: m.ID, ChannelID: m.Channel}, nil
+}
+
+func (f *fakeSession) ChannelEdit(channelID string, data *discordgo.ChannelEdit, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.record("ChannelEdit " + channelID)
+	return &discordgo.Channel{ID: channelID}, nil
+}
+
+func (f *fakeSession) UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.record("UserChannelCreate " + recipientID)
+	if f.dmErr != nil {
+		return nil, f.dmErr
+	}
+	return &discordgo.Channel{ID: "dm-" + recipientID, Type: discordgo.ChannelTypeDM}, nil
+}
+
+func (f *fakeSession) GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error) {
+	f.record("GuildMember " + guildID + " " + userID)
+	return &discordgo.Member{GuildID: guildID, User: &discordgo.User{ID: userID}, Roles: f.memberRoles[guildID]}, nil
+}
+
+func (f *fakeSession) GuildMemberRoleAdd(guildID, userID, roleID string, options ...discordgo.RequestOption) error {
+	f.record("GuildMemberRoleAdd " + guildID + " " + userID + " " + roleID)
+	return nil
+}
+
+func (f *fakeSession) GuildMemberRoleRemove(guildID, userID, roleID string, options ...discordgo.RequestOption) error {
+	f.record("GuildMemberRoleRemove " + guildID + " " + userID + " " + roleID)
+	return f.roleRemoveErr
+}
+
+func (f *fakeSession) GuildMemberDelete(guildID, userID string, options ...discordgo.RequestOption) error {
+	f.record("GuildMemberDelete " + guildID + " " + userID)
+	return f.kickErr
+}
+
+func (f *fakeSession) GuildMemberMove(guildID string, userID string, channelID *string, options ...discordgo.RequestOption) error {
+	f.record("GuildMemberMove " + guildID + " " + userID)
+	return nil
+}
+
+func (f *fakeSession) InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error {
+	f.record("InteractionRespond")
+	f.mu.Lock()
+	f.responses = append(f.responses, resp)
+	f.mu.Unlock()
+	return nil
+}
+
+func (f *fakeSession) InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	content := ""
+	if newresp.Content != nil {
+		content = *newresp.Content
+	}
+	f.record("InteractionResponseEdit " + content)
+	return &discordgo.Message{}, nil
+}
+
+func (f *fakeSession) FollowupMessageCreate(interaction *discordgo.Interaction, wait bool, data *discordgo.WebhookParams, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.record("FollowupMessageCreate")
+	return &discordgo.Message{}, nil
+}
+
+func (f *fakeSession) MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error {
+	f.record("MessageReactionAdd " + channelID + " " + messageID + " " + emojiID)
+	return nil
+}
+
+func (f *fakeSession) MessageThreadStartComplex(channelID, messageID string, data *discordgo.ThreadStart, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.record("MessageThreadStart " + channelID + " " + messageID)
+	return &discordgo.Channel{ID: "thread"}, nil
+}
+
+func (f *fakeSession) ChannelMessage(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error) {
+	f.record("ChannelMessage " + channelID + " " + messageID)
+	return &discordgo.Message{ID: messageID, ChannelID: channelID}, nil
+}
+
+func (f *fakeSession) ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error) {
+	f.record("ChannelMessages " + channelID)
+	return nil, nil
+}
+
+func (f *fakeSession) ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error {
+	f.record("ChannelMessageDelete " + channelID + " " + messageID)
+	return nil
+}
+
+func (f *fakeSession) ChannelMessagesBulkDelete(channelID string, messages []string, options ...discordgo.RequestOption) error {
+	f.record("ChannelMessagesBulkDelete " + channelID)
+	return nil
+}
+
+// Channel reports channels named "dm-..." as DMs and anything else as a
+// guild text channel
+func (f *fakeSession) Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error) {
+	f.record("Channel " + channelID)
+	if strings.HasPrefix(channelID, "dm-") {
+		return &discordgo.Channel{ID: channelID, Type: discordgo.ChannelTypeDM}, nil
+	}
+	return &discordgo.Channel{ID: channelID, Type: discordgo.ChannelTypeGuildText}, nil
+}
+
+func (f *fakeSession) GuildLeave(guildID string, options ...discordgo.RequestOption) error {
+	f.record("GuildLeave " + guildID)
+	return nil
+}
+
+func (f *fakeSession) GuildRoles(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Role, error) {
+	f.record("GuildRoles " + guildID)
+	return nil, nil
+}
+
+func (f *fakeSession) GuildMembers(guildID string, after string, limit int, options ...discordgo.RequestOption) ([]*discordgo.Member, error) {
+	f.record("GuildMembers " + guildID)
+	return nil, nil
+}
+
+func (f *fakeSession) GuildMemberTimeout(guildID string, userID string, until *time.Time, options ...discordgo.RequestOption) error {
+	f.record("GuildMemberTimeout " + guildID + " " + userID)
+	return nil
+}
+
+func (f *fakeSession) InteractionResponseDelete(interaction *discordgo.Interaction, options ...discordgo.RequestOption) error {
+	f.record("InteractionResponseDelete")
+	return nil
+}
+
+func (f *fakeSession) ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error) {
+	f.record("ApplicationCommandBulkOverwrite " + guildID)
+	return commands, nil
+}
+
+func (f *fakeSession) ApplicationCommandDelete(appID, guildID, cmdID string, options ...discordgo.RequestOption) error {
+	f.record("ApplicationCommandDelete " + guildID + " " + cmdID)
+	return nil
+}
+
+// The verification log is written to ./data, so run from a scratch directory
+func TestMain(m *testing.M) {
+	dir, err := os.MkdirTemp("", "modbot-test")
+	if err != nil {
+		panic(err)
+	}
+	err = os.Chdir(dir)
+	if err != nil {
+		panic(err)
+	}
+	code := m.Run()
+	os.RemoveAll(dir)
+	os.Exit(code)
+}
+
+// useServerConfig replaces the loaded config with a single guild for the
+// length of the test
+func useServerConfig(t *testing.T, guildID string, serverConfig ServerConfig) {
+	t.Helper()
+	configMutex.Lock()
+	previous := config
+	config = Config{Servers: map[string]ServerConfig{guildID: serverConfig}}
+	configMutex.Unlock()
+
+	t.Cleanup(func() {
+		configMutex.Lock()
+		config = previous
+		configMutex.Unlock()
+	})
+}
+
+func TestProcessEmailVerification(t *testing.T) {
+	const guildID = "100000000000000001"
+	auditConfig := ServerConfig{MemberAuditChannelID: "audit"}
+
+	tests := []struct {
+		name         string
+		userID       string
+		content      string
+		serverConfig ServerConfig
+		rateLimited  bool
+		wantAudit    bool
+		wantReply    string
+	}{
+		{
+			name:         "valid email goes to the moderators",
+			userID:       "200000000000000001",
+			content:      "student@uclan.ac.uk",
+			serverConfig: auditConfig,
+			wantAudit:    true,
+		},
+		{
+			name:         "wrong domain is rejected",
+			userID:       "200000000000000002",
+			content:      "student@example.com",
+			serverConfig: auditConfig,
+			wantReply:    "@uclan.ac.uk",
+		},
+		{
+			name:         "no audit channel",
+			userID:       "200000000000000003",
+			content:      "student@uclan.ac.uk",
+			serverConfig: ServerConfig{},
+			wantReply:    "verification isn't set up on this server yet",
+		},
+		{
+			name:    "rate limited",
+			userID:  "200000000000000004",
+			content: "student@uclan.ac.uk",
+			serverConfig: ServerConfig{
+				MemberAuditChannelID: "audit",
+				RateLimitEnabled:     true,
+				RateLimitDuration:    time.Hour,
+				CooldownMessage:      "Slow down, try again in {remaining}.",
+			},
+			rateLimited: true,
+			wantReply:   "Slow down",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useServerConfig(t, guildID, tt.serverConfig)
+			if tt.rateLimited {
+				rateLimitLock.Lock()
+				rateLimitMap[rateLimitKey(guildID, tt.userID)] = time.Now()
+				rateLimitLock.Unlock()
+			}
+
+			s := &fakeSession{}
+			processEmailVerification(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+				ID:        "submission-" + tt.userID,
+				ChannelID: "dm-" + tt.userID,
+				GuildID:   guildID,
+				Content:   tt.content,
+				Author:    &discordgo.User{ID: tt.userID, Username: "student"},
+			}})
+			removePendingVerification(guildID, tt.userID)
+
+			var audit *discordgo.MessageSend
+			var replies []string
+			for _, message := range s.sent {
+				switch message.ChannelID {
+				case "audit":
+					audit = message.Data
+				case "dm-" + tt.userID:
+					replies = append(replies, message.Data.Content)
+				}
+			}
+
+			if tt.wantAudit != (audit != nil) {
+				t.Fatalf("audit message sent = %v, want %v (calls %q)", audit != nil, tt.wantAudit, s.calls)
+			}
+			if audit != nil {
+				row := audit.Components[0].(discordgo.ActionsRow)
+				approve := row.Components[0].(discordgo.Button).CustomID
+				deny := row.Components[1].(discordgo.Button).CustomID
+				if approve != encodeCustomID("approve", tt.userID) || deny != encodeCustomID("deny", tt.userID) {
+					t.Errorf("buttons = %q, %q", approve, deny)
+				}
+			}
+			if len(replies) != 1 {
+				t.Fatalf("got %d replies, want 1 (calls %q)", len(replies), s.calls)
+			}
+			if !strings.Contains(replies[0], tt.wantReply) {
+				t.Errorf("reply = %q, want it to contain %q", replies[0], tt.wantReply)
+			}
+		})
+	}
+}
+
+func TestHandleButton(t *testing.T) {
+	const (
+		guildID     = "100000000000000002"
+		moderatorID = "300000000000000001"
+	)
+	serverConfig := ServerConfig{
+		MemberAuditChannelID: "audit",
+		UnverifiedRoleID:     "unverified",
+		VerifiedRoleID:       "verified",
+	}
+
+	tests := []struct {
+		name                string
+		customID            string
+		kickErr             error
+		dmErr               error
+		preventSelfApproval bool
+		wantCalls           []string
+		wantEdit            string
+	}{
+		{
+			name:     "approve",
+			customID: encodeCustomID("approve", "200000000000000011"),
+			wantCalls: []string{
+				"UserChannelCreate 200000000000000011",
+				"GuildMemberRoleRemove " + guildID + " 200000000000000011 unverified",
+				"GuildMemberRoleAdd " + guildID + " 200000000000000011 verified",
+			},
+			wantEdit: "has been approved",
+		},
+		{
+			name:     "approve when the DM fails",
+			customID: encodeCustomID("approve", "200000000000000093"),
+			dmErr:    errors.New("discord is down"),
+			wantCalls: []string{
+				"GuildMemberRoleRemove " + guildID + " 200000000000000093 unverified",
+				"GuildMemberRoleAdd " + guildID + " 200000000000000093 verified",
+			},
+			wantEdit: "couldn't be messaged (discord is down)",
+		},
+		{
+			name:     "deny",
+			customID: encodeCustomID("deny", "200000000000000012"),
+			wantCalls: []string{
+				"UserChannelCreate 200000000000000012",
+				"GuildMemberDelete " + guildID + " 200000000000000012",
+			},
+			wantEdit: "has been denied and removed from the server",
+		},
+		{
+			name:     "deny after the member left",
+			customID: encodeCustomID("deny", "200000000000000013"),
+			kickErr: &discordgo.RESTError{Message: &discordgo.APIErrorMessage{
+				Code: discordgo.ErrCodeUnknownMember,
+			}},
+			wantCalls: []string{"GuildMemberDelete " + guildID + " 200000000000000013"},
+			wantEdit:  "They had already left the server",
+		},
+		{
+			name:                "moderator approving themselves",
+			customID:            encodeCustomID("approve", moderatorID),
+			preventSelfApproval: true,
+			wantCalls:           []string{"InteractionResponseEdit You can't approve or deny your own verification request. Please ask another moderator."},
+		},
+		{
+			name:      "invalid customID",
+			customID:  "nonsense",
+			wantCalls: []string{"InteractionRespond"},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			caseConfig := serverConfig
+			caseConfig.PreventSelfApproval = tt.preventSelfApproval
+			useServerConfig(t, guildID, caseConfig)
+
+			s := &fakeSession{kickErr: tt.kickErr, dmErr: tt.dmErr}
+			handleButton(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+				Type:      discordgo.InteractionMessageComponent,
+				GuildID:   guildID,
+				ChannelID: "audit",
+				Message:   &discordgo.Message{ID: "audit-" + tt.name},
+				Member:    &discordgo.Member{User: &discordgo.User{ID: moderatorID}},
+				Data:      discordgo.MessageComponentInteractionData{CustomID: tt.customID},
+			}})
+
+			for _, call := range tt.wantCalls {
+				if !s.called(call) {
+					t.Errorf("missing call %q (calls %q)", call, s.calls)
+				}
+			}
+
+			if tt.wantEdit == "" {
+				if len(s.edits) != 0 {
+					t.Errorf("audit message edited, want it left alone")
+				}
+				return
+			}
+			if len(s.edits) != 1 {
+				t.Fatalf("got %d audit message edits, want 1 (calls %q)", len(s.edits), s.calls)
+			}
+			edit := s.edits[0]
+			if !strings.Contains(*edit.Content, tt.wantEdit) || !strings.Contains(*edit.Content, "<@"+moderatorID+">") {
+				t.Errorf("audit message = %q, want it to contain %q and the moderator", *edit.Content, tt.wantEdit)
+			}
+			if edit.Components == nil || len(*edit.Components) != 0 {
+				t.Errorf("buttons weren't removed from the audit message")
+			}
+		})
+	}
+}
+
+func TestUseDenialRetry(t *testing.T) {
+	const guildID = "100000000000000004"
+
+	tests := []struct {
+		name          string
+		userID        string
+		maxRetries    int
+		uses          int
+		wantRemaining int
+		wantOK        bool
+	}{
+		{name: "retries disabled", userID: "200000000000000031", maxRetries: 0, uses: 1, wantOK: false},
+		{name: "first retry", userID: "200000000000000032", maxRetries: 2, uses: 1, wantRemaining: 1, wantOK: true},
+		{name: "last retry", userID: "200000000000000033", maxRetries: 2, uses: 2, wantRemaining: 0, wantOK: true},
+		{name: "retries used up", userID: "200000000000000034", maxRetries: 2, uses: 3, wantOK: false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			t.Cleanup(func() { resetDenialRetries(guildID, tt.userID) })
+
+			var remaining int
+			var ok bool
+			for range tt.uses {
+				remaining, ok = useDenialRetry(guildID, tt.userID, tt.maxRetries)
+			}
+			if remaining != tt.wantRemaining || ok != tt.wantOK {
+				t.Errorf("useDenialRetry() = %d, %v, want %d, %v", remaining, ok, tt.wantRemaining, tt.wantOK)
+			}
+		})
+	}
+}
+
+// Denied members get their retries before they're kicked
+func TestDenialRetryDM(t *testing.T) {
+	got := denialRetryDM("Computing Society", 2)
+	want := "Your verification request for Computing Society was denied. Please reply with a valid email address to try again. You have 2 attempt(s) remaining."
+	if got != want {
+		t.Errorf("denialRetryDM() = %q, want %q", got, want)
+	}
+}
+
+func TestDenyWithRetries(t *testing.T) {
+	const (
+		guildID = "100000000000000005"
+		userID  = "200000000000000035"
+	)
+	useServerConfig(t, guildID, ServerConfig{MemberAuditChannelID: "audit", DenialRetries: 1})
+	t.Cleanup(func() { resetDenialRetries(guildID, userID) })
+
+	deny := func() *fakeSession {
+		s := &fakeSession{}
+		handleButton(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:      discordgo.InteractionMessageComponent,
+			GuildID:   guildID,
+			ChannelID: "audit",
+			Message:   &discordgo.Message{ID: "audit-retry"},
+			Member:    &discordgo.Member{User: &discordgo.User{ID: "300000000000000002"}},
+			Data:      discordgo.MessageComponentInteractionData{CustomID: encodeCustomID("deny", userID)},
+		}})
+		return s
+	}
+
+	s := deny()
+	if s.called("GuildMemberDelete " + guildID + " " + userID) {
+		t.Fatal("member kicked while they still had a retry")
+	}
+	if len(s.sent) == 0 || s.sent[0].ChannelID != "dm-"+userID || s.sent[0].Data.Content != denialRetryDM("the server", 0) {
+		t.Errorf("messages sent = %+v, want the retry DM first", s.sent)
+	}
+	if len(s.edits) != 1 || !strings.Contains(*s.edits[0].Content, "may resubmit (0 retries remaining)") {
+		t.Errorf("audit message edits = %v, want the retry noted", s.edits)
+	}
+
+	// Out of retries, so the next denial kicks
+	s = deny()
+	if !s.called("GuildMemberDelete " + guildID + " " + userID) {
+		t.Errorf("member not kicked once out of retries (calls %q)", s.calls)
+	}
+}
+
+func TestArchiveAuditThreadOnDecision(t *testing.T) {
+	const guildID = "100000000000000007"
+
+	tests := []struct {
+		name         string
+		serverConfig ServerConfig
+		wantArchive  bool
+	}{
+		{name: "threads disabled", serverConfig: ServerConfig{ArchiveDecidedThreads: true}},
+		{name: "archiving disabled", serverConfig: ServerConfig{UseAuditThreads: true}},
+		{name: "archiving enabled", serverConfig: ServerConfig{UseAuditThreads: true, ArchiveDecidedThreads: true}, wantArchive: true},
+	}
+
+	for idx, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useServerConfig(t, guildID, tt.serverConfig)
+			userID := fmt.Sprintf("20000000000000005%d", idx)
+			messageID := "audit-thread-" + userID
+
+			s := &fakeSession{}
+			handleButton(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+				Type:      discordgo.InteractionMessageComponent,
+				GuildID:   guildID,
+				ChannelID: "audit",
+				Message:   &discordgo.Message{ID: messageID},
+				Member:    &discordgo.Member{User: &discordgo.User{ID: "300000000000000003"}},
+				Data:      discordgo.MessageComponentInteractionData{CustomID: encodeCustomID("approve", userID)},
+			}})
+
+			if archived := s.called("ChannelEdit " + messageID); archived != tt.wantArchive {
+				t.Errorf("thread archived = %v, want %v (calls %q)", archived, tt.wantArchive, s.calls)
+			}
+		})
+	}
+}
+
+func TestIsDM(t *testing.T) {
+	tests := []struct {
+		name       string
+		message    *discordgo.Message
+		want       bool
+		wantLookup bool
+	}{
+		{
+			name:    "guild message",
+			message: &discordgo.Message{GuildID: "100000000000000001", ChannelID: "general", Author: &discordgo.User{ID: "200000000000000091"}},
+		},
+		{
+			name:       "DM",
+			message:    &discordgo.Message{ChannelID: "dm-200000000000000091", Author: &discordgo.User{ID: "200000000000000091"}},
+			want:       true,
+			wantLookup: true,
+		},
+		{
+			name:       "guild channel without a guild ID",
+			message:    &discordgo.Message{ChannelID: "general", Author: &discordgo.User{ID: "200000000000000091"}},
+			wantLookup: true,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeSession{}
+			if got := isDM(s, &discordgo.MessageCreate{Message: tt.message}); got != tt.want {
+				t.Errorf("isDM() = %v, want %v", got, tt.want)
+			}
+			if looked := s.called("Channel " + tt.message.ChannelID); looked != tt.wantLookup {
+				t.Errorf("channel looked up = %v, want %v", looked, tt.wantLookup)
+			}
+		})
+	}
+}
+
+func TestSetRateLimit(t *testing.T) {
+	const guildID = "100000000000000021"
+
+	tests := []struct {
+		name        string
+		minutes     int
+		wantApplied bool
+	}{
+		{name: "zero", minutes: 0},
+		{name: "negative", minutes: -5},
+		{name: "minimum", minutes: 1, wantApplied: true},
+		{name: "maximum", minutes: maxRateLimitMinutes, wantApplied: true},
+		{name: "above the maximum", minutes: maxRateLimitMinutes + 1},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useServerConfig(t, guildID, ServerConfig{RateLimitDuration: 10 * time.Minute})
+
+			s := &fakeSession{}
+			setRateLimit(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+				Type:    discordgo.InteractionApplicationCommand,
+				GuildID: guildID,
+				Data: discordgo.ApplicationCommandInteractionData{
+					Name: "set_rate_limit",
+					Options: []*discordgo.ApplicationCommandInteractionDataOption{{
+						Name:  "minutes",
+						Type:  discordgo.ApplicationCommandOptionInteger,
+						Value: float64(tt.minutes),
+					}},
+				},
+			}})
+
+			if len(s.responses) != 1 {
+				t.Fatalf("got %d responses, want 1", len(s.responses))
+			}
+			content := s.responses[0].Data.Content
+
+			want := 10 * time.Minute
+			if tt.wantApplied {
+				want = time.Duration(tt.minutes) * time.Minute
+				if !strings.Contains(content, "successfully") {
+					t.Errorf("response = %q, want success", content)
+				}
+			} else if !strings.Contains(content, fmt.Sprintf("between 1 and %d minutes", maxRateLimitMinutes)) {
+				t.Errorf("response = %q, want the allowed range", content)
+			}
+			if got := getOrCreateServerConfig(guildID).RateLimitDuration; got != want {
+				t.Errorf("rate limit = %s, want %s", got, want)
+			}
+		})
+	}
+}
+
+func TestSwapMemberRole(t *testing.T) {
+	const (
+		guildID = "100000000000000028"
+		userID  = "200000000000000109"
+	)
+	add := "GuildMemberRoleAdd " + guildID + " " + userID + " new"
+	remove := "GuildMemberRoleRemove " + guildID + " " + userID + " old"
+
+	tests := []struct {
+		name          string
+		roles         []string
+		roleRemoveErr error
+		wantSwapped   bool
+		wantErr       bool
+		wantCalls     []string
+	}{
+		{name: "swapped", roles: []string{"old"}, wantSwapped: true, wantCalls: []string{add, remove}},
+		{name: "doesn't hold the old role", roles: []string{"other"}},
+		{
+			name:          "removing the old role fails",
+			roles:         []string{"old"},
+			roleRemoveErr: errors.New("missing permissions"),
+			wantErr:       true,
+			wantCalls:     []string{add, remove},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			s := &fakeSession{roleRemoveErr: tt.roleRemoveErr}
+			member := &discordgo.Member{User: &discordgo.User{ID: userID}, Roles: tt.roles}
+
+			swapped, err := swapMemberRole(s, guildID, member, "old", "new")
+			if swapped != tt.wantSwapped || (err != nil) != tt.wantErr {
+				t.Errorf("swapMemberRole() = %v, %v, want %v, error %v", swapped, err, tt.wantSwapped, tt.wantErr)
+			}
+			if !slices.Equal(s.calls, tt.wantCalls) {
+				t.Errorf("calls = %q, want %q", s.calls, tt.wantCalls)
+			}
+		})
+	}
+}
+
+func TestAuditResultContent(t *testing.T) {
+	const (
+		guildID = "100000000000000029"
+		userID  = "200000000000000110"
+	)
+	outcome := "<@" + userID + "> has been denied and removed from the server."
+
+	tests := []struct {
+		name  string
+		quiet bool
+		want  string
+	}{
+		{name: "public", want: outcome},
+		{name: "quiet", quiet: true, want: "Verification request from <@" + userID + "> handled."},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useServerConfig(t, guildID, ServerConfig{QuietAuditResults: tt.quiet})
+
+			if got := auditResultContent(guildID, userID, outcome); got != tt.want {
+				t.Errorf("auditResultContent() = %q, want %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestAuditReactions(t *testing.T) {
+	const (
+		guildID = "100000000000000030"
+		userID  = "200000000000000111"
+	)
+	emojis := parseEmojis("👀 <:pending:123456> <a:spin:789>")
+	if want := []string{"👀", "pending:123456", "spin:789"}; !slices.Equal(emojis, want) {
+		t.Fatalf("parseEmojis() = %q, want %q", emojis, want)
+	}
+	useServerConfig(t, guildID, ServerConfig{MemberAuditChannelID: "audit", AuditReactions: emojis})
+	t.Cleanup(func() { removePendingVerification(guildID, userID) })
+
+	s := &fakeSession{}
+	processEmailVerification(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+		ID:        "submission-" + userID,
+		ChannelID: "dm-" + userID,
+		GuildID:   guildID,
+		Content:   "student@uclan.ac.uk",
+		Author:    &discordgo.User{ID: userID, Username: "student"},
+	}})
+
+	var reactions []string
+	for _, call := range s.calls {
+		if strings.HasPrefix(call, "MessageReactionAdd ") {
+			reactions = append(reactions, call)
+		}
+	}
+	want := []string{
+		"MessageReactionAdd audit message 👀",
+		"MessageReactionAdd audit message pending:123456",
+		"MessageReactionAdd audit message spin:789",
+	}
+	if !slices.Equal(reactions, want) {
+		t.Errorf("reactions = %q, want %q", reactions, want)
+	}
+}
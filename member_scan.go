@@ -0,0 +1,98 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// needsUnverifiedRole reports whether an existing member should be treated
+// as unverified: they hold no roles at all, or lack the verified role when
+// one is given. Members who already have the unverified role are skipped.
+func needsUnverifiedRole(member *discordgo.Member, unverifiedRoleID, verifiedRoleID string) bool {
+	if member.User.Bot || memberHasRole(member, unverifiedRoleID) {
+		return false
+	}
+	if verifiedRoleID != "" {
+		return !memberHasRole(member, verifiedRoleID)
+	}
+	return len(member.Roles) == 0
+}
+
+// applyUnverifiedRole gives the unverified role to members who joined before
+// the bot was set up, since guildMemberAdd only sees new joins.
+func applyUnverifiedRole(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
+	var verifiedRoleID string
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Name == "verified_role" {
+			verifiedRoleID = option.RoleValue(sessionOf(s), guildID).ID
+		}
+	}
+
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	if serverConfig.UnverifiedRoleID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "No unverified role is set for this server. Use /set_unverified_role first.",
+			},
+		})
+		return
+	}
+
+	// Scanning a large server takes a while, so defer the response
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Error("Error acknowledging interaction", "error", err)
+		return
+	}
+
+	members, err := fetchAllMembers(s, guildID)
+	if err != nil {
+		slog.Error("Error fetching members", "guild_id", guildID, "error", err)
+		errorContent := "Error fetching server members: " + err.Error()
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &errorContent,
+		})
+		return
+	}
+
+	throttle := newBulkThrottle(guildBulkPolicy(guildID))
+	applied, failed := 0, 0
+	for _, member := range members {
+		if !needsUnverifiedRole(member, serverConfig.UnverifiedRoleID, verifiedRoleID) {
+			continue
+		}
+		logger := slog.With("guild_id", guildID, "user_id", member.User.ID)
+
+		err := withRetry(func() error { return memberRoleAdd(s, guildID, member.User.ID, serverConfig.UnverifiedRoleID) })
+		if err != nil {
+			logger.Error("Error adding unverified role", "error", err)
+			failed++
+			throttle.done()
+			continue
+		}
+		applied++
+
+		err = sendWelcomeDM(s, guildID, member.User.ID, serverConfig)
+		if err != nil {
+			logger.Error("Error sending DM", "error", err)
+		}
+		throttle.done()
+	}
+
+	content := fmt.Sprintf("Unverified role applied to %d existing member(s)! :white_check_mark:", applied)
+	if failed > 0 {
+		content += fmt.Sprintf(" %d failed (see logs)", failed)
+	}
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+}
@@ -0,0 +1,109 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net/http"
+	"net/url"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var membershipClient = &http.Client{Timeout: 10 * time.Second}
+
+// checkMembership asks the society's membership API whether the email has a
+// current membership. The API is called as GET <url>?email=<email> and should
+// answer 200 with {"member": true|false}, or 404 for unknown emails.
+func checkMembership(client *http.Client, apiURL, token, email string) (bool, error) {
+	parsedURL, err := url.Parse(apiURL)
+	if err != nil {
+		return false, err
+	}
+	query := parsedURL.Query()
+	query.Set("email", email)
+	parsedURL.RawQuery = query.Encode()
+
+	req, err := http.NewRequest(http.MethodGet, parsedURL.String(), nil)
+	if err != nil {
+		return false, err
+	}
+	if token != "" {
+		req.Header.Set("Authorization", "Bearer "+token)
+	}
+
+	resp, err := client.Do(req)
+	if err != nil {
+		return false, err
+	}
+	defer resp.Body.Close()
+
+	switch resp.StatusCode {
+	case http.StatusOK:
+		var body struct {
+			Member bool `json:"member"`
+		}
+		err = json.NewDecoder(resp.Body).Decode(&body)
+		if err != nil {
+			return false, err
+		}
+		return body.Member, nil
+	case http.StatusNotFound:
+		return false, nil
+	default:
+		return false, fmt.Errorf("membership API returned %s", resp.Status)
+	}
+}
+
+func membershipNote(isMember bool, err error) string {
+	switch {
+	case err != nil:
+		return "⚠️ Membership could not be checked"
+	case isMember:
+		return "✅ Has a current society membership"
+	default:
+		return "❌ No current society membership found"
+	}
+}
+
+// lookupMembership checks the applicant's membership before their request
+// is posted, so the result is on the audit message from the start and can't
+// race with a moderator's decision. It returns the note for moderators and
+// whether the applicant should be approved without review.
+func lookupMembership(serverConfig ServerConfig, userID, email string) (string, bool) {
+	isMember, err := checkMembership(membershipClient, serverConfig.MembershipAPIURL, serverConfig.MembershipAPIToken, email)
+	if err != nil {
+		slog.Error("Error checking membership", "user_id", userID, "error", err)
+	}
+	return membershipNote(isMember, err), err == nil && isMember && serverConfig.MembershipMode == "auto"
+}
+
+// autoApproveMember approves a confirmed member without waiting for a
+// moderator and posts the outcome to the audit channel.
+func autoApproveMember(s discordSession, m *discordgo.MessageCreate, guildID string, serverConfig ServerConfig, email, details, note string) {
+	userID := m.Author.ID
+	approveErr := approveMember(s, guildID, userID)
+
+	removePendingVerification(guildID, userID)
+	recordDecision(s, VerificationLogEntry{
+		GuildID: guildID,
+		UserID:  userID,
+		Action:  "approve",
+		Email:   email,
+		Time:    time.Now(),
+	})
+
+	content := fmt.Sprintf("<@%s> has been approved automatically! Welcome to the server! 🎉\n%s", userID, note)
+	if approveErr != nil {
+		content += dmFailedNote(userID, approveErr)
+	}
+	_, err := sendAuditMessage(s, serverConfig, &discordgo.MessageSend{
+		Content:         content,
+		Embeds:          []*discordgo.MessageEmbed{verificationRequestEmbed(m.Author, email, details, memberJoinedAt(s, guildID, userID), false, serverConfig.EmbedLanguage)},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error("Error sending automatic approval to audit channel", "guild_id", guildID, "user_id", userID, "error", err)
+	}
+}
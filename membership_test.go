@@ -0,0 +1,134 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// stubMembershipAPI answers for a single known member
+func stubMembershipAPI(t *testing.T) *httptest.Server {
+	t.Helper()
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Header.Get("Authorization") != "Bearer secret" {
+			w.WriteHeader(http.StatusUnauthorized)
+			return
+		}
+		switch r.URL.Query().Get("email") {
+		case "member@uclan.ac.uk":
+			w.Write([]byte(`{"member": true}`))
+		case "lapsed@uclan.ac.uk":
+			w.Write([]byte(`{"member": false}`))
+		default:
+			w.WriteHeader(http.StatusNotFound)
+		}
+	}))
+	t.Cleanup(server.Close)
+	return server
+}
+
+func TestCheckMembership(t *testing.T) {
+	server := stubMembershipAPI(t)
+
+	tests := []struct {
+		name       string
+		token      string
+		email      string
+		wantMember bool
+		wantErr    bool
+	}{
+		{name: "member", token: "secret", email: "member@uclan.ac.uk", wantMember: true},
+		{name: "lapsed member", token: "secret", email: "lapsed@uclan.ac.uk"},
+		{name: "unknown email", token: "secret", email: "nobody@uclan.ac.uk"},
+		{name: "bad token", token: "wrong", email: "member@uclan.ac.uk", wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			isMember, err := checkMembership(server.Client(), server.URL, tt.token, tt.email)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if isMember != tt.wantMember {
+				t.Errorf("isMember = %v, want %v", isMember, tt.wantMember)
+			}
+		})
+	}
+}
+
+func TestSubmitForReviewMembership(t *testing.T) {
+	const guildID = "100000000000000003"
+	server := stubMembershipAPI(t)
+
+	tests := []struct {
+		name        string
+		userID      string
+		email       string
+		mode        string
+		wantButtons bool
+		wantContent string
+	}{
+		{
+			name:        "auto mode approves members",
+			userID:      "200000000000000021",
+			email:       "member@uclan.ac.uk",
+			mode:        "auto",
+			wantContent: "has been approved automatically",
+		},
+		{
+			name:        "auto mode reviews non-members",
+			userID:      "200000000000000022",
+			email:       "lapsed@uclan.ac.uk",
+			mode:        "auto",
+			wantButtons: true,
+			wantContent: "No current society membership found",
+		},
+		{
+			name:        "flag mode reviews members",
+			userID:      "200000000000000023",
+			email:       "member@uclan.ac.uk",
+			mode:        "flag",
+			wantButtons: true,
+			wantContent: "Has a current society membership",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConfig := ServerConfig{
+				MemberAuditChannelID: "audit",
+				MembershipAPIURL:     server.URL,
+				MembershipAPIToken:   "secret",
+				MembershipMode:       tt.mode,
+			}
+			useServerConfig(t, guildID, serverConfig)
+
+			s := &fakeSession{}
+			submitForReview(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+				ID:        "submission-" + tt.userID,
+				ChannelID: "dm-" + tt.userID,
+				Author:    &discordgo.User{ID: tt.userID, Username: "student"},
+			}}, guildID, serverConfig, tt.email, nil)
+			removePendingVerification(guildID, tt.userID)
+
+			var audit *discordgo.MessageSend
+			for _, message := range s.sent {
+				if message.ChannelID == "audit" {
+					audit = message.Data
+				}
+			}
+			if audit == nil {
+				t.Fatalf("no audit message sent (calls %q)", s.calls)
+			}
+			if !strings.Contains(audit.Content, tt.wantContent) {
+				t.Errorf("audit message = %q, want it to contain %q", audit.Content, tt.wantContent)
+			}
+			if hasButtons := len(audit.Components) > 0; hasButtons != tt.wantButtons {
+				t.Errorf("audit message has buttons = %v, want %v", hasButtons, tt.wantButtons)
+			}
+		})
+	}
+}
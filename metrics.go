@@ -0,0 +1,74 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+)
+
+// Counters exposed on /metrics, labelled by guild
+var verificationMetrics = []struct {
+	name string
+	help string
+}{
+	{"verifications_requested_total", "Verification requests received."},
+	{"verifications_approved_total", "Verification requests approved."},
+	{"verifications_denied_total", "Verification requests denied."},
+	{"verifications_rate_limited_total", "Verification requests rejected or queued by the rate limit."},
+}
+
+var (
+	metricCounts = make(map[string]map[string]int64)
+	metricsLock  sync.Mutex
+)
+
+func incrementMetric(name, guildID string) {
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+
+	if metricCounts[name] == nil {
+		metricCounts[name] = make(map[string]int64)
+	}
+	metricCounts[name][guildID]++
+}
+
+// renderMetrics writes the counters in the Prometheus text format.
+func renderMetrics() string {
+	metricsLock.Lock()
+	defer metricsLock.Unlock()
+
+	var sb strings.Builder
+	for _, metric := range verificationMetrics {
+		sb.WriteString(fmt.Sprintf("# HELP %s %s\n# TYPE %s counter\n", metric.name, metric.help, metric.name))
+
+		guildIDs := make([]string, 0, len(metricCounts[metric.name]))
+		for guildID := range metricCounts[metric.name] {
+			guildIDs = append(guildIDs, guildID)
+		}
+		sort.Strings(guildIDs)
+		for _, guildID := range guildIDs {
+			sb.WriteString(fmt.Sprintf("%s{guild_id=%q} %d\n", metric.name, guildID, metricCounts[metric.name][guildID]))
+		}
+	}
+	return sb.String()
+}
+
+func startMetricsServer(addr string) *http.Server {
+	mux := http.NewServeMux()
+	mux.HandleFunc("/metrics", func(w http.ResponseWriter, r *http.Request) {
+		w.Header().Set("Content-Type", "text/plain; version=0.0.4")
+		fmt.Fprint(w, renderMetrics())
+	})
+
+	server := &http.Server{Addr: addr, Handler: mux}
+	go func() {
+		err := server.ListenAndServe()
+		if err != nil && err != http.ErrServerClosed {
+			slog.Error("Metrics server stopped", "error", err)
+		}
+	}()
+	return server
+}
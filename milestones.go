@@ -0,0 +1,43 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+)
+
+// crossedMilestone returns the highest multiple of interval that the count
+// passed when going from before to after.
+func crossedMilestone(before, after, interval int) (int, bool) {
+	if interval <= 0 || after <= before {
+		return 0, false
+	}
+	milestone := after - after%interval
+	if milestone <= before || milestone == 0 {
+		return 0, false
+	}
+	return milestone, true
+}
+
+func milestoneMessage(count int) string {
+	return fmt.Sprintf("We now have %d verified members! 🎉", count)
+}
+
+func announceMilestone(s discordSession, guildID string, before, after int) {
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	if serverConfig.MilestoneChannelID == "" {
+		return
+	}
+
+	milestone, crossed := crossedMilestone(before, after, serverConfig.MilestoneInterval)
+	if !crossed {
+		return
+	}
+
+	_, err := s.ChannelMessageSend(serverConfig.MilestoneChannelID, milestoneMessage(milestone))
+	if err != nil {
+		slog.Error("Error sending milestone announcement", "error", err)
+	}
+}
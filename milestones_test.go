@@ -0,0 +1,31 @@
+package main
+
+import "testing"
+
+func TestCrossedMilestone(t *testing.T) {
+	tests := []struct {
+		name          string
+		before, after int
+		interval      int
+		want          int
+		wantOK        bool
+	}{
+		{name: "disabled", before: 99, after: 100, interval: 0},
+		{name: "reached exactly", before: 99, after: 100, interval: 100, want: 100, wantOK: true},
+		{name: "passed in one jump", before: 95, after: 105, interval: 100, want: 100, wantOK: true},
+		{name: "highest of several", before: 5, after: 35, interval: 10, want: 30, wantOK: true},
+		{name: "not reached", before: 101, after: 150, interval: 100},
+		{name: "already at milestone", before: 100, after: 100, interval: 100},
+		{name: "count went down", before: 101, after: 99, interval: 100},
+		{name: "from zero below interval", before: 0, after: 5, interval: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, ok := crossedMilestone(tt.before, tt.after, tt.interval)
+			if got != tt.want || ok != tt.wantOK {
+				t.Errorf("crossedMilestone(%d, %d, %d) = %d, %v, want %d, %v", tt.before, tt.after, tt.interval, got, ok, tt.want, tt.wantOK)
+			}
+		})
+	}
+}
@@ -0,0 +1,47 @@
+package main
+
+import (
+	"fmt"
+	"sync"
+	"time"
+)
+
+var (
+	lastModActions    = make(map[string]time.Time)
+	lastModActionLock sync.Mutex
+)
+
+// modActionCooldownRemaining reports how much longer moderators should wait
+// before acting on the target again, or zero if they're clear to act.
+func modActionCooldownRemaining(guildID, targetID string, cooldown time.Duration, now time.Time) time.Duration {
+	if cooldown <= 0 {
+		return 0
+	}
+
+	lastModActionLock.Lock()
+	defer lastModActionLock.Unlock()
+
+	last, exists := lastModActions[guildID+":"+targetID]
+	if !exists || now.Sub(last) >= cooldown {
+		return 0
+	}
+	return cooldown - now.Sub(last)
+}
+
+// modActionCooldownWarning returns a note to show alongside an action taken
+// within the cooldown of the last one on the same target, or "" if there
+// was no recent action. The action still goes ahead.
+func modActionCooldownWarning(guildID, targetID string, cooldown time.Duration, now time.Time) string {
+	remaining := modActionCooldownRemaining(guildID, targetID, cooldown, now)
+	if remaining <= 0 {
+		return ""
+	}
+	ago := cooldown - remaining
+	return fmt.Sprintf("\n⚠️ <@%s> was already acted on %s ago. Please check this wasn't a mistake.", targetID, pluralise(int(ago.Round(time.Second)/time.Second), "second"))
+}
+
+func recordModAction(guildID, targetID string, now time.Time) {
+	lastModActionLock.Lock()
+	lastModActions[guildID+":"+targetID] = now
+	lastModActionLock.Unlock()
+}
@@ -0,0 +1,75 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestModActionCooldownWarning(t *testing.T) {
+	const guildID = "100000000000000013"
+	now := time.Date(2026, 9, 21, 12, 0, 0, 0, time.UTC)
+	recordModAction(guildID, "200000000000000081", now)
+
+	tests := []struct {
+		name     string
+		targetID string
+		cooldown time.Duration
+		at       time.Time
+		want     string
+	}{
+		{name: "within the window", targetID: "200000000000000081", cooldown: time.Minute, at: now.Add(5 * time.Second), want: "acted on 5 seconds ago"},
+		{name: "after the window", targetID: "200000000000000081", cooldown: time.Minute, at: now.Add(time.Minute)},
+		{name: "cooldown disabled", targetID: "200000000000000081", at: now.Add(5 * time.Second)},
+		{name: "different target", targetID: "200000000000000082", cooldown: time.Minute, at: now.Add(5 * time.Second)},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := modActionCooldownWarning(guildID, tt.targetID, tt.cooldown, tt.at)
+			if tt.want == "" && got != "" {
+				t.Errorf("modActionCooldownWarning() = %q, want no warning", got)
+			}
+			if !strings.Contains(got, tt.want) {
+				t.Errorf("modActionCooldownWarning() = %q, want it to contain %q", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestRepeatDecisionWarns(t *testing.T) {
+	const (
+		guildID = "100000000000000014"
+		userID  = "200000000000000083"
+	)
+	useServerConfig(t, guildID, ServerConfig{MemberAuditChannelID: "audit", ModActionCooldown: time.Minute})
+
+	approve := func(messageID string) *fakeSession {
+		s := &fakeSession{}
+		handleButton(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+			Type:      discordgo.InteractionMessageComponent,
+			GuildID:   guildID,
+			ChannelID: "audit",
+			Message:   &discordgo.Message{ID: messageID},
+			Member:    &discordgo.Member{User: &discordgo.User{ID: "300000000000000005"}},
+			Data:      discordgo.MessageComponentInteractionData{CustomID: encodeCustomID("approve", userID)},
+		}})
+		return s
+	}
+
+	s := approve("audit-first")
+	if len(s.edits) != 1 || strings.Contains(*s.edits[0].Content, "already acted on") {
+		t.Fatalf("first decision edits = %v, want one without a warning", s.edits)
+	}
+
+	// The second decision still goes through, with a warning
+	s = approve("audit-second")
+	if len(s.edits) != 1 {
+		t.Fatalf("got %d audit message edits, want 1 (calls %q)", len(s.edits), s.calls)
+	}
+	if !strings.Contains(*s.edits[0].Content, "has been approved") || !strings.Contains(*s.edits[0].Content, "already acted on") {
+		t.Errorf("audit message = %q, want the approval with a warning", *s.edits[0].Content)
+	}
+}
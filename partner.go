@@ -0,0 +1,92 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// partnerButton lets a new member verify using a role they already hold in
+// the partner guild. The customID carries the guild they're joining.
+func partnerButton(guildID, partnerName string) discordgo.ActionsRow {
+	return discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    fmt.Sprintf("I'm already verified in %s", partnerName),
+				Style:    discordgo.PrimaryButton,
+				CustomID: encodeCustomID("partner", guildID),
+			},
+		},
+	}
+}
+
+// hasPartnerRole reports whether the member lookup in the partner guild found
+// them holding the partner role. A failed lookup usually means they aren't a
+// member there.
+func hasPartnerRole(member *discordgo.Member, err error, roleID string) bool {
+	if err != nil || member == nil {
+		return false
+	}
+	return memberHasRole(member, roleID)
+}
+
+func partnerVerified(s discordSession, serverConfig ServerConfig, userID string) bool {
+	member, err := s.GuildMember(serverConfig.PartnerGuildID, userID)
+	return hasPartnerRole(member, err, serverConfig.PartnerRoleID)
+}
+
+func handlePartnerVerification(s discordSession, i *discordgo.InteractionCreate, guildID string) {
+	userID := interactionUserID(i)
+
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	var responseContent string
+	switch {
+	case serverConfig.PartnerGuildID == "":
+		responseContent = "Partner verification is no longer enabled for this server. Please verify with your email instead."
+	case !partnerVerified(s, serverConfig, userID):
+		responseContent = "We couldn't find the verified role on your account in our partner server. Please verify with your email instead."
+	default:
+		// Remove unverified role
+		if serverConfig.UnverifiedRoleID != "" {
+			err := withRetry(func() error { return memberRoleRemove(s, guildID, userID, serverConfig.UnverifiedRoleID) })
+			if err != nil {
+				slog.Error("Error removing unverified role", "error", err)
+				responseContent = "You're verified in our partner server but something went wrong updating your roles. Please contact a moderator."
+				break
+			}
+		}
+		grantVerifiedRole(s, guildID, userID)
+
+		slog.Info("User verified via partner guild", "user_id", userID, "partner_guild_id", serverConfig.PartnerGuildID)
+		recordDecision(s, VerificationLogEntry{
+			GuildID: guildID,
+			UserID:  userID,
+			Action:  "approve",
+			Time:    time.Now(),
+		})
+		responseContent = "You're verified and now have access to the server. Welcome! 🎉"
+		welcomeMember(s, guildID, userID)
+
+		// The button has done its job
+		_, err := s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+			Channel:    i.ChannelID,
+			ID:         i.Message.ID,
+			Components: &[]discordgo.MessageComponent{},
+		})
+		if err != nil {
+			slog.Error("Error removing partner button", "error", err)
+		}
+	}
+
+	_, err := s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &responseContent,
+	})
+	if err != nil {
+		slog.Error("Error editing interaction response", "error", err)
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestHandlePartnerVerification(t *testing.T) {
+	const (
+		guildID        = "100000000000000031"
+		partnerGuildID = "100000000000000032"
+	)
+	partnerConfig := ServerConfig{
+		UnverifiedRoleID: "unverified",
+		VerifiedRoleID:   "verified",
+		PartnerGuildID:   partnerGuildID,
+		PartnerRoleID:    "partner-verified",
+	}
+
+	tests := []struct {
+		name         string
+		userID       string
+		serverConfig ServerConfig
+		partnerRoles []string
+		wantVerified bool
+		wantResponse string
+	}{
+		{
+			name:         "holds the partner role",
+			userID:       "200000000000000112",
+			serverConfig: partnerConfig,
+			partnerRoles: []string{"partner-verified"},
+			wantVerified: true,
+			wantResponse: "You're verified and now have access to the server. Welcome! 🎉",
+		},
+		{
+			name:         "missing the partner role",
+			userID:       "200000000000000113",
+			serverConfig: partnerConfig,
+			partnerRoles: []string{"someone-else"},
+			wantResponse: "We couldn't find the verified role on your account in our partner server. Please verify with your email instead.",
+		},
+		{
+			name:         "partner verification turned off",
+			userID:       "200000000000000114",
+			serverConfig: ServerConfig{UnverifiedRoleID: "unverified"},
+			wantResponse: "Partner verification is no longer enabled for this server. Please verify with your email instead.",
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			useServerConfig(t, guildID, tt.serverConfig)
+
+			s := &fakeSession{memberRoles: map[string][]string{partnerGuildID: tt.partnerRoles}}
+			handlePartnerVerification(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+				Type:      discordgo.InteractionMessageComponent,
+				ChannelID: "welcome",
+				Message:   &discordgo.Message{ID: "partner-button"},
+				Member:    &discordgo.Member{User: &discordgo.User{ID: tt.userID}},
+				Data:      discordgo.MessageComponentInteractionData{CustomID: encodeCustomID("partner", guildID)},
+			}}, guildID)
+
+			if !s.called("InteractionResponseEdit " + tt.wantResponse) {
+				t.Errorf("missing response %q (calls %q)", tt.wantResponse, s.calls)
+			}
+			removed := s.called("GuildMemberRoleRemove " + guildID + " " + tt.userID + " unverified")
+			granted := s.called("GuildMemberRoleAdd " + guildID + " " + tt.userID + " verified")
+			if removed != tt.wantVerified || granted != tt.wantVerified {
+				t.Errorf("roles updated = %v/%v, want %v (calls %q)", removed, granted, tt.wantVerified, s.calls)
+			}
+			if got := s.called("ChannelMessageEdit welcome partner-button"); got != tt.wantVerified {
+				t.Errorf("partner button removed = %v, want %v", got, tt.wantVerified)
+			}
+		})
+	}
+}
@@ -0,0 +1,229 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type pendingVerification struct {
+	GuildID     string
+	UserID      string
+	Email       string
+	SubmittedAt time.Time
+}
+
+var (
+	pendingVerifications = make(map[string]pendingVerification)
+	pendingLock          sync.Mutex
+)
+
+// addPendingVerification records a request awaiting review and returns how
+// many other requests in the same guild are already ahead of it.
+func addPendingVerification(guildID, userID, email string) int {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+
+	ahead := 0
+	for key, pending := range pendingVerifications {
+		if pending.GuildID == guildID && key != guildID+":"+userID {
+			ahead++
+		}
+	}
+
+	pendingVerifications[guildID+":"+userID] = pendingVerification{
+		GuildID:     guildID,
+		UserID:      userID,
+		Email:       email,
+		SubmittedAt: time.Now(),
+	}
+	savePendingVerifications()
+	return ahead
+}
+
+// hasPendingVerification reports whether the user has a request waiting for
+// a moderator in the guild.
+func hasPendingVerification(guildID, userID string) bool {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+
+	_, exists := pendingVerifications[guildID+":"+userID]
+	return exists
+}
+
+func removePendingVerification(guildID, userID string) (pendingVerification, bool) {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+
+	key := guildID + ":" + userID
+	pending, exists := pendingVerifications[key]
+	delete(pendingVerifications, key)
+	if exists {
+		savePendingVerifications()
+	}
+	return pending, exists
+}
+
+func loadPendingVerifications() error {
+	data, err := os.ReadFile("./data/pending.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	entries := make(map[string]pendingVerification)
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return err
+	}
+
+	pendingLock.Lock()
+	pendingVerifications = entries
+	pendingLock.Unlock()
+	return nil
+}
+
+// savePendingVerifications writes the requests awaiting review to disk so
+// they survive a restart. The caller must hold pendingLock.
+func savePendingVerifications() {
+	data, err := json.MarshalIndent(pendingVerifications, "", "  ")
+	if err == nil {
+		err = os.MkdirAll("./data", os.ModePerm)
+	}
+	if err == nil {
+		err = writeFileAtomic("./data/pending.json", data, 0644)
+	}
+	if err != nil {
+		slog.Error("Error saving pending verifications", "error", err)
+	}
+}
+
+// Requests listed by /pending_verifications before the rest are summarised
+const maxPendingListed = 50
+
+func listPendingVerifications(s discordSession, i *discordgo.InteractionCreate) {
+	if !isModerator(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "You need the Timeout Members permission to use this command.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	pending := guildPendingVerifications(i.GuildID)
+	sort.Slice(pending, func(a, b int) bool {
+		return pending[a].SubmittedAt.Before(pending[b].SubmittedAt)
+	})
+
+	description := "There are no verification requests waiting for review."
+	if len(pending) > 0 {
+		var b strings.Builder
+		now := time.Now()
+		for n, request := range pending {
+			if n == maxPendingListed {
+				fmt.Fprintf(&b, "…and %d more", len(pending)-n)
+				break
+			}
+			fmt.Fprintf(&b, "<@%s> - %s - waiting %s\n", request.UserID, truncateText(request.Email, 100), formatWaiting(now.Sub(request.SubmittedAt)))
+		}
+		description = b.String()
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Embeds: []*discordgo.MessageEmbed{
+				{
+					Title:       fmt.Sprintf("Pending verifications (%d)", len(pending)),
+					Description: description,
+				},
+			},
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// estimateWait assumes each request ahead in the queue takes about as long as
+// recent requests have taken to be reviewed.
+func estimateWait(pendingAhead int, averageTurnaround time.Duration) time.Duration {
+	return averageTurnaround * time.Duration(pendingAhead+1)
+}
+
+func formatWait(d time.Duration) string {
+	minutes := int(d.Round(time.Minute) / time.Minute)
+	switch {
+	case minutes < 1:
+		return "less than a minute"
+	case minutes == 1:
+		return "about 1 minute"
+	case minutes < 60:
+		return fmt.Sprintf("about %d minutes", minutes)
+	case minutes < 120:
+		return "about 1 hour"
+	default:
+		return fmt.Sprintf("about %d hours", minutes/60)
+	}
+}
+
+// Sent once a request reaches the moderators, unless the guild sets its own
+const defaultConfirmationMessage = "Thanks! A moderator will review your verification request shortly."
+
+func waitingMessage(confirmation string, pendingAhead int, averageTurnaround time.Duration) string {
+	content := confirmation
+	if content == "" {
+		content = defaultConfirmationMessage
+	}
+	if pendingAhead == 1 {
+		content += " There is 1 request ahead of yours."
+	} else if pendingAhead > 1 {
+		content += fmt.Sprintf(" There are %d requests ahead of yours.", pendingAhead)
+	}
+	if averageTurnaround > 0 {
+		content += fmt.Sprintf(" The estimated wait is %s.", formatWait(estimateWait(pendingAhead, averageTurnaround)))
+	}
+	return content
+}
+
+func setConfirmationMessage(s discordSession, i *discordgo.InteractionCreate) {
+	var message string
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		message = strings.TrimSpace(options[0].StringValue())
+	}
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.ConfirmationMessage = message
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Confirmation message set successfully! :white_check_mark: Members will see:\n" + waitingMessage(message, 0, 0),
+		},
+	})
+}
@@ -0,0 +1,51 @@
+package main
+
+import (
+	"testing"
+	"time"
+)
+
+func TestEstimateWait(t *testing.T) {
+	tests := []struct {
+		name         string
+		pendingAhead int
+		turnaround   time.Duration
+		want         time.Duration
+	}{
+		{name: "no history", pendingAhead: 3, turnaround: 0, want: 0},
+		{name: "first in the queue", pendingAhead: 0, turnaround: 10 * time.Minute, want: 10 * time.Minute},
+		{name: "others ahead", pendingAhead: 2, turnaround: 10 * time.Minute, want: 30 * time.Minute},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := estimateWait(tt.pendingAhead, tt.turnaround)
+			if got != tt.want {
+				t.Errorf("estimateWait(%d, %v) = %v, want %v", tt.pendingAhead, tt.turnaround, got, tt.want)
+			}
+		})
+	}
+}
+
+func TestFormatWait(t *testing.T) {
+	tests := []struct {
+		wait time.Duration
+		want string
+	}{
+		{wait: 0, want: "less than a minute"},
+		{wait: 29 * time.Second, want: "less than a minute"},
+		{wait: 30 * time.Second, want: "about 1 minute"},
+		{wait: 45 * time.Minute, want: "about 45 minutes"},
+		{wait: 90 * time.Minute, want: "about 1 hour"},
+		{wait: 5*time.Hour + 20*time.Minute, want: "about 5 hours"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.wait.String(), func(t *testing.T) {
+			got := formatWait(tt.wait)
+			if got != tt.want {
+				t.Errorf("formatWait(%v) = %q, want %q", tt.wait, got, tt.want)
+			}
+		})
+	}
+}
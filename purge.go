@@ -0,0 +1,117 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Discord refuses to bulk delete messages older than two weeks
+const bulkDeleteMaxAge = 14 * 24 * time.Hour
+
+var (
+	manageMessagesPermission int64 = discordgo.PermissionManageMessages
+	purgeMinCount                  = 1.0
+)
+
+// splitByAge separates messages that can be bulk deleted from those that
+// have to be deleted one at a time
+func splitByAge(messages []*discordgo.Message, now time.Time) (recent []string, old []string) {
+	for _, message := range messages {
+		sent, err := discordgo.SnowflakeTimestamp(message.ID)
+		if err == nil && now.Sub(sent) < bulkDeleteMaxAge {
+			recent = append(recent, message.ID)
+		} else {
+			old = append(old, message.ID)
+		}
+	}
+	return recent, old
+}
+
+func purgeMessages(s discordSession, i *discordgo.InteractionCreate) {
+	if i.Member == nil || i.Member.Permissions&(discordgo.PermissionManageMessages|discordgo.PermissionAdministrator) == 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "You need the Manage Messages permission to use this command.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	var count int
+	var target *discordgo.User
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "count":
+			count = int(option.IntValue())
+		case "user":
+			target = option.UserValue(sessionOf(s))
+		}
+	}
+
+	// Deleting can take a while once older messages are involved
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Error acknowledging interaction", "error", err)
+		return
+	}
+
+	respond := func(content string) {
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &content,
+		})
+	}
+
+	// Only the last 100 messages are considered, filtered by user if one
+	// was given
+	history, err := s.ChannelMessages(i.ChannelID, 100, "", "", "")
+	if err != nil {
+		slog.Error("Error fetching messages", "channel_id", i.ChannelID, "error", err)
+		respond("Error fetching messages: " + err.Error())
+		return
+	}
+
+	var messages []*discordgo.Message
+	for _, message := range history {
+		if len(messages) == count {
+			break
+		}
+		if target != nil && (message.Author == nil || message.Author.ID != target.ID) {
+			continue
+		}
+		messages = append(messages, message)
+	}
+
+	recent, old := splitByAge(messages, time.Now())
+	deleted := 0
+
+	err = s.ChannelMessagesBulkDelete(i.ChannelID, recent)
+	if err != nil {
+		slog.Error("Error bulk deleting messages", "channel_id", i.ChannelID, "error", err)
+	} else {
+		deleted += len(recent)
+	}
+
+	throttle := newBulkThrottle(guildBulkPolicy(i.GuildID))
+	for _, messageID := range old {
+		err := s.ChannelMessageDelete(i.ChannelID, messageID)
+		if err != nil {
+			slog.Error("Error deleting message", "channel_id", i.ChannelID, "message_id", messageID, "error", err)
+		} else {
+			deleted++
+		}
+		throttle.done()
+	}
+
+	slog.Info("Purged messages", "guild_id", i.GuildID, "channel_id", i.ChannelID, "moderator_id", interactionUserID(i), "deleted", deleted)
+	respond(fmt.Sprintf("Deleted %d of %d message(s) :white_check_mark:", deleted, len(messages)))
+}
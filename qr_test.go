@@ -0,0 +1,201 @@
+package main
+
+import (
+	"bytes"
+	"image/png"
+	"slices"
+	"strings"
+	"testing"
+)
+
+func TestReedSolomonRemainder(t *testing.T) {
+	// The 1-M "HELLO WORLD" example from the specification
+	data := []byte{32, 91, 11, 120, 209, 114, 220, 77, 67, 64, 236, 17, 236, 17, 236, 17}
+	want := []byte{196, 35, 39, 119, 235, 215, 231, 226, 93, 23}
+
+	if got := reedSolomonRemainder(data, reedSolomonDivisor(10)); !bytes.Equal(got, want) {
+		t.Errorf("error correction = %v, want %v", got, want)
+	}
+}
+
+func TestQRFormatAndVersionBits(t *testing.T) {
+	formats := map[int]int{
+		0: 0b101010000010010,
+		3: 0b101101101001011,
+		5: 0b100000011001110,
+		7: 0b100101010100000,
+	}
+	for mask, want := range formats {
+		if got := qrFormatBits(mask); got != want {
+			t.Errorf("format bits for mask %d = %015b, want %015b", mask, got, want)
+		}
+	}
+
+	versions := map[int]int{
+		7:  0b000111110010010100,
+		10: 0b001010010011010011,
+	}
+	for version, want := range versions {
+		if got := qrVersionBits(version); got != want {
+			t.Errorf("version bits for %d = %018b, want %018b", version, got, want)
+		}
+	}
+}
+
+// readQR reads the text back out of a code, undoing each encoding step
+func readQR(t *testing.T, q *qrCode) string {
+	t.Helper()
+	version := (q.size - 17) / 4
+
+	var format int
+	for i := 14; i >= 9; i-- {
+		format = format<<1 | boolBit(q.modules[8][14-i])
+	}
+	format = format<<1 | boolBit(q.modules[8][7])
+	format = format<<1 | boolBit(q.modules[8][8])
+	format = format<<1 | boolBit(q.modules[7][8])
+	for i := 5; i >= 0; i-- {
+		format = format<<1 | boolBit(q.modules[i][8])
+	}
+	mask := slices.IndexFunc([]int{0, 1, 2, 3, 4, 5, 6, 7}, func(m int) bool { return qrFormatBits(m) == format })
+	if mask < 0 {
+		t.Fatalf("unreadable format bits %015b", format)
+	}
+
+	var bits qrBits
+	for right := q.size - 1; right >= 1; right -= 2 {
+		if right == 6 {
+			right = 5
+		}
+		for vert := 0; vert < q.size; vert++ {
+			for j := 0; j < 2; j++ {
+				x, y := right-j, vert
+				if (right+1)&2 == 0 {
+					y = q.size - 1 - vert
+				}
+				if !q.reserved[y][x] {
+					bits = append(bits, q.modules[y][x] != qrMasked(mask, x, y))
+				}
+			}
+		}
+	}
+	codewords := bits.bytes()
+
+	v := qrVersions[version]
+	blocks := make([][]byte, len(v.blocks))
+	idx := 0
+	for col := 0; col < v.blocks[len(v.blocks)-1]; col++ {
+		for b, n := range v.blocks {
+			if col < n {
+				blocks[b] = append(blocks[b], codewords[idx])
+				idx++
+			}
+		}
+	}
+	var data qrBits
+	for _, block := range blocks {
+		for _, b := range block {
+			data.append(int(b), 8)
+		}
+	}
+
+	read := func(n int) int {
+		value := 0
+		for _, bit := range data[:n] {
+			value = value<<1 | boolBit(bit)
+		}
+		data = data[n:]
+		return value
+	}
+	if mode := read(4); mode != 0b0100 {
+		t.Fatalf("mode = %04b, want byte mode", mode)
+	}
+	countBits := 8
+	if version >= 10 {
+		countBits = 16
+	}
+	length := read(countBits)
+	text := make([]byte, length)
+	for i := range text {
+		text[i] = byte(read(8))
+	}
+	return string(text)
+}
+
+func boolBit(b bool) int {
+	if b {
+		return 1
+	}
+	return 0
+}
+
+func TestEncodeQR(t *testing.T) {
+	tests := []struct {
+		name        string
+		text        string
+		wantVersion int
+	}{
+		{name: "short", text: "hello", wantVersion: 1},
+		{name: "version information", text: strings.Repeat("a", 120), wantVersion: 7},
+		{name: "uneven blocks", text: strings.Repeat("b", 150), wantVersion: 8},
+		{name: "largest", text: strings.Repeat("c", 213), wantVersion: 10},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			q, err := encodeQR(tt.text)
+			if err != nil {
+				t.Fatal(err)
+			}
+			if want := 17 + 4*tt.wantVersion; q.size != want {
+				t.Fatalf("size = %d, want %d", q.size, want)
+			}
+
+			// Finder pattern corners and the timing pattern
+			for _, corner := range [][2]int{{0, 0}, {q.size - 1, 0}, {0, q.size - 1}} {
+				if !q.modules[corner[1]][corner[0]] {
+					t.Errorf("finder corner %v is light", corner)
+				}
+			}
+			for i := 8; i < q.size-8; i++ {
+				if q.modules[6][i] != (i%2 == 0) || q.modules[i][6] != (i%2 == 0) {
+					t.Fatalf("timing pattern broken at %d", i)
+				}
+			}
+
+			if got := readQR(t, q); got != tt.text {
+				t.Errorf("read back %q, want %q", got, tt.text)
+			}
+		})
+	}
+
+	if _, err := encodeQR(strings.Repeat("d", 214)); err != errQRTooLong {
+		t.Errorf("err = %v, want errQRTooLong", err)
+	}
+}
+
+func TestQRPNG(t *testing.T) {
+	q, err := encodeQR("hello")
+	if err != nil {
+		t.Fatal(err)
+	}
+	data, err := q.png(4)
+	if err != nil {
+		t.Fatal(err)
+	}
+
+	img, err := png.Decode(bytes.NewReader(data))
+	if err != nil {
+		t.Fatal(err)
+	}
+	// 21 modules plus a 4 module quiet zone each side
+	if width := img.Bounds().Dx(); width != (21+8)*4 {
+		t.Errorf("width = %d, want %d", width, (21+8)*4)
+	}
+	if r, _, _, _ := img.At(0, 0).RGBA(); r != 0xffff {
+		t.Errorf("quiet zone isn't white")
+	}
+	if r, _, _, _ := img.At(16, 16).RGBA(); r != 0 {
+		t.Errorf("finder corner isn't black")
+	}
+}
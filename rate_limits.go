@@ -0,0 +1,182 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"os"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+const (
+	// How often cooldowns are flushed to disk between shutdowns
+	rateLimitSaveInterval = time.Minute
+	// How often expired cooldowns are dropped from memory
+	rateLimitSweepInterval = 5 * time.Minute
+	// Longest cooldown /set_rate_limit accepts, one day
+	maxRateLimitMinutes = 1440
+)
+
+var minRateLimitMinutes = 1.0
+
+// Sent to rate limited members unless the guild sets its own
+const defaultCooldownMessage = "Please wait {remaining} before sending another verification request."
+
+func rateLimitKey(guildID, userID string) string {
+	return guildID + ":" + userID
+}
+
+// pruneRateLimits drops cooldowns that have already run out under their
+// guild's current settings
+func pruneRateLimits(entries map[string]time.Time, servers map[string]ServerConfig, now time.Time) {
+	for key, last := range entries {
+		guildID, _, _ := strings.Cut(key, ":")
+		serverConfig := servers[guildID]
+		if !serverConfig.RateLimitEnabled || now.Sub(last) >= serverConfig.RateLimitDuration {
+			delete(entries, key)
+		}
+	}
+}
+
+func loadRateLimits() error {
+	data, err := os.ReadFile("./data/ratelimits.json")
+	if err != nil {
+		if os.IsNotExist(err) {
+			return nil
+		}
+		return err
+	}
+
+	entries := make(map[string]time.Time)
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return err
+	}
+
+	configMutex.RLock()
+	pruneRateLimits(entries, config.Servers, time.Now())
+	configMutex.RUnlock()
+
+	rateLimitLock.Lock()
+	rateLimitMap = entries
+	rateLimitLock.Unlock()
+	return nil
+}
+
+func saveRateLimits() error {
+	rateLimitLock.Lock()
+	data, err := json.MarshalIndent(rateLimitMap, "", "  ")
+	rateLimitLock.Unlock()
+	if err != nil {
+		return err
+	}
+
+	err = os.MkdirAll("./data", os.ModePerm)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic("./data/ratelimits.json", data, 0644)
+}
+
+func sweepRateLimits() {
+	configMutex.RLock()
+	defer configMutex.RUnlock()
+
+	rateLimitLock.Lock()
+	before := len(rateLimitMap)
+	pruneRateLimits(rateLimitMap, config.Servers, time.Now())
+	removed := before - len(rateLimitMap)
+	rateLimitLock.Unlock()
+
+	if removed > 0 {
+		slog.Debug("Swept expired rate limits", "removed", removed)
+	}
+}
+
+func runRateLimitSweeps() {
+	ticker := time.NewTicker(rateLimitSweepInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		sweepRateLimits()
+	}
+}
+
+func runRateLimitSaves() {
+	ticker := time.NewTicker(rateLimitSaveInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := saveRateLimits()
+		if err != nil {
+			slog.Error("Error saving rate limits", "error", err)
+		}
+	}
+}
+
+func pluralise(count int, unit string) string {
+	if count == 1 {
+		return "1 " + unit
+	}
+	return fmt.Sprintf("%d %ss", count, unit)
+}
+
+// formatRemaining renders a cooldown as minutes and seconds, rounding up so
+// members are never told to wait less than they have to
+func formatRemaining(remaining time.Duration) string {
+	seconds := int((remaining + time.Second - 1) / time.Second)
+	if seconds < 1 {
+		seconds = 1
+	}
+	minutes, seconds := seconds/60, seconds%60
+	switch {
+	case minutes == 0:
+		return pluralise(seconds, "second")
+	case seconds == 0:
+		return pluralise(minutes, "minute")
+	default:
+		return pluralise(minutes, "minute") + " " + pluralise(seconds, "second")
+	}
+}
+
+func cooldownMessage(template string, remaining time.Duration) string {
+	if template == "" {
+		template = defaultCooldownMessage
+	}
+	return strings.ReplaceAll(template, "{remaining}", formatRemaining(remaining))
+}
+
+func setCooldownMessage(s discordSession, i *discordgo.InteractionCreate) {
+	var message string
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		message = strings.TrimSpace(options[0].StringValue())
+	}
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.CooldownMessage = message
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Cooldown message set successfully! :white_check_mark: Members will see:\n" + cooldownMessage(message, 4*time.Minute+30*time.Second),
+		},
+	})
+}
@@ -0,0 +1,91 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestPruneRateLimits(t *testing.T) {
+	now := time.Date(2024, 1, 1, 12, 0, 0, 0, time.UTC)
+	servers := map[string]ServerConfig{
+		"limited":  {RateLimitEnabled: true, RateLimitDuration: 10 * time.Minute},
+		"disabled": {RateLimitEnabled: false, RateLimitDuration: 10 * time.Minute},
+	}
+	entries := map[string]time.Time{
+		rateLimitKey("limited", "recent"):  now.Add(-time.Minute),
+		rateLimitKey("limited", "expired"): now.Add(-10 * time.Minute),
+		rateLimitKey("disabled", "recent"): now.Add(-time.Minute),
+		rateLimitKey("removed", "recent"):  now.Add(-time.Minute),
+	}
+
+	pruneRateLimits(entries, servers, now)
+
+	want := map[string]time.Time{
+		rateLimitKey("limited", "recent"): now.Add(-time.Minute),
+	}
+	if !reflect.DeepEqual(entries, want) {
+		t.Errorf("after pruning = %v, want %v", entries, want)
+	}
+}
+
+func TestRateLimitPerGuild(t *testing.T) {
+	const (
+		limitedGuildID = "100000000000000019"
+		otherGuildID   = "100000000000000020"
+		userID         = "200000000000000100"
+	)
+	rateLimited := ServerConfig{
+		RateLimitEnabled:  true,
+		RateLimitDuration: time.Hour,
+		CooldownMessage:   "Slow down, try again in {remaining}.",
+	}
+	limitedConfig := rateLimited
+	limitedConfig.MemberAuditChannelID = "audit-limited"
+	otherConfig := rateLimited
+	otherConfig.MemberAuditChannelID = "audit-other"
+
+	useServerConfig(t, limitedGuildID, limitedConfig)
+	configMutex.Lock()
+	config.Servers[otherGuildID] = otherConfig
+	configMutex.Unlock()
+
+	rateLimitLock.Lock()
+	rateLimitMap[rateLimitKey(limitedGuildID, userID)] = time.Now()
+	rateLimitLock.Unlock()
+	t.Cleanup(func() {
+		rateLimitLock.Lock()
+		delete(rateLimitMap, rateLimitKey(limitedGuildID, userID))
+		delete(rateLimitMap, rateLimitKey(otherGuildID, userID))
+		rateLimitLock.Unlock()
+		removePendingVerification(otherGuildID, userID)
+	})
+
+	s := &fakeSession{}
+	for _, guildID := range []string{otherGuildID, limitedGuildID} {
+		processEmailVerification(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID:        "submission-" + guildID,
+			ChannelID: "dm-" + userID,
+			GuildID:   guildID,
+			Content:   "student" + guildID + "@uclan.ac.uk",
+			Author:    &discordgo.User{ID: userID, Username: "student"},
+		}})
+	}
+
+	if !s.called("ChannelMessageSend audit-other") {
+		t.Errorf("cooldown in one guild held up a submission to another (calls %q)", s.calls)
+	}
+	if s.called("ChannelMessageSend audit-limited") {
+		t.Errorf("submission went through despite the cooldown")
+	}
+
+	// The submission that went through starts a cooldown of its own
+	rateLimitLock.Lock()
+	_, limited := rateLimitMap[rateLimitKey(otherGuildID, userID)]
+	rateLimitLock.Unlock()
+	if !limited {
+		t.Errorf("no cooldown recorded for the other guild")
+	}
+}
@@ -0,0 +1,72 @@
+package main
+
+import (
+	"log/slog"
+	"os"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// resetConfig asks for confirmation before wiping the guild's settings, since
+// there's no undo.
+func resetConfig(s discordSession, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "This will delete every setting for this server, including channels, roles and messages. Are you sure?",
+			Flags:   discordgo.MessageFlagsEphemeral,
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.Button{
+							Label:    "Reset config",
+							Style:    discordgo.DangerButton,
+							CustomID: encodeCustomID("resetconfig", i.GuildID),
+						},
+					},
+				},
+			},
+		},
+	})
+}
+
+func forgetGuildConfig(guildID string) {
+	configMutex.Lock()
+	delete(config.Servers, guildID)
+	configMutex.Unlock()
+	queueConfigSave(guildID)
+}
+
+// handleResetConfig carries out a confirmed /reset_config
+func handleResetConfig(s discordSession, i *discordgo.InteractionCreate, guildID string) {
+	content := "Config reset successfully! :white_check_mark: Run the setup commands again to configure the bot."
+	if guildID != i.GuildID || !isAdministrator(i) {
+		content = "You need to be an administrator of this server to reset its config."
+	} else {
+		forgetGuildConfig(guildID)
+		slog.Info("Config reset", "guild_id", guildID, "user_id", interactionUserID(i))
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &content,
+	})
+}
+
+// guildDelete drops the config of a guild the bot was removed from when
+// PRUNE_REMOVED_GUILDS is set. Outages also send this event, with the guild
+// marked unavailable, and those are ignored.
+func guildDelete(s discordSession, g *discordgo.GuildDelete) {
+	if g.Unavailable || os.Getenv("PRUNE_REMOVED_GUILDS") != "true" {
+		return
+	}
+
+	configMutex.RLock()
+	_, exists := config.Servers[g.ID]
+	configMutex.RUnlock()
+	if !exists {
+		return
+	}
+
+	forgetGuildConfig(g.ID)
+	slog.Info("Removed config for guild the bot is no longer in", "guild_id", g.ID)
+}
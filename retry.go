@@ -0,0 +1,53 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Attempts made by withRetry, and the backoff before the first retry
+const (
+	retryAttempts    = 3
+	retryBaseBackoff = 500 * time.Millisecond
+)
+
+// retryDelay reports how long to wait before retrying err, or false if the
+// error isn't worth retrying. Rate limits honour Discord's Retry-After.
+func retryDelay(err error, attempt int) (time.Duration, bool) {
+	var restErr *discordgo.RESTError
+	if !errors.As(err, &restErr) || restErr.Response == nil {
+		return 0, false
+	}
+
+	status := restErr.Response.StatusCode
+	if status == http.StatusTooManyRequests {
+		if seconds, err := strconv.ParseFloat(restErr.Response.Header.Get("Retry-After"), 64); err == nil {
+			return time.Duration(seconds * float64(time.Second)), true
+		}
+	} else if status < 500 {
+		return 0, false
+	}
+	return retryBaseBackoff << attempt, true
+}
+
+// withRetry runs fn, retrying transient Discord failures (429 and 5xx) with
+// exponential backoff.
+func withRetry(fn func() error) error {
+	var err error
+	for attempt := 0; attempt < retryAttempts; attempt++ {
+		err = fn()
+		if err == nil {
+			return nil
+		}
+		delay, retry := retryDelay(err, attempt)
+		if !retry || attempt == retryAttempts-1 {
+			break
+		}
+		time.Sleep(delay)
+	}
+	return err
+}
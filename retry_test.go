@@ -0,0 +1,51 @@
+package main
+
+import (
+	"errors"
+	"net/http"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func restError(status int, header http.Header) error {
+	return &discordgo.RESTError{Response: &http.Response{StatusCode: status, Header: header}}
+}
+
+func TestWithRetry(t *testing.T) {
+	rateLimited := restError(http.StatusTooManyRequests, http.Header{"Retry-After": []string{"0"}})
+
+	tests := []struct {
+		name      string
+		errs      []error
+		wantCalls int
+		wantErr   bool
+	}{
+		{name: "succeeds first time", errs: nil, wantCalls: 1},
+		{name: "rate limited twice", errs: []error{rateLimited, rateLimited}, wantCalls: 3},
+		{name: "server error then rate limited", errs: []error{restError(http.StatusBadGateway, nil), rateLimited}, wantCalls: 3},
+		{name: "gives up after the last attempt", errs: []error{rateLimited, rateLimited, rateLimited, rateLimited}, wantCalls: 3, wantErr: true},
+		{name: "client error isn't retried", errs: []error{restError(http.StatusForbidden, nil)}, wantCalls: 1, wantErr: true},
+		{name: "non-REST error isn't retried", errs: []error{errors.New("connection reset")}, wantCalls: 1, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			calls := 0
+			err := withRetry(func() error {
+				calls++
+				if calls <= len(tt.errs) {
+					return tt.errs[calls-1]
+				}
+				return nil
+			})
+
+			if calls != tt.wantCalls {
+				t.Errorf("fn called %d times, want %d", calls, tt.wantCalls)
+			}
+			if (err != nil) != tt.wantErr {
+				t.Errorf("withRetry() error = %v, want error %v", err, tt.wantErr)
+			}
+		})
+	}
+}
@@ -0,0 +1,185 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"sort"
+	"sync"
+	"time"
+	_ "time/tzdata"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Room for the summary DM, leaving space under Discord's 2000 character
+// limit for the line counting the requests left out
+const maxReviewSummaryLength = 1950
+
+var (
+	// The local date each guild's summary was last sent, so it only goes
+	// out once a day
+	lastSummarySent = make(map[string]string)
+	lastSummaryLock sync.Mutex
+)
+
+// summaryDue reports whether the guild's summary should be sent at now,
+// given the configured HH:MM time in the guild's timezone.
+func summaryDue(guildID string, serverConfig ServerConfig, now time.Time) bool {
+	if serverConfig.SummaryTime == "" {
+		return false
+	}
+
+	location, err := time.LoadLocation(serverConfig.SummaryTimezone)
+	if err != nil {
+		location = time.UTC
+	}
+	local := now.In(location)
+	if local.Format("15:04") != serverConfig.SummaryTime {
+		return false
+	}
+
+	today := local.Format("2006-01-02")
+	lastSummaryLock.Lock()
+	defer lastSummaryLock.Unlock()
+	if lastSummarySent[guildID] == today {
+		return false
+	}
+	lastSummarySent[guildID] = today
+	return true
+}
+
+// buildReviewSummary lists the guild's pending requests, oldest first. It
+// returns an empty string when there is nothing waiting.
+func buildReviewSummary(guildName string, pending []pendingVerification, now time.Time) string {
+	if len(pending) == 0 {
+		return ""
+	}
+
+	sort.Slice(pending, func(a, b int) bool {
+		return pending[a].SubmittedAt.Before(pending[b].SubmittedAt)
+	})
+
+	lines := []string{fmt.Sprintf("Good morning! There are %d verification request(s) waiting for review in %s:", len(pending), guildName)}
+	for _, request := range pending {
+		lines = append(lines, fmt.Sprintf("- <@%s>, waiting %s", request.UserID, formatWaiting(now.Sub(request.SubmittedAt))))
+	}
+	return joinLinesCapped(lines, maxReviewSummaryLength)
+}
+
+func formatWaiting(d time.Duration) string {
+	if d < time.Hour {
+		return fmt.Sprintf("%dm", int(d/time.Minute))
+	}
+	return fmt.Sprintf("%dh %dm", int(d/time.Hour), int(d%time.Hour/time.Minute))
+}
+
+// selectSummaryRecipients returns the configured users plus every non-bot
+// member holding the summary role, without duplicates.
+func selectSummaryRecipients(members []*discordgo.Member, roleID string, userIDs []string) []string {
+	seen := make(map[string]bool)
+	var recipients []string
+	for _, userID := range userIDs {
+		if !seen[userID] {
+			seen[userID] = true
+			recipients = append(recipients, userID)
+		}
+	}
+
+	if roleID == "" {
+		return recipients
+	}
+	for _, member := range members {
+		if member.User == nil || member.User.Bot || seen[member.User.ID] || !memberHasRole(member, roleID) {
+			continue
+		}
+		seen[member.User.ID] = true
+		recipients = append(recipients, member.User.ID)
+	}
+	return recipients
+}
+
+func guildPendingVerifications(guildID string) []pendingVerification {
+	pendingLock.Lock()
+	defer pendingLock.Unlock()
+
+	var pending []pendingVerification
+	for _, request := range pendingVerifications {
+		if request.GuildID == guildID {
+			pending = append(pending, request)
+		}
+	}
+	return pending
+}
+
+func fetchAllMembers(s discordSession, guildID string) ([]*discordgo.Member, error) {
+	var all []*discordgo.Member
+	after := ""
+	for {
+		members, err := s.GuildMembers(guildID, after, 1000)
+		if err != nil {
+			return nil, err
+		}
+		all = append(all, members...)
+		if len(members) < 1000 {
+			return all, nil
+		}
+		after = members[len(members)-1].User.ID
+	}
+}
+
+func sendReviewSummary(s discordSession, guildID string, serverConfig ServerConfig) {
+	guildName := guildID
+	if guild, err := stateOf(s).Guild(guildID); err == nil {
+		guildName = guild.Name
+	}
+
+	summary := buildReviewSummary(guildName, guildPendingVerifications(guildID), time.Now())
+	if summary == "" {
+		return
+	}
+
+	var members []*discordgo.Member
+	if serverConfig.SummaryRoleID != "" {
+		var err error
+		members, err = fetchAllMembers(s, guildID)
+		if err != nil {
+			slog.Error("Error fetching members", "guild_id", guildID, "error", err)
+			return
+		}
+	}
+
+	throttle := newBulkThrottle(guildBulkPolicy(guildID))
+	for _, userID := range selectSummaryRecipients(members, serverConfig.SummaryRoleID, serverConfig.SummaryUserIDs) {
+		dmChannel, err := s.UserChannelCreate(userID)
+		if err != nil {
+			slog.Error("Error creating DM channel", "error", err)
+			continue
+		}
+		_, err = s.ChannelMessageSend(dmChannel.ID, summary)
+		if err != nil {
+			slog.Error("Error sending review summary", "user_id", userID, "error", err)
+		}
+		throttle.done()
+	}
+}
+
+// runReviewSummaries checks once a minute for guilds whose summary is due
+func runReviewSummaries(s discordSession) {
+	ticker := time.NewTicker(time.Minute)
+	defer ticker.Stop()
+
+	for now := range ticker.C {
+		configMutex.RLock()
+		due := make(map[string]ServerConfig)
+		for guildID, serverConfig := range config.Servers {
+			if summaryDue(guildID, serverConfig, now) {
+				due[guildID] = serverConfig
+			}
+		}
+		configMutex.RUnlock()
+
+		for guildID, serverConfig := range due {
+			sendReviewSummary(s, guildID, serverConfig)
+		}
+	}
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"fmt"
+	"reflect"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestSelectSummaryRecipients(t *testing.T) {
+	members := []*discordgo.Member{
+		{User: &discordgo.User{ID: "mod"}, Roles: []string{"moderators"}},
+		{User: &discordgo.User{ID: "bot", Bot: true}, Roles: []string{"moderators"}},
+		{User: &discordgo.User{ID: "member"}, Roles: []string{"members"}},
+		{User: &discordgo.User{ID: "admin"}, Roles: []string{"members", "moderators"}},
+		{Roles: []string{"moderators"}},
+	}
+
+	tests := []struct {
+		name    string
+		roleID  string
+		userIDs []string
+		want    []string
+	}{
+		{name: "nobody configured", want: nil},
+		{name: "users only", userIDs: []string{"owner", "chair"}, want: []string{"owner", "chair"}},
+		{name: "role holders skip bots", roleID: "moderators", want: []string{"mod", "admin"}},
+		{name: "no duplicates", roleID: "moderators", userIDs: []string{"admin", "admin"}, want: []string{"admin", "mod"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := selectSummaryRecipients(members, tt.roleID, tt.userIDs)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("selectSummaryRecipients() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestBuildReviewSummary(t *testing.T) {
+	now := time.Date(2026, 9, 21, 9, 0, 0, 0, time.UTC)
+	pending := func(n int) []pendingVerification {
+		var requests []pendingVerification
+		for idx := 0; idx < n; idx++ {
+			requests = append(requests, pendingVerification{
+				GuildID:     "guild",
+				UserID:      fmt.Sprintf("200000000000%06d", idx),
+				SubmittedAt: now.Add(-time.Duration(idx+1) * time.Minute),
+			})
+		}
+		return requests
+	}
+
+	if got := buildReviewSummary("Society", nil, now); got != "" {
+		t.Errorf("summary with nothing pending = %q, want empty", got)
+	}
+
+	summary := buildReviewSummary("Society", pending(2), now)
+	if !strings.HasPrefix(summary, "Good morning! There are 2 verification request(s)") {
+		t.Errorf("summary = %q", summary)
+	}
+	// Oldest first
+	if strings.Index(summary, "200000000000000001") > strings.Index(summary, "200000000000000000") {
+		t.Errorf("summary isn't oldest first:\n%s", summary)
+	}
+
+	summary = buildReviewSummary("Society", pending(200), now)
+	if len(summary) > 2000 {
+		t.Errorf("summary is %d characters, want at most 2000", len(summary))
+	}
+	if !strings.Contains(summary, "more") {
+		t.Errorf("long summary doesn't count the requests left out")
+	}
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"fmt"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// reviewerMention returns the mention to put on a new verification request
+// and the allowed mentions that go with it. With pings muted the role is
+// still shown but nobody is notified.
+func reviewerMention(serverConfig ServerConfig) (string, *discordgo.MessageAllowedMentions) {
+	allowed := &discordgo.MessageAllowedMentions{}
+	if serverConfig.ReviewerRoleID == "" {
+		return "", allowed
+	}
+	if !serverConfig.MuteReviewerPing {
+		allowed.Roles = []string{serverConfig.ReviewerRoleID}
+	}
+	return fmt.Sprintf("<@&%s> ", serverConfig.ReviewerRoleID), allowed
+}
+
+func setReviewerRole(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
+	var roleID string
+	ping := true
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "role":
+			roleID = option.RoleValue(sessionOf(s), guildID).ID
+		case "ping":
+			ping = option.BoolValue()
+		}
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.ReviewerRoleID = roleID
+	serverConfig.MuteReviewerPing = !ping
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Reviewer role cleared successfully! :white_check_mark:"
+	if roleID != "" && ping {
+		content = fmt.Sprintf("<@&%s> will be pinged for each new verification request! :white_check_mark:", roleID)
+	} else if roleID != "" {
+		content = fmt.Sprintf("<@&%s> will be shown on new verification requests without being pinged! :white_check_mark:", roleID)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content:         content,
+			AllowedMentions: &discordgo.MessageAllowedMentions{},
+		},
+	})
+}
@@ -0,0 +1,76 @@
+package main
+
+import (
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// discordSession is the part of *discordgo.Session the bot uses, so tests
+// can swap in a fake.
+type discordSession interface {
+	ChannelMessageSend(channelID string, content string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageSendComplex(channelID string, data *discordgo.MessageSend, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessageEditComplex(m *discordgo.MessageEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessage(channelID, messageID string, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	ChannelMessages(channelID string, limit int, beforeID, afterID, aroundID string, options ...discordgo.RequestOption) ([]*discordgo.Message, error)
+	ChannelMessageDelete(channelID, messageID string, options ...discordgo.RequestOption) error
+	ChannelMessagesBulkDelete(channelID string, messages []string, options ...discordgo.RequestOption) error
+	Channel(channelID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	ChannelEdit(channelID string, data *discordgo.ChannelEdit, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	UserChannelCreate(recipientID string, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	GuildLeave(guildID string, options ...discordgo.RequestOption) error
+	GuildRoles(guildID string, options ...discordgo.RequestOption) ([]*discordgo.Role, error)
+	GuildMember(guildID, userID string, options ...discordgo.RequestOption) (*discordgo.Member, error)
+	GuildMembers(guildID string, after string, limit int, options ...discordgo.RequestOption) ([]*discordgo.Member, error)
+	GuildMemberRoleAdd(guildID, userID, roleID string, options ...discordgo.RequestOption) error
+	GuildMemberRoleRemove(guildID, userID, roleID string, options ...discordgo.RequestOption) error
+	GuildMemberDelete(guildID, userID string, options ...discordgo.RequestOption) error
+	GuildMemberMove(guildID string, userID string, channelID *string, options ...discordgo.RequestOption) error
+	GuildMemberTimeout(guildID string, userID string, until *time.Time, options ...discordgo.RequestOption) error
+	InteractionRespond(interaction *discordgo.Interaction, resp *discordgo.InteractionResponse, options ...discordgo.RequestOption) error
+	InteractionResponseEdit(interaction *discordgo.Interaction, newresp *discordgo.WebhookEdit, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	InteractionResponseDelete(interaction *discordgo.Interaction, options ...discordgo.RequestOption) error
+	FollowupMessageCreate(interaction *discordgo.Interaction, wait bool, data *discordgo.WebhookParams, options ...discordgo.RequestOption) (*discordgo.Message, error)
+	MessageReactionAdd(channelID, messageID, emojiID string, options ...discordgo.RequestOption) error
+	MessageThreadStartComplex(channelID, messageID string, data *discordgo.ThreadStart, options ...discordgo.RequestOption) (*discordgo.Channel, error)
+	ApplicationCommandBulkOverwrite(appID string, guildID string, commands []*discordgo.ApplicationCommand, options ...discordgo.RequestOption) ([]*discordgo.ApplicationCommand, error)
+	ApplicationCommandDelete(appID, guildID, cmdID string, options ...discordgo.RequestOption) error
+}
+
+var _ discordSession = (*discordgo.Session)(nil)
+
+// stateKeeper is implemented by sessions other than *discordgo.Session that
+// keep a state cache of their own.
+type stateKeeper interface {
+	sessionState() *discordgo.State
+}
+
+// stateOf returns the session's state cache, or an empty one for sessions
+// that don't keep one.
+func stateOf(s discordSession) *discordgo.State {
+	if session, ok := s.(*discordgo.Session); ok && session.State != nil {
+		return session.State
+	}
+	if keeper, ok := s.(stateKeeper); ok && keeper.sessionState() != nil {
+		return keeper.sessionState()
+	}
+	return discordgo.NewState()
+}
+
+// sessionOf returns the underlying *discordgo.Session, or nil for fakes.
+// Command option helpers such as UserValue take one to look up extra
+// details and fall back to just the ID without it.
+func sessionOf(s discordSession) *discordgo.Session {
+	session, _ := s.(*discordgo.Session)
+	return session
+}
+
+// botUserID returns the bot's own user ID, or "" before the session has
+// identified.
+func botUserID(s discordSession) string {
+	if user := stateOf(s).User; user != nil {
+		return user.ID
+	}
+	return ""
+}
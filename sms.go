@@ -0,0 +1,287 @@
+package main
+
+import (
+	"crypto/rand"
+	"errors"
+	"fmt"
+	"log/slog"
+	"math/big"
+	"net/http"
+	"net/url"
+	"os"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long a verification code stays valid after it is sent
+const codeTTL = 10 * time.Minute
+
+// Wrong guesses allowed before a code is thrown away. With six digits this
+// keeps the odds of guessing a code negligible.
+const maxCodeAttempts = 5
+
+// Texts cost money and land on real phones, so each user has to wait between
+// codes and both users and numbers get a daily allowance
+const (
+	smsResendCooldown = time.Minute
+	smsDailyLimit     = 3
+)
+
+var (
+	errSMSCooldown   = errors.New("a code was sent too recently")
+	errSMSDailyLimit = errors.New("daily text limit reached")
+)
+
+var (
+	errNoPendingCode      = errors.New("no verification code is pending")
+	errCodeExpired        = errors.New("verification code has expired")
+	errWrongCode          = errors.New("verification code is incorrect")
+	errTooManyCodeGuesses = errors.New("too many incorrect verification codes")
+)
+
+// smsSender delivers text messages. It is an interface so providers other
+// than Twilio can be plugged in.
+type smsSender interface {
+	SendSMS(to, body string) error
+}
+
+type twilioSender struct {
+	accountSID string
+	authToken  string
+	from       string
+	client     *http.Client
+}
+
+func (t *twilioSender) SendSMS(to, body string) error {
+	form := url.Values{"To": {to}, "From": {t.from}, "Body": {body}}
+	req, err := http.NewRequest(http.MethodPost, fmt.Sprintf("https://api.twilio.com/2010-04-01/Accounts/%s/Messages.json", t.accountSID), strings.NewReader(form.Encode()))
+	if err != nil {
+		return err
+	}
+	req.SetBasicAuth(t.accountSID, t.authToken)
+	req.Header.Set("Content-Type", "application/x-www-form-urlencoded")
+
+	resp, err := t.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= 300 {
+		return fmt.Errorf("twilio returned %s", resp.Status)
+	}
+	return nil
+}
+
+// Configured from TWILIO_* env vars at startup; nil when SMS is unavailable
+var smsProvider smsSender
+
+func loadSMSProvider() {
+	accountSID := os.Getenv("TWILIO_ACCOUNT_SID")
+	if accountSID == "" {
+		return
+	}
+	smsProvider = &twilioSender{
+		accountSID: accountSID,
+		authToken:  os.Getenv("TWILIO_AUTH_TOKEN"),
+		from:       os.Getenv("TWILIO_FROM_NUMBER"),
+		client:     &http.Client{Timeout: 10 * time.Second},
+	}
+}
+
+type codeEntry struct {
+	GuildID   string
+	Code      string
+	ExpiresAt time.Time
+	Attempts  int
+}
+
+var (
+	pendingCodes     = make(map[string]codeEntry)
+	pendingCodesLock sync.Mutex
+)
+
+// Recent texts, keyed by "user:<id>" and "number:<phone>"
+var (
+	smsSends     = make(map[string][]time.Time)
+	smsSendsLock sync.Mutex
+)
+
+// allowSMS checks the user's and number's recent texts and, if another one
+// is allowed, records it.
+func allowSMS(userID, number string, now time.Time) error {
+	smsSendsLock.Lock()
+	defer smsSendsLock.Unlock()
+
+	// Drop anything older than a day, for every key, so the map stays small
+	for key, sends := range smsSends {
+		var recent []time.Time
+		for _, sent := range sends {
+			if now.Sub(sent) < 24*time.Hour {
+				recent = append(recent, sent)
+			}
+		}
+		if len(recent) == 0 {
+			delete(smsSends, key)
+		} else {
+			smsSends[key] = recent
+		}
+	}
+
+	keys := []string{"user:" + userID, "number:" + number}
+	userSends := smsSends[keys[0]]
+	if len(userSends) > 0 && now.Sub(userSends[len(userSends)-1]) < smsResendCooldown {
+		return errSMSCooldown
+	}
+	for _, key := range keys {
+		if len(smsSends[key]) >= smsDailyLimit {
+			return errSMSDailyLimit
+		}
+	}
+	for _, key := range keys {
+		smsSends[key] = append(smsSends[key], now)
+	}
+	return nil
+}
+
+func generateCode() (string, error) {
+	n, err := rand.Int(rand.Reader, big.NewInt(1000000))
+	if err != nil {
+		return "", err
+	}
+	return fmt.Sprintf("%06d", n.Int64()), nil
+}
+
+// issueCode creates a fresh code for the user, replacing any earlier one.
+func issueCode(guildID, userID string, now time.Time) (string, error) {
+	code, err := generateCode()
+	if err != nil {
+		return "", err
+	}
+
+	pendingCodesLock.Lock()
+	pendingCodes[userID] = codeEntry{GuildID: guildID, Code: code, ExpiresAt: now.Add(codeTTL)}
+	pendingCodesLock.Unlock()
+	return code, nil
+}
+
+func hasPendingCode(userID string) bool {
+	pendingCodesLock.Lock()
+	defer pendingCodesLock.Unlock()
+
+	_, exists := pendingCodes[userID]
+	return exists
+}
+
+// redeemCode checks the submitted code. Codes are single-use: a correct or
+// expired code is removed, and so is one that has been guessed wrong
+// maxCodeAttempts times, so the user has to ask for a new code.
+func redeemCode(userID, code string, now time.Time) (codeEntry, error) {
+	pendingCodesLock.Lock()
+	defer pendingCodesLock.Unlock()
+
+	entry, exists := pendingCodes[userID]
+	if !exists {
+		return codeEntry{}, errNoPendingCode
+	}
+	if now.After(entry.ExpiresAt) {
+		delete(pendingCodes, userID)
+		return codeEntry{}, errCodeExpired
+	}
+	if entry.Code != code {
+		entry.Attempts++
+		if entry.Attempts >= maxCodeAttempts {
+			delete(pendingCodes, userID)
+			return codeEntry{}, errTooManyCodeGuesses
+		}
+		pendingCodes[userID] = entry
+		return codeEntry{}, errWrongCode
+	}
+	delete(pendingCodes, userID)
+	return entry, nil
+}
+
+func startSMSVerification(s discordSession, m *discordgo.MessageCreate, guildID string, serverConfig ServerConfig) {
+	if !serverConfig.SMSVerificationEnabled || smsProvider == nil {
+		replyToSubmission(s, m, "Phone verification isn't enabled for this server. Please provide your university email instead.")
+		return
+	}
+
+	err := allowSMS(m.Author.ID, m.Content, time.Now())
+	if errors.Is(err, errSMSCooldown) {
+		replyToSubmission(s, m, "We've only just sent you a code. Please wait a minute before asking for another.")
+		return
+	}
+	if errors.Is(err, errSMSDailyLimit) {
+		slog.Warn("SMS daily limit reached", "guild_id", guildID, "user_id", m.Author.ID)
+		replyToSubmission(s, m, "Too many codes have been sent today. Please try again tomorrow or verify with your university email instead.")
+		return
+	}
+
+	code, err := issueCode(guildID, m.Author.ID, time.Now())
+	if err != nil {
+		slog.Error("Error generating verification code", "error", err)
+		return
+	}
+
+	err = smsProvider.SendSMS(m.Content, fmt.Sprintf("Your verification code is %s. It expires in 10 minutes.", code))
+	if err != nil {
+		slog.Error("Error sending SMS", "user_id", m.Author.ID, "error", err)
+		replyToSubmission(s, m, "Sorry, we couldn't send a text to that number. Please check it and try again.")
+		return
+	}
+
+	replyToSubmission(s, m, "We've sent a 6-digit code to your phone. Please reply here with the code to finish verifying.")
+}
+
+func handleCodeSubmission(s discordSession, m *discordgo.MessageCreate) {
+	entry, err := redeemCode(m.Author.ID, m.Content, time.Now())
+	if err != nil {
+		switch err {
+		case errCodeExpired:
+			s.ChannelMessageSend(m.ChannelID, "That code has expired. Please start verification again to get a new one.")
+		case errWrongCode:
+			s.ChannelMessageSend(m.ChannelID, "That code is incorrect. Please check it and try again.")
+		case errTooManyCodeGuesses:
+			slog.Warn("Verification code discarded after too many wrong guesses", "user_id", m.Author.ID)
+			s.ChannelMessageSend(m.ChannelID, "That code is incorrect, and there have been too many wrong attempts. Please start verification again to get a new code.")
+		default:
+			s.ChannelMessageSend(m.ChannelID, "There's no verification code waiting for you. Please start verification first.")
+		}
+		return
+	}
+
+	configMutex.RLock()
+	serverConfig := config.Servers[entry.GuildID]
+	configMutex.RUnlock()
+
+	// Remove unverified role
+	if serverConfig.UnverifiedRoleID != "" {
+		err = withRetry(func() error {
+			return memberRoleRemove(s, entry.GuildID, m.Author.ID, serverConfig.UnverifiedRoleID)
+		})
+		if err != nil {
+			slog.Error("Error removing unverified role", "error", err)
+			s.ChannelMessageSend(m.ChannelID, "Your code was correct but something went wrong removing your unverified role. Please contact a moderator.")
+			return
+		}
+	}
+	grantVerifiedRole(s, entry.GuildID, m.Author.ID)
+
+	recordDecision(s, VerificationLogEntry{
+		GuildID: entry.GuildID,
+		UserID:  m.Author.ID,
+		Action:  "approve",
+		Time:    time.Now(),
+	})
+
+	_, err = s.ChannelMessageSend(m.ChannelID, "You're verified and now have access to the server. Welcome! 🎉")
+	if err != nil {
+		slog.Error("Error sending DM", "error", err)
+	}
+
+	welcomeMember(s, entry.GuildID, m.Author.ID)
+}
@@ -0,0 +1,169 @@
+package main
+
+import (
+	"errors"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+type fakeSMSSender struct {
+	sent []string
+}
+
+func (f *fakeSMSSender) SendSMS(to, body string) error {
+	f.sent = append(f.sent, body)
+	return nil
+}
+
+func TestRedeemCode(t *testing.T) {
+	const guildID = "100000000000000022"
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+
+	issue := func(t *testing.T, userID string) string {
+		t.Helper()
+		code, err := issueCode(guildID, userID, now)
+		if err != nil {
+			t.Fatal(err)
+		}
+		t.Cleanup(func() {
+			pendingCodesLock.Lock()
+			delete(pendingCodes, userID)
+			pendingCodesLock.Unlock()
+		})
+		return code
+	}
+
+	t.Run("correct code", func(t *testing.T) {
+		const userID = "200000000000000101"
+		code := issue(t, userID)
+		if len(code) != 6 {
+			t.Errorf("code = %q, want 6 digits", code)
+		}
+
+		entry, err := redeemCode(userID, code, now.Add(time.Minute))
+		if err != nil || entry.GuildID != guildID {
+			t.Fatalf("redeemCode() = %+v, %v, want the code's guild", entry, err)
+		}
+		if _, err := redeemCode(userID, code, now.Add(time.Minute)); !errors.Is(err, errNoPendingCode) {
+			t.Errorf("second redeem error = %v, want the code to be single-use", err)
+		}
+	})
+
+	t.Run("wrong code", func(t *testing.T) {
+		const userID = "200000000000000102"
+		code := issue(t, userID)
+
+		if _, err := redeemCode(userID, "not-it", now); !errors.Is(err, errWrongCode) {
+			t.Fatalf("error = %v, want errWrongCode", err)
+		}
+		if _, err := redeemCode(userID, code, now); err != nil {
+			t.Errorf("correct code after one wrong guess: %v", err)
+		}
+	})
+
+	t.Run("too many wrong guesses", func(t *testing.T) {
+		const userID = "200000000000000103"
+		code := issue(t, userID)
+
+		var err error
+		for range maxCodeAttempts {
+			_, err = redeemCode(userID, "not-it", now)
+		}
+		if !errors.Is(err, errTooManyCodeGuesses) {
+			t.Fatalf("error = %v, want errTooManyCodeGuesses", err)
+		}
+		if _, err := redeemCode(userID, code, now); !errors.Is(err, errNoPendingCode) {
+			t.Errorf("error = %v, want the code thrown away", err)
+		}
+	})
+
+	t.Run("expired", func(t *testing.T) {
+		const userID = "200000000000000104"
+		code := issue(t, userID)
+
+		if _, err := redeemCode(userID, code, now.Add(codeTTL+time.Second)); !errors.Is(err, errCodeExpired) {
+			t.Errorf("error = %v, want errCodeExpired", err)
+		}
+	})
+}
+
+func TestAllowSMS(t *testing.T) {
+	const userID = "200000000000000105"
+	now := time.Date(2026, 10, 1, 12, 0, 0, 0, time.UTC)
+	t.Cleanup(func() {
+		smsSendsLock.Lock()
+		for key := range smsSends {
+			delete(smsSends, key)
+		}
+		smsSendsLock.Unlock()
+	})
+
+	if err := allowSMS(userID, "+447700900001", now); err != nil {
+		t.Fatalf("first text: %v", err)
+	}
+	if err := allowSMS(userID, "+447700900001", now.Add(30*time.Second)); !errors.Is(err, errSMSCooldown) {
+		t.Errorf("text within the cooldown: error = %v, want errSMSCooldown", err)
+	}
+
+	// Switching numbers doesn't get round the user's allowance
+	for n, number := range []string{"+447700900002", "+447700900003"} {
+		if err := allowSMS(userID, number, now.Add(time.Duration(n+1)*2*time.Minute)); err != nil {
+			t.Fatalf("text %d: %v", n+2, err)
+		}
+	}
+	if err := allowSMS(userID, "+447700900004", now.Add(time.Hour)); !errors.Is(err, errSMSDailyLimit) {
+		t.Errorf("text over the daily limit: error = %v, want errSMSDailyLimit", err)
+	}
+
+	if err := allowSMS(userID, "+447700900004", now.Add(25*time.Hour)); err != nil {
+		t.Errorf("text the next day: %v", err)
+	}
+}
+
+func TestSMSVerification(t *testing.T) {
+	const (
+		guildID = "100000000000000023"
+		userID  = "200000000000000106"
+	)
+	useServerConfig(t, guildID, ServerConfig{SMSVerificationEnabled: true, UnverifiedRoleID: "unverified"})
+
+	sender := &fakeSMSSender{}
+	previous := smsProvider
+	smsProvider = sender
+	t.Cleanup(func() {
+		smsProvider = previous
+		smsSendsLock.Lock()
+		delete(smsSends, "user:"+userID)
+		delete(smsSends, "number:+447700900010")
+		smsSendsLock.Unlock()
+	})
+
+	s := &fakeSession{}
+	message := func(content string) *discordgo.MessageCreate {
+		return &discordgo.MessageCreate{Message: &discordgo.Message{
+			ChannelID: "dm-" + userID,
+			Content:   content,
+			Author:    &discordgo.User{ID: userID},
+		}}
+	}
+	startSMSVerification(s, message("+447700900010"), guildID, getOrCreateServerConfig(guildID))
+
+	if len(sender.sent) != 1 {
+		t.Fatalf("sent %d texts, want 1", len(sender.sent))
+	}
+	code := regexp.MustCompile(`\d{6}`).FindString(sender.sent[0])
+	if !hasPendingCode(userID) || code == "" {
+		t.Fatalf("no code pending after sending %q", sender.sent[0])
+	}
+
+	handleCodeSubmission(s, message(code))
+	if !s.called("GuildMemberRoleRemove " + guildID + " " + userID + " unverified") {
+		t.Errorf("unverified role not removed (calls %q)", s.calls)
+	}
+	if hasPendingCode(userID) {
+		t.Errorf("code still pending after use")
+	}
+}
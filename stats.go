@@ -0,0 +1,128 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Stats are reused for this long so repeated /stats calls don't page through
+// the whole member list each time
+const statsCacheDuration = time.Minute
+
+type guildStats struct {
+	Unverified  int
+	Verified7d  int
+	RateLimited int
+	At          time.Time
+}
+
+var (
+	statsCache     = make(map[string]guildStats)
+	statsCacheLock sync.Mutex
+)
+
+// countVerifiedSince counts approvals in the guild since the given time
+func countVerifiedSince(entries []VerificationLogEntry, guildID string, since time.Time) int {
+	count := 0
+	for _, entry := range entries {
+		if entry.GuildID == guildID && entry.Action == "approve" && !entry.Time.Before(since) {
+			count++
+		}
+	}
+	return count
+}
+
+// countRateLimited counts the guild's members still in their cooldown
+func countRateLimited(entries map[string]time.Time, guildID string, cooldown time.Duration, now time.Time) int {
+	count := 0
+	for key, last := range entries {
+		if strings.HasPrefix(key, guildID+":") && now.Sub(last) < cooldown {
+			count++
+		}
+	}
+	return count
+}
+
+func collectGuildStats(s discordSession, guildID string, now time.Time) (guildStats, error) {
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	stats := guildStats{At: now}
+	if serverConfig.UnverifiedRoleID != "" {
+		members, err := fetchAllMembers(s, guildID)
+		if err != nil {
+			return guildStats{}, err
+		}
+		for _, member := range members {
+			if memberHasRole(member, serverConfig.UnverifiedRoleID) {
+				stats.Unverified++
+			}
+		}
+	}
+
+	verificationLogLock.Lock()
+	stats.Verified7d = countVerifiedSince(verificationLog, guildID, now.Add(-7*24*time.Hour))
+	verificationLogLock.Unlock()
+
+	if serverConfig.RateLimitEnabled {
+		rateLimitLock.Lock()
+		stats.RateLimited = countRateLimited(rateLimitMap, guildID, serverConfig.RateLimitDuration, now)
+		rateLimitLock.Unlock()
+	}
+	return stats, nil
+}
+
+func showStats(s discordSession, i *discordgo.InteractionCreate) {
+	guildID := i.GuildID
+	now := time.Now()
+
+	// Paging through members can outlast the interaction deadline
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+	})
+	if err != nil {
+		slog.Error("Error acknowledging interaction", "error", err)
+		return
+	}
+
+	statsCacheLock.Lock()
+	stats, cached := statsCache[guildID]
+	statsCacheLock.Unlock()
+
+	if !cached || now.Sub(stats.At) > statsCacheDuration {
+		stats, err = collectGuildStats(s, guildID, now)
+		if err != nil {
+			slog.Error("Error collecting stats", "guild_id", guildID, "error", err)
+			errorContent := "Error collecting stats: " + err.Error()
+			s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+				Content: &errorContent,
+			})
+			return
+		}
+
+		statsCacheLock.Lock()
+		statsCache[guildID] = stats
+		statsCacheLock.Unlock()
+	}
+
+	s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Embeds: &[]*discordgo.MessageEmbed{
+			{
+				Title: "Verification stats",
+				Fields: []*discordgo.MessageEmbedField{
+					{Name: "Unverified members", Value: fmt.Sprint(stats.Unverified), Inline: true},
+					{Name: "Verified in the last 7 days", Value: fmt.Sprint(stats.Verified7d), Inline: true},
+					{Name: "Currently rate limited", Value: fmt.Sprint(stats.RateLimited), Inline: true},
+				},
+				Footer:    &discordgo.MessageEmbedFooter{Text: "Updated"},
+				Timestamp: stats.At.Format(time.RFC3339),
+			},
+		},
+	})
+}
@@ -0,0 +1,177 @@
+// Package store keeps the bot's per-guild configuration and moderation
+// records in an embedded SQLite database, so saving one guild no longer
+// rewrites every other guild's settings.
+package store
+
+import (
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+
+	_ "modernc.org/sqlite"
+)
+
+// ErrTokenClaimed is returned by ClaimToken when the value is already taken.
+var ErrTokenClaimed = errors.New("token value already claimed")
+
+type Store struct {
+	db *sql.DB
+}
+
+// Warning is a strike issued to a member by a moderator.
+type Warning struct {
+	GuildID     string
+	UserID      string
+	ModeratorID string
+	Reason      string
+	CreatedAt   time.Time
+}
+
+// Open opens (creating if needed) the database at path.
+func Open(path string) (*Store, error) {
+	db, err := sql.Open("sqlite", path)
+	if err != nil {
+		return nil, err
+	}
+
+	// SQLite allows one writer at a time; a single connection avoids
+	// "database is locked" errors between our own goroutines
+	db.SetMaxOpenConns(1)
+
+	_, err = db.Exec(`CREATE TABLE IF NOT EXISTS guild_configs (
+		guild_id TEXT PRIMARY KEY,
+		data     TEXT NOT NULL
+	);
+	CREATE TABLE IF NOT EXISTS warnings (
+		id           INTEGER PRIMARY KEY AUTOINCREMENT,
+		guild_id     TEXT NOT NULL,
+		user_id      TEXT NOT NULL,
+		moderator_id TEXT NOT NULL,
+		reason       TEXT NOT NULL,
+		created_at   INTEGER NOT NULL
+	);
+	CREATE INDEX IF NOT EXISTS warnings_member ON warnings (guild_id, user_id);
+	CREATE TABLE IF NOT EXISTS used_tokens (
+		guild_id TEXT NOT NULL,
+		kind     TEXT NOT NULL,
+		value    TEXT NOT NULL,
+		user_id  TEXT NOT NULL,
+		PRIMARY KEY (guild_id, kind, value)
+	)`)
+	if err != nil {
+		db.Close()
+		return nil, fmt.Errorf("creating schema: %w", err)
+	}
+	return &Store{db: db}, nil
+}
+
+func (s *Store) Close() error {
+	return s.db.Close()
+}
+
+// GuildConfigs returns every guild's stored config, keyed by guild ID.
+func (s *Store) GuildConfigs() (map[string][]byte, error) {
+	rows, err := s.db.Query(`SELECT guild_id, data FROM guild_configs`)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	configs := make(map[string][]byte)
+	for rows.Next() {
+		var guildID string
+		var data []byte
+		if err := rows.Scan(&guildID, &data); err != nil {
+			return nil, err
+		}
+		configs[guildID] = data
+	}
+	return configs, rows.Err()
+}
+
+// SaveGuildConfig stores one guild's config, replacing any earlier version.
+func (s *Store) SaveGuildConfig(guildID string, data []byte) error {
+	_, err := s.db.Exec(`INSERT INTO guild_configs (guild_id, data) VALUES (?, ?)
+		ON CONFLICT (guild_id) DO UPDATE SET data = excluded.data`, guildID, string(data))
+	return err
+}
+
+// DeleteGuildConfig removes a guild's config. Deleting one that was never
+// saved is not an error.
+func (s *Store) DeleteGuildConfig(guildID string) error {
+	_, err := s.db.Exec(`DELETE FROM guild_configs WHERE guild_id = ?`, guildID)
+	return err
+}
+
+// ImportGuildConfigs stores several configs in one transaction, so a failed
+// migration leaves the database untouched.
+func (s *Store) ImportGuildConfigs(configs map[string][]byte) error {
+	tx, err := s.db.Begin()
+	if err != nil {
+		return err
+	}
+	defer tx.Rollback()
+
+	for guildID, data := range configs {
+		_, err := tx.Exec(`INSERT INTO guild_configs (guild_id, data) VALUES (?, ?)
+			ON CONFLICT (guild_id) DO UPDATE SET data = excluded.data`, guildID, string(data))
+		if err != nil {
+			return fmt.Errorf("guild %s: %w", guildID, err)
+		}
+	}
+	return tx.Commit()
+}
+
+// AddWarning records a strike.
+func (s *Store) AddWarning(w Warning) error {
+	_, err := s.db.Exec(`INSERT INTO warnings (guild_id, user_id, moderator_id, reason, created_at) VALUES (?, ?, ?, ?, ?)`,
+		w.GuildID, w.UserID, w.ModeratorID, w.Reason, w.CreatedAt.Unix())
+	return err
+}
+
+// Warnings returns a member's strikes in one guild, oldest first.
+func (s *Store) Warnings(guildID, userID string) ([]Warning, error) {
+	rows, err := s.db.Query(`SELECT moderator_id, reason, created_at FROM warnings
+		WHERE guild_id = ? AND user_id = ? ORDER BY id`, guildID, userID)
+	if err != nil {
+		return nil, err
+	}
+	defer rows.Close()
+
+	var warnings []Warning
+	for rows.Next() {
+		w := Warning{GuildID: guildID, UserID: userID}
+		var createdAt int64
+		if err := rows.Scan(&w.ModeratorID, &w.Reason, &createdAt); err != nil {
+			return nil, err
+		}
+		w.CreatedAt = time.Unix(createdAt, 0)
+		warnings = append(warnings, w)
+	}
+	return warnings, rows.Err()
+}
+
+// ClaimToken records that a token value of the given kind, such as a subject
+// or token ID, was used in the guild. Claiming a value again succeeds only
+// for the same non-empty userID; otherwise ErrTokenClaimed is returned.
+func (s *Store) ClaimToken(guildID, kind, value, userID string) error {
+	res, err := s.db.Exec(`INSERT INTO used_tokens (guild_id, kind, value, user_id) VALUES (?, ?, ?, ?)
+		ON CONFLICT (guild_id, kind, value) DO NOTHING`, guildID, kind, value, userID)
+	if err != nil {
+		return err
+	}
+	if n, err := res.RowsAffected(); err != nil || n == 1 {
+		return err
+	}
+
+	var owner string
+	err = s.db.QueryRow(`SELECT user_id FROM used_tokens WHERE guild_id = ? AND kind = ? AND value = ?`, guildID, kind, value).Scan(&owner)
+	if err != nil {
+		return err
+	}
+	if userID == "" || owner != userID {
+		return ErrTokenClaimed
+	}
+	return nil
+}
@@ -0,0 +1,88 @@
+package store
+
+import (
+	"path/filepath"
+	"reflect"
+	"testing"
+)
+
+func openTestStore(t *testing.T) (*Store, string) {
+	t.Helper()
+	path := filepath.Join(t.TempDir(), "bot.db")
+	s, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() { s.Close() })
+	return s, path
+}
+
+func TestFreshStoreIsEmpty(t *testing.T) {
+	s, _ := openTestStore(t)
+
+	configs, err := s.GuildConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if len(configs) != 0 {
+		t.Errorf("fresh store has %d configs, want none", len(configs))
+	}
+}
+
+func TestGuildConfigRoundTrip(t *testing.T) {
+	s, path := openTestStore(t)
+
+	if err := s.SaveGuildConfig("guild-a", []byte(`{"max_attempts":3}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.SaveGuildConfig("guild-b", []byte(`{"max_attempts":1}`)); err != nil {
+		t.Fatal(err)
+	}
+	// Saving again replaces the earlier version
+	if err := s.SaveGuildConfig("guild-a", []byte(`{"max_attempts":5}`)); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteGuildConfig("guild-b"); err != nil {
+		t.Fatal(err)
+	}
+	if err := s.DeleteGuildConfig("never-saved"); err != nil {
+		t.Errorf("deleting an unsaved guild: %v", err)
+	}
+	s.Close()
+
+	// Reopening reads back what was written
+	reopened, err := Open(path)
+	if err != nil {
+		t.Fatal(err)
+	}
+	defer reopened.Close()
+
+	configs, err := reopened.GuildConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	want := map[string][]byte{"guild-a": []byte(`{"max_attempts":5}`)}
+	if !reflect.DeepEqual(configs, want) {
+		t.Errorf("configs = %q, want %q", configs, want)
+	}
+}
+
+func TestImportGuildConfigs(t *testing.T) {
+	s, _ := openTestStore(t)
+
+	imported := map[string][]byte{
+		"guild-a": []byte(`{"max_attempts":3}`),
+		"guild-b": []byte(`{}`),
+	}
+	if err := s.ImportGuildConfigs(imported); err != nil {
+		t.Fatal(err)
+	}
+
+	configs, err := s.GuildConfigs()
+	if err != nil {
+		t.Fatal(err)
+	}
+	if !reflect.DeepEqual(configs, imported) {
+		t.Errorf("configs = %q, want %q", configs, imported)
+	}
+}
@@ -0,0 +1,174 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"regexp"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How long a member has to send their student ID after their email before
+// they have to start again
+const studentIDTimeout = 10 * time.Minute
+
+// studentIDStep is a verification waiting on the member's student ID. The
+// original submission is kept so the request can carry on where it left off.
+type studentIDStep struct {
+	GuildID string
+	Email   string
+	Fields  []fieldValue
+	Message *discordgo.MessageCreate
+	timer   *time.Timer
+}
+
+var (
+	awaitingStudentID     = make(map[string]*studentIDStep)
+	awaitingStudentIDLock sync.Mutex
+)
+
+// Compiled student ID patterns, keyed by pattern
+var (
+	studentIDPatterns     = make(map[string]*regexp.Regexp)
+	studentIDPatternsLock sync.Mutex
+)
+
+func compileStudentIDPattern(pattern string) (*regexp.Regexp, error) {
+	studentIDPatternsLock.Lock()
+	defer studentIDPatternsLock.Unlock()
+
+	if re, ok := studentIDPatterns[pattern]; ok {
+		return re, nil
+	}
+	re, err := regexp.Compile(pattern)
+	if err != nil {
+		return nil, err
+	}
+	studentIDPatterns[pattern] = re
+	return re, nil
+}
+
+func hasPendingStudentID(userID string) bool {
+	awaitingStudentIDLock.Lock()
+	defer awaitingStudentIDLock.Unlock()
+	_, waiting := awaitingStudentID[userID]
+	return waiting
+}
+
+// askForStudentID holds a valid email submission back until the member sends
+// a student ID. Starting again replaces any earlier step.
+func askForStudentID(s discordSession, m *discordgo.MessageCreate, guildID, email string, fields []fieldValue) {
+	step := &studentIDStep{
+		GuildID: guildID,
+		Email:   email,
+		Fields:  fields,
+		Message: m,
+	}
+	step.timer = time.AfterFunc(studentIDTimeout, func() {
+		awaitingStudentIDLock.Lock()
+		if awaitingStudentID[m.Author.ID] == step {
+			delete(awaitingStudentID, m.Author.ID)
+		}
+		awaitingStudentIDLock.Unlock()
+		slog.Debug("Student ID step timed out", "guild_id", guildID, "user_id", m.Author.ID)
+	})
+
+	awaitingStudentIDLock.Lock()
+	if previous, ok := awaitingStudentID[m.Author.ID]; ok {
+		previous.timer.Stop()
+	}
+	awaitingStudentID[m.Author.ID] = step
+	awaitingStudentIDLock.Unlock()
+
+	replyToSubmission(s, m, fmt.Sprintf("Thanks! Please reply with your student ID to finish verifying. If you don't reply within %d minutes you'll need to send your email again.", int(studentIDTimeout/time.Minute)))
+}
+
+// handleStudentIDSubmission checks a DM'd student ID and, if it matches the
+// guild's pattern, sends the held request on to the moderators.
+func handleStudentIDSubmission(s discordSession, m *discordgo.MessageCreate) {
+	awaitingStudentIDLock.Lock()
+	step, waiting := awaitingStudentID[m.Author.ID]
+	awaitingStudentIDLock.Unlock()
+	if !waiting {
+		return
+	}
+
+	configMutex.RLock()
+	serverConfig := config.Servers[step.GuildID]
+	configMutex.RUnlock()
+
+	studentID := strings.TrimSpace(cleanSubmission(m.Content))
+	if serverConfig.StudentIDRegex != "" {
+		re, err := compileStudentIDPattern(serverConfig.StudentIDRegex)
+		if err != nil || !re.MatchString(studentID) {
+			replyToSubmission(s, m, "That doesn't look like a valid student ID. Please check it and try again.")
+			return
+		}
+	}
+
+	awaitingStudentIDLock.Lock()
+	if awaitingStudentID[m.Author.ID] != step {
+		// Timed out or replaced while we were checking
+		awaitingStudentIDLock.Unlock()
+		return
+	}
+	delete(awaitingStudentID, m.Author.ID)
+	awaitingStudentIDLock.Unlock()
+	step.timer.Stop()
+
+	fields := append(step.Fields, fieldValue{Name: "Student ID", Value: studentID})
+	submitForReview(s, step.Message, step.GuildID, serverConfig, step.Email, fields)
+}
+
+func setStudentIDPattern(s discordSession, i *discordgo.InteractionCreate) {
+	var pattern string
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		pattern = strings.TrimSpace(options[0].StringValue())
+	}
+	guildID := i.GuildID
+
+	if pattern != "" {
+		_, err := compileStudentIDPattern(pattern)
+		if err != nil {
+			s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+				Type: discordgo.InteractionResponseChannelMessageWithSource,
+				Data: &discordgo.InteractionResponseData{
+					Content: fmt.Sprintf("Invalid pattern: %s\nFor example, `^G\\d{8}$`", err),
+				},
+			})
+			return
+		}
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.StudentIDRegex = pattern
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Student ID step turned off successfully! :white_check_mark:"
+	if pattern != "" {
+		content = fmt.Sprintf("Student ID pattern set successfully! :white_check_mark: Members will be asked for a student ID matching `%s` after their email", pattern)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
@@ -0,0 +1,35 @@
+package main
+
+import (
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+var (
+	// Submissions waiting for the user's rate-limit cooldown to end, keyed
+	// by guild and user ID. Only the latest submission is kept.
+	queuedSubmissions = make(map[string]*discordgo.MessageCreate)
+	queuedLock        sync.Mutex
+)
+
+// queueSubmission stores the message to be processed later. It reports
+// whether the user already had a submission queued, in which case the old
+// one is replaced and no new timer is needed.
+func queueSubmission(guildID, userID string, m *discordgo.MessageCreate) bool {
+	queuedLock.Lock()
+	defer queuedLock.Unlock()
+
+	_, alreadyQueued := queuedSubmissions[rateLimitKey(guildID, userID)]
+	queuedSubmissions[rateLimitKey(guildID, userID)] = m
+	return alreadyQueued
+}
+
+func takeQueuedSubmission(guildID, userID string) (*discordgo.MessageCreate, bool) {
+	queuedLock.Lock()
+	defer queuedLock.Unlock()
+
+	m, exists := queuedSubmissions[rateLimitKey(guildID, userID)]
+	delete(queuedSubmissions, rateLimitKey(guildID, userID))
+	return m, exists
+}
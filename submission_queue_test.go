@@ -0,0 +1,105 @@
+package main
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestQueuedSubmission(t *testing.T) {
+	const (
+		guildID = "100000000000000024"
+		userID  = "200000000000000107"
+	)
+	useServerConfig(t, guildID, ServerConfig{
+		MemberAuditChannelID: "audit",
+		RateLimitEnabled:     true,
+		RateLimitDuration:    50 * time.Millisecond,
+		QueueRateLimited:     true,
+	})
+	rateLimitLock.Lock()
+	rateLimitMap[rateLimitKey(guildID, userID)] = time.Now()
+	rateLimitLock.Unlock()
+	t.Cleanup(func() {
+		rateLimitLock.Lock()
+		delete(rateLimitMap, rateLimitKey(guildID, userID))
+		rateLimitLock.Unlock()
+		takeQueuedSubmission(guildID, userID)
+		removePendingVerification(guildID, userID)
+	})
+
+	s := &fakeSession{}
+	for _, email := range []string{"first@uclan.ac.uk", "second@uclan.ac.uk"} {
+		processEmailVerification(s, &discordgo.MessageCreate{Message: &discordgo.Message{
+			ID:        "submission-" + email,
+			ChannelID: "dm-" + userID,
+			GuildID:   guildID,
+			Content:   email,
+			Author:    &discordgo.User{ID: userID, Username: "student"},
+		}})
+	}
+
+	auditMessages := func() int {
+		s.mu.Lock()
+		defer s.mu.Unlock()
+		count := 0
+		for _, message := range s.sent {
+			if message.ChannelID == "audit" {
+				count++
+			}
+		}
+		return count
+	}
+
+	if auditMessages() != 0 {
+		t.Fatal("submission processed during the cooldown")
+	}
+	s.mu.Lock()
+	for _, message := range s.sent {
+		if message.ChannelID == "dm-"+userID && !strings.Contains(message.Data.Content, "queued") {
+			t.Errorf("reply = %q, want it to say the submission was queued", message.Data.Content)
+		}
+	}
+	s.mu.Unlock()
+
+	deadline := time.Now().Add(time.Second)
+	for auditMessages() == 0 && time.Now().Before(deadline) {
+		time.Sleep(5 * time.Millisecond)
+	}
+	if got := auditMessages(); got != 1 {
+		t.Fatalf("got %d audit messages after the cooldown, want 1 (calls %q)", got, s.calls)
+	}
+	pending, ok := removePendingVerification(guildID, userID)
+	if !ok || pending.Email != "second@uclan.ac.uk" {
+		t.Errorf("pending request = %+v, want the latest submission", pending)
+	}
+}
+
+func TestQueueSubmissionReplaces(t *testing.T) {
+	const (
+		guildID = "100000000000000025"
+		userID  = "200000000000000108"
+	)
+	t.Cleanup(func() { takeQueuedSubmission(guildID, userID) })
+
+	first := &discordgo.MessageCreate{Message: &discordgo.Message{Content: "first"}}
+	second := &discordgo.MessageCreate{Message: &discordgo.Message{Content: "second"}}
+
+	if queueSubmission(guildID, userID, first) {
+		t.Fatal("first submission reported as already queued")
+	}
+	// The caller only starts a timer when nothing was queued yet
+	if !queueSubmission(guildID, userID, second) {
+		t.Fatal("second submission not reported as replacing the first")
+	}
+
+	queued, ok := takeQueuedSubmission(guildID, userID)
+	if !ok || queued != second {
+		t.Fatalf("queued submission = %v, want the second one", queued)
+	}
+	if _, ok := takeQueuedSubmission(guildID, userID); ok {
+		t.Errorf("submission still queued after being taken")
+	}
+}
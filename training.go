@@ -0,0 +1,159 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// trainingRequest posts a practice request to the audit channel. Its buttons
+// use training actions, so nothing happens to the applicant when moderators
+// practise on it.
+func trainingRequest(s discordSession, i *discordgo.InteractionCreate) {
+	if !isModerator(i) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "You need the Timeout Members permission to use this command.",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	// The moderator is the applicant unless they pick someone else
+	applicant := &discordgo.User{ID: interactionUserID(i)}
+	if i.Member.User != nil {
+		applicant = i.Member.User
+	}
+	configMutex.RLock()
+	serverConfig := config.Servers[i.GuildID]
+	configMutex.RUnlock()
+
+	email := exampleEmail(serverConfig)
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "user":
+			applicant = option.UserValue(sessionOf(s))
+		case "email":
+			email = option.StringValue()
+		}
+	}
+
+	auditChannelID := serverConfig.MemberAuditChannelID
+
+	if auditChannelID == "" {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Set a member audit channel before posting training requests",
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	actionRow := discordgo.ActionsRow{
+		Components: []discordgo.MessageComponent{
+			discordgo.Button{
+				Label:    "Approve",
+				Style:    discordgo.SuccessButton,
+				CustomID: encodeCustomID("trainingapprove", applicant.ID),
+			},
+			discordgo.Button{
+				Label:    "Deny",
+				Style:    discordgo.DangerButton,
+				CustomID: encodeCustomID("trainingdeny", applicant.ID),
+			},
+		},
+	}
+
+	_, err := s.ChannelMessageSendComplex(auditChannelID, &discordgo.MessageSend{
+		Content:         fmt.Sprintf("🎓 **TRAINING REQUEST** - no action will be taken\nUser %s has requested verification with email %s", applicant.Username, email),
+		Components:      []discordgo.MessageComponent{actionRow},
+		AllowedMentions: &discordgo.MessageAllowedMentions{},
+	})
+	if err != nil {
+		slog.Error("Error sending training request", "error", err)
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error posting training request: " + err.Error(),
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: fmt.Sprintf("Training request posted in <#%s> :white_check_mark:", auditChannelID),
+			Flags:   discordgo.MessageFlagsEphemeral,
+		},
+	})
+}
+
+// trainingOutcome returns the DM the moderator receives in place of the
+// applicant, and the note left on the audit message.
+func trainingOutcome(action, moderatorID, denial string) (dm string, result string, ok bool) {
+	switch action {
+	case "approve":
+		dm = "🎓 Training: this is the message the user would receive on approval:\n\n" + approvalMessage
+		result = fmt.Sprintf("🎓 Training request approved by <@%s>. No action was taken.", moderatorID)
+	case "deny":
+		dm = "🎓 Training: this is the message the user would receive on denial. In a real request they would also be kicked:\n\n" + denial
+		result = fmt.Sprintf("🎓 Training request denied by <@%s>. No one was kicked.", moderatorID)
+	default:
+		return "", "", false
+	}
+	return dm, result, true
+}
+
+func handleTrainingDecision(s discordSession, i *discordgo.InteractionCreate, action, userID string) {
+	moderatorID := interactionUserID(i)
+	slog.Debug("Processing training action", "action", action, "moderator_id", moderatorID, "user_id", userID)
+
+	configMutex.RLock()
+	denial := denialDM(config.Servers[i.GuildID])
+	configMutex.RUnlock()
+
+	dm, responseContent, ok := trainingOutcome(action, moderatorID, denial)
+	if !ok {
+		unknownContent := "Unknown action"
+		s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+			Content: &unknownContent,
+		})
+		return
+	}
+
+	// Send the DM to the moderator rather than the applicant
+	dmChannel, err := s.UserChannelCreate(moderatorID)
+	if err != nil {
+		slog.Error("Error creating DM channel", "error", err)
+	} else {
+		_, err = s.ChannelMessageSend(dmChannel.ID, dm)
+		if err != nil {
+			slog.Error("Error sending DM", "error", err)
+		}
+	}
+
+	_, err = s.ChannelMessageEditComplex(&discordgo.MessageEdit{
+		Channel:    i.ChannelID,
+		ID:         i.Message.ID,
+		Content:    &responseContent,
+		Components: &[]discordgo.MessageComponent{},
+	})
+	if err != nil {
+		slog.Error("Error editing original message", "error", err)
+	}
+
+	completionMessage := "Training action completed. Check your DMs to see what the user would have received."
+	_, err = s.InteractionResponseEdit(i.Interaction, &discordgo.WebhookEdit{
+		Content: &completionMessage,
+	})
+	if err != nil {
+		slog.Error("Error editing interaction response", "error", err)
+	}
+}
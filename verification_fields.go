@@ -0,0 +1,102 @@
+package main
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+)
+
+// VerificationField is an extra detail members must send after their email,
+// one per line, such as their name or course.
+type VerificationField struct {
+	Name    string `json:"name"`
+	Pattern string `json:"pattern"`
+	Example string `json:"example"`
+}
+
+type fieldValue struct {
+	Name  string
+	Value string
+}
+
+// cleanSubmission strips the whitespace and code formatting Discord users
+// tend to paste around their email, e.g. "`name@uclan.ac.uk`" or a
+// ``` code block.
+func cleanSubmission(content string) string {
+	content = strings.TrimSpace(content)
+	if len(content) >= 6 && strings.HasPrefix(content, "```") && strings.HasSuffix(content, "```") {
+		content = content[3 : len(content)-3]
+	} else {
+		content = strings.Trim(content, "`")
+	}
+	return strings.TrimSpace(content)
+}
+
+// splitSubmission separates the email on the first line of a DM from any
+// further lines of details. The email is lowercased so it's recorded the
+// same way however it was typed.
+func splitSubmission(content string) (string, []string) {
+	lines := strings.Split(content, "\n")
+	var details []string
+	for _, line := range lines[1:] {
+		if line = strings.TrimSpace(line); line != "" {
+			details = append(details, line)
+		}
+	}
+	email := strings.Trim(strings.TrimSpace(lines[0]), "`")
+	return strings.ToLower(email), details
+}
+
+// parseVerificationFields matches each line of details to the configured
+// field in the same position. Lines may be labelled, e.g. "Course: BSc
+// Computing", in which case the label is dropped.
+func parseVerificationFields(lines []string, fields []VerificationField) ([]fieldValue, error) {
+	if len(lines) != len(fields) {
+		return nil, fmt.Errorf("expected %d line(s) of details after your email but got %d", len(fields), len(lines))
+	}
+
+	values := make([]fieldValue, 0, len(fields))
+	for idx, field := range fields {
+		value := lines[idx]
+		if label, rest, found := strings.Cut(value, ":"); found && strings.EqualFold(strings.TrimSpace(label), field.Name) {
+			value = strings.TrimSpace(rest)
+		}
+
+		pattern := field.Pattern
+		if pattern == "" {
+			pattern = ".+"
+		}
+		fieldRegex, err := regexp.Compile("^(?:" + pattern + ")$")
+		if err != nil {
+			return nil, fmt.Errorf("the %s field is misconfigured, please contact a moderator", field.Name)
+		}
+		if !fieldRegex.MatchString(value) {
+			return nil, fmt.Errorf("your %s doesn't look right", field.Name)
+		}
+		values = append(values, fieldValue{Name: field.Name, Value: value})
+	}
+	return values, nil
+}
+
+// verificationPrompt describes the message format members should send.
+func verificationPrompt(exampleEmail string, fields []VerificationField) string {
+	var sb strings.Builder
+	sb.WriteString("Please send your details on separate lines, like this:```\n" + exampleEmail)
+	for _, field := range fields {
+		example := field.Example
+		if example == "" {
+			example = "your " + strings.ToLower(field.Name)
+		}
+		sb.WriteString(fmt.Sprintf("\n%s: %s", field.Name, example))
+	}
+	sb.WriteString("```")
+	return sb.String()
+}
+
+func formatFieldValues(values []fieldValue) string {
+	var sb strings.Builder
+	for _, value := range values {
+		sb.WriteString(fmt.Sprintf("\n%s: %s", value.Name, value.Value))
+	}
+	return sb.String()
+}
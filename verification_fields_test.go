@@ -0,0 +1,83 @@
+package main
+
+import (
+	"reflect"
+	"testing"
+)
+
+func TestParseVerificationFields(t *testing.T) {
+	fields := []VerificationField{
+		{Name: "Name"},
+		{Name: "Course", Pattern: `BSc .+|MSc .+`},
+	}
+
+	tests := []struct {
+		name    string
+		lines   []string
+		want    []fieldValue
+		wantErr bool
+	}{
+		{
+			name:  "plain values",
+			lines: []string{"Ada Lovelace", "BSc Computing"},
+			want:  []fieldValue{{Name: "Name", Value: "Ada Lovelace"}, {Name: "Course", Value: "BSc Computing"}},
+		},
+		{
+			name:  "labelled values",
+			lines: []string{"name: Ada Lovelace", "Course:MSc Cyber Security"},
+			want:  []fieldValue{{Name: "Name", Value: "Ada Lovelace"}, {Name: "Course", Value: "MSc Cyber Security"}},
+		},
+		{
+			name:  "colon in an unlabelled value",
+			lines: []string{"Ada: The Countess", "BSc Computing"},
+			want:  []fieldValue{{Name: "Name", Value: "Ada: The Countess"}, {Name: "Course", Value: "BSc Computing"}},
+		},
+		{name: "missing a line", lines: []string{"Ada Lovelace"}, wantErr: true},
+		{name: "too many lines", lines: []string{"Ada Lovelace", "BSc Computing", "extra"}, wantErr: true},
+		{name: "doesn't match the pattern", lines: []string{"Ada Lovelace", "Computing"}, wantErr: true},
+		{name: "pattern must match the whole value", lines: []string{"Ada Lovelace", "Not a BSc Computing"}, wantErr: true},
+		{name: "empty label value", lines: []string{"Name:", "BSc Computing"}, wantErr: true},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got, err := parseVerificationFields(tt.lines, fields)
+			if (err != nil) != tt.wantErr {
+				t.Fatalf("err = %v, wantErr %v", err, tt.wantErr)
+			}
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("parseVerificationFields() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+
+	_, err := parseVerificationFields([]string{"x"}, []VerificationField{{Name: "Broken", Pattern: "("}})
+	if err == nil {
+		t.Error("invalid field pattern accepted")
+	}
+}
+
+func TestCleanSubmission(t *testing.T) {
+	tests := []struct {
+		name    string
+		content string
+		want    string
+	}{
+		{name: "plain email", content: "name@uclan.ac.uk", want: "name@uclan.ac.uk"},
+		{name: "trailing newline", content: "name@uclan.ac.uk\n", want: "name@uclan.ac.uk"},
+		{name: "surrounding blank lines", content: "\n\nname@uclan.ac.uk\r\n\n", want: "name@uclan.ac.uk"},
+		{name: "inline code", content: "`name@uclan.ac.uk`", want: "name@uclan.ac.uk"},
+		{name: "code block", content: "```name@uclan.ac.uk```", want: "name@uclan.ac.uk"},
+		{name: "code block on its own lines", content: "```\nname@uclan.ac.uk\n```\n", want: "name@uclan.ac.uk"},
+		{name: "code block with details", content: "```\nname@uclan.ac.uk\nBSc Computing\n```", want: "name@uclan.ac.uk\nBSc Computing"},
+		{name: "just backticks", content: "``````", want: ""},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			if got := cleanSubmission(tt.content); got != tt.want {
+				t.Errorf("cleanSubmission(%q) = %q, want %q", tt.content, got, tt.want)
+			}
+		})
+	}
+}
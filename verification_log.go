@@ -0,0 +1,312 @@
+package main
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"log/slog"
+	"os"
+	"sort"
+	"sync"
+	"time"
+)
+
+type VerificationLogEntry struct {
+	GuildID     string    `json:"guild_id"`
+	UserID      string    `json:"user_id"`
+	Action      string    `json:"action"`
+	ModeratorID string    `json:"moderator_id"`
+	Email       string    `json:"email,omitempty"`
+	Time        time.Time `json:"time"`
+	SubmittedAt time.Time `json:"submitted_at,omitempty"`
+}
+
+type ModeratorTally struct {
+	ModeratorID string
+	Approved    int
+	Denied      int
+}
+
+var (
+	verificationLog     []VerificationLogEntry
+	verificationLogLock sync.Mutex
+)
+
+const (
+	verificationLogPath       = "./data/verification_log.jsonl"
+	legacyVerificationLogPath = "./data/verification_log.json"
+
+	// How long superseded decisions are kept, unless
+	// VERIFICATION_LOG_RETENTION says otherwise
+	defaultVerificationLogRetention = 180 * 24 * time.Hour
+	verificationLogCompactInterval  = 24 * time.Hour
+)
+
+// verificationLogRetention reads VERIFICATION_LOG_RETENTION, where 0 keeps
+// every entry.
+func verificationLogRetention() time.Duration {
+	value := os.Getenv("VERIFICATION_LOG_RETENTION")
+	if value == "" {
+		return defaultVerificationLogRetention
+	}
+	parsed, err := time.ParseDuration(value)
+	if err != nil || parsed < 0 {
+		slog.Warn("Ignoring invalid VERIFICATION_LOG_RETENTION", "value", value)
+		return defaultVerificationLogRetention
+	}
+	return parsed
+}
+
+// pruneVerificationLog drops entries older than the retention period, except
+// each member's latest one, which still says whether they're verified.
+func pruneVerificationLog(entries []VerificationLogEntry, retention time.Duration, now time.Time) []VerificationLogEntry {
+	if retention <= 0 {
+		return entries
+	}
+
+	latest := make(map[string]int)
+	for idx, entry := range entries {
+		latest[entry.GuildID+"/"+entry.UserID] = idx
+	}
+
+	cutoff := now.Add(-retention)
+	kept := make([]VerificationLogEntry, 0, len(entries))
+	for idx, entry := range entries {
+		if entry.Time.Before(cutoff) && latest[entry.GuildID+"/"+entry.UserID] != idx {
+			continue
+		}
+		kept = append(kept, entry)
+	}
+	return kept
+}
+
+// loadVerificationLog reads the log, one JSON entry per line. Logs from older
+// versions, saved as a single JSON array, are converted the first time.
+func loadVerificationLog() error {
+	file, err := os.Open(verificationLogPath)
+	if os.IsNotExist(err) {
+		return importLegacyVerificationLog()
+	}
+	if err != nil {
+		return err
+	}
+	defer file.Close()
+
+	var entries []VerificationLogEntry
+	scanner := bufio.NewScanner(file)
+	scanner.Buffer(make([]byte, 0, 64*1024), 1024*1024)
+	for scanner.Scan() {
+		line := scanner.Bytes()
+		if len(line) == 0 {
+			continue
+		}
+		var entry VerificationLogEntry
+		err := json.Unmarshal(line, &entry)
+		if err != nil {
+			// A crash mid-append can leave a partial last line
+			slog.Warn("Skipping unreadable verification log entry", "error", err)
+			continue
+		}
+		entries = append(entries, entry)
+	}
+	if err := scanner.Err(); err != nil {
+		return err
+	}
+
+	verificationLogLock.Lock()
+	verificationLog = entries
+	verificationLogLock.Unlock()
+	return compactVerificationLog()
+}
+
+func importLegacyVerificationLog() error {
+	data, err := os.ReadFile(legacyVerificationLogPath)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	if err != nil {
+		return err
+	}
+
+	var entries []VerificationLogEntry
+	err = json.Unmarshal(data, &entries)
+	if err != nil {
+		return err
+	}
+
+	verificationLogLock.Lock()
+	verificationLog = entries
+	verificationLogLock.Unlock()
+	return compactVerificationLog()
+}
+
+// compactVerificationLog applies the retention period and rewrites the log
+// with only the entries kept.
+func compactVerificationLog() error {
+	verificationLogLock.Lock()
+	defer verificationLogLock.Unlock()
+
+	verificationLog = pruneVerificationLog(verificationLog, verificationLogRetention(), time.Now())
+
+	var buf bytes.Buffer
+	encoder := json.NewEncoder(&buf)
+	for _, entry := range verificationLog {
+		err := encoder.Encode(entry)
+		if err != nil {
+			return err
+		}
+	}
+
+	err := os.MkdirAll("./data", os.ModePerm)
+	if err != nil {
+		return err
+	}
+	return writeFileAtomic(verificationLogPath, buf.Bytes(), 0644)
+}
+
+func runVerificationLogCompaction() {
+	ticker := time.NewTicker(verificationLogCompactInterval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		err := compactVerificationLog()
+		if err != nil {
+			slog.Error("Error compacting verification log", "error", err)
+		}
+	}
+}
+
+// appendVerificationLog adds the entry to the end of the log file, so a
+// decision doesn't rewrite the whole history.
+func appendVerificationLog(entry VerificationLogEntry) error {
+	line, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+
+	verificationLogLock.Lock()
+	defer verificationLogLock.Unlock()
+
+	err = os.MkdirAll("./data", os.ModePerm)
+	if err != nil {
+		return err
+	}
+	file, err := os.OpenFile(verificationLogPath, os.O_APPEND|os.O_CREATE|os.O_WRONLY, 0644)
+	if err != nil {
+		return err
+	}
+	_, err = file.Write(append(line, '\n'))
+	if closeErr := file.Close(); err == nil {
+		err = closeErr
+	}
+	if err != nil {
+		return err
+	}
+
+	verificationLog = append(verificationLog, entry)
+	return nil
+}
+
+// recordDecision saves a verification outcome and announces any
+// verified-member milestone it reaches.
+func recordDecision(s discordSession, entry VerificationLogEntry) {
+	switch entry.Action {
+	case "approve":
+		incrementMetric("verifications_approved_total", entry.GuildID)
+	case "deny":
+		incrementMetric("verifications_denied_total", entry.GuildID)
+	}
+	sendAuditEvent(auditEvent{
+		GuildID:     entry.GuildID,
+		UserID:      entry.UserID,
+		Action:      entry.Action,
+		ModeratorID: entry.ModeratorID,
+		Timestamp:   entry.Time,
+	})
+
+	verificationLogLock.Lock()
+	before := countVerified(verificationLog, entry.GuildID)
+	verificationLogLock.Unlock()
+
+	err := appendVerificationLog(entry)
+	if err != nil {
+		slog.Error("Error saving verification log", "error", err)
+		return
+	}
+
+	if entry.Action == "approve" {
+		verificationLogLock.Lock()
+		after := countVerified(verificationLog, entry.GuildID)
+		verificationLogLock.Unlock()
+
+		announceMilestone(s, entry.GuildID, before, after)
+	}
+}
+
+// countVerified returns how many distinct members of a guild have been
+// approved.
+func countVerified(entries []VerificationLogEntry, guildID string) int {
+	approved := make(map[string]bool)
+	for _, entry := range entries {
+		if entry.GuildID == guildID && entry.Action == "approve" {
+			approved[entry.UserID] = true
+		}
+	}
+	return len(approved)
+}
+
+// tallyModerators counts the approvals and denials each moderator made in a
+// guild since the given time, busiest moderator first.
+func tallyModerators(entries []VerificationLogEntry, guildID string, since time.Time) []ModeratorTally {
+	counts := make(map[string]*ModeratorTally)
+	for _, entry := range entries {
+		if entry.GuildID != guildID || entry.ModeratorID == "" || entry.Time.Before(since) {
+			continue
+		}
+		tally, exists := counts[entry.ModeratorID]
+		if !exists {
+			tally = &ModeratorTally{ModeratorID: entry.ModeratorID}
+			counts[entry.ModeratorID] = tally
+		}
+		switch entry.Action {
+		case "approve":
+			tally.Approved++
+		case "deny":
+			tally.Denied++
+		}
+	}
+
+	tallies := make([]ModeratorTally, 0, len(counts))
+	for _, tally := range counts {
+		tallies = append(tallies, *tally)
+	}
+	sort.Slice(tallies, func(a, b int) bool {
+		totalA := tallies[a].Approved + tallies[a].Denied
+		totalB := tallies[b].Approved + tallies[b].Denied
+		if totalA != totalB {
+			return totalA > totalB
+		}
+		return tallies[a].ModeratorID < tallies[b].ModeratorID
+	})
+	return tallies
+}
+
+// averageTurnaround is the mean time between submission and decision over a
+// guild's most recent decisions, or zero if there is no history yet.
+func averageTurnaround(entries []VerificationLogEntry, guildID string, limit int) time.Duration {
+	var total time.Duration
+	count := 0
+	for idx := len(entries) - 1; idx >= 0 && count < limit; idx-- {
+		entry := entries[idx]
+		if entry.GuildID != guildID || entry.SubmittedAt.IsZero() {
+			continue
+		}
+		total += entry.Time.Sub(entry.SubmittedAt)
+		count++
+	}
+
+	if count == 0 {
+		return 0
+	}
+	return total / time.Duration(count)
+}
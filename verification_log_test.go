@@ -0,0 +1,181 @@
+package main
+
+import (
+	"encoding/json"
+	"os"
+	"reflect"
+	"testing"
+	"time"
+)
+
+func TestTallyModerators(t *testing.T) {
+	since := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	after := since.Add(time.Hour)
+
+	tests := []struct {
+		name    string
+		entries []VerificationLogEntry
+		want    []ModeratorTally
+	}{
+		{
+			name: "no decisions",
+			want: []ModeratorTally{},
+		},
+		{
+			name: "busiest moderator first",
+			entries: []VerificationLogEntry{
+				{GuildID: "guild", ModeratorID: "a", Action: "approve", Time: after},
+				{GuildID: "guild", ModeratorID: "b", Action: "approve", Time: after},
+				{GuildID: "guild", ModeratorID: "b", Action: "deny", Time: after},
+			},
+			want: []ModeratorTally{
+				{ModeratorID: "b", Approved: 1, Denied: 1},
+				{ModeratorID: "a", Approved: 1},
+			},
+		},
+		{
+			name: "ties sorted by ID",
+			entries: []VerificationLogEntry{
+				{GuildID: "guild", ModeratorID: "b", Action: "deny", Time: after},
+				{GuildID: "guild", ModeratorID: "a", Action: "deny", Time: after},
+			},
+			want: []ModeratorTally{
+				{ModeratorID: "a", Denied: 1},
+				{ModeratorID: "b", Denied: 1},
+			},
+		},
+		{
+			name: "other guilds, old and automatic decisions skipped",
+			entries: []VerificationLogEntry{
+				{GuildID: "other", ModeratorID: "a", Action: "approve", Time: after},
+				{GuildID: "guild", ModeratorID: "a", Action: "approve", Time: since.Add(-time.Second)},
+				{GuildID: "guild", Action: "approve", Time: after},
+				{GuildID: "guild", ModeratorID: "a", Action: "approve", Time: since},
+			},
+			want: []ModeratorTally{
+				{ModeratorID: "a", Approved: 1},
+			},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := tallyModerators(tt.entries, "guild", since)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("tallyModerators() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestPruneVerificationLog(t *testing.T) {
+	now := time.Date(2026, 9, 21, 12, 0, 0, 0, time.UTC)
+	old := now.Add(-200 * 24 * time.Hour)
+	recent := now.Add(-24 * time.Hour)
+
+	entries := []VerificationLogEntry{
+		{GuildID: "guild", UserID: "denied-then-approved", Action: "deny", Time: old},
+		{GuildID: "guild", UserID: "approved-long-ago", Action: "approve", Time: old},
+		{GuildID: "guild", UserID: "denied-then-approved", Action: "approve", Time: old.Add(time.Hour)},
+		{GuildID: "other", UserID: "approved-long-ago", Action: "deny", Time: old},
+		{GuildID: "guild", UserID: "recent", Action: "deny", Time: recent},
+		{GuildID: "guild", UserID: "recent", Action: "approve", Time: recent},
+	}
+
+	tests := []struct {
+		name      string
+		retention time.Duration
+		want      []VerificationLogEntry
+	}{
+		{name: "retention disabled", want: entries},
+		{
+			name:      "old history dropped",
+			retention: 180 * 24 * time.Hour,
+			want:      []VerificationLogEntry{entries[1], entries[2], entries[3], entries[4], entries[5]},
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			got := pruneVerificationLog(entries, tt.retention, now)
+			if !reflect.DeepEqual(got, tt.want) {
+				t.Errorf("pruneVerificationLog() = %+v, want %+v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestVerificationLogPersistence(t *testing.T) {
+	verificationLogLock.Lock()
+	saved := verificationLog
+	verificationLogLock.Unlock()
+	t.Cleanup(func() {
+		verificationLogLock.Lock()
+		verificationLog = saved
+		verificationLogLock.Unlock()
+	})
+
+	entry := VerificationLogEntry{GuildID: "100000000000000011", UserID: "200000000000000071", Action: "approve", Time: time.Now().UTC().Truncate(time.Second)}
+	if err := appendVerificationLog(entry); err != nil {
+		t.Fatal(err)
+	}
+
+	verificationLogLock.Lock()
+	verificationLog = nil
+	verificationLogLock.Unlock()
+	if err := loadVerificationLog(); err != nil {
+		t.Fatal(err)
+	}
+
+	verificationLogLock.Lock()
+	defer verificationLogLock.Unlock()
+	if len(verificationLog) == 0 || !reflect.DeepEqual(verificationLog[len(verificationLog)-1], entry) {
+		t.Errorf("last entry after reloading = %+v, want %+v", verificationLog, entry)
+	}
+}
+
+func TestImportLegacyVerificationLog(t *testing.T) {
+	verificationLogLock.Lock()
+	saved := verificationLog
+	verificationLogLock.Unlock()
+
+	current, err := os.ReadFile(verificationLogPath)
+	if err != nil && !os.IsNotExist(err) {
+		t.Fatal(err)
+	}
+	t.Cleanup(func() {
+		os.Remove(legacyVerificationLogPath)
+		if current != nil {
+			os.WriteFile(verificationLogPath, current, 0644)
+		}
+		verificationLogLock.Lock()
+		verificationLog = saved
+		verificationLogLock.Unlock()
+	})
+
+	legacy := []VerificationLogEntry{{GuildID: "100000000000000012", UserID: "200000000000000072", Action: "approve", Time: time.Now().UTC().Truncate(time.Second)}}
+	data, err := json.Marshal(legacy)
+	if err != nil {
+		t.Fatal(err)
+	}
+	if err := os.MkdirAll("./data", os.ModePerm); err != nil {
+		t.Fatal(err)
+	}
+	if err := os.WriteFile(legacyVerificationLogPath, data, 0644); err != nil {
+		t.Fatal(err)
+	}
+	os.Remove(verificationLogPath)
+
+	if err := loadVerificationLog(); err != nil {
+		t.Fatal(err)
+	}
+	verificationLogLock.Lock()
+	got := verificationLog
+	verificationLogLock.Unlock()
+	if !reflect.DeepEqual(got, legacy) {
+		t.Errorf("imported log = %+v, want %+v", got, legacy)
+	}
+	if _, err := os.Stat(verificationLogPath); err != nil {
+		t.Errorf("log wasn't converted to JSON lines: %v", err)
+	}
+}
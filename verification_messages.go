@@ -0,0 +1,179 @@
+package main
+
+import (
+	"log/slog"
+	"maps"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// A verify message with at least this many messages after it is treated as
+// scrolled away and re-posted at the bottom of the channel
+const verificationMessageScrollLimit = 50
+
+// recordVerificationMessage stores which message holds the verify button in
+// a channel, or forgets the channel when messageID is empty
+func recordVerificationMessage(guildID, channelID, messageID string) error {
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	messages := maps.Clone(serverConfig.VerificationMessages)
+	if messages == nil {
+		messages = make(map[string]string)
+	}
+	if messageID == "" {
+		delete(messages, channelID)
+	} else {
+		messages[channelID] = messageID
+	}
+	serverConfig.VerificationMessages = messages
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	return saveConfig(guildID)
+}
+
+func repostVerificationMessage(s discordSession, guildID, channelID string) {
+	logger := slog.With("guild_id", guildID, "channel_id", channelID)
+
+	msg, err := s.ChannelMessageSendComplex(channelID, verifyButtonMessage())
+	if err != nil {
+		logger.Error("Error re-posting verification message", "error", err)
+		return
+	}
+	err = recordVerificationMessage(guildID, channelID, msg.ID)
+	if err != nil {
+		logger.Error("Error saving config", "error", err)
+		return
+	}
+	logger.Info("Re-posted verification message")
+}
+
+// ensureVerificationMessages re-posts any of the guild's verify messages that
+// were deleted while the bot was offline or have scrolled out of view
+func ensureVerificationMessages(s discordSession, guildID string) {
+	configMutex.RLock()
+	messages := maps.Clone(config.Servers[guildID].VerificationMessages)
+	configMutex.RUnlock()
+
+	for channelID, messageID := range messages {
+		logger := slog.With("guild_id", guildID, "channel_id", channelID)
+
+		_, err := s.ChannelMessage(channelID, messageID)
+		if err != nil && !isNotFound(err) {
+			logger.Warn("Error checking verification message", "error", err)
+			continue
+		}
+		if err == nil {
+			newer, err := s.ChannelMessages(channelID, verificationMessageScrollLimit, "", messageID, "")
+			if err != nil {
+				logger.Warn("Error checking verification message", "error", err)
+				continue
+			}
+			if len(newer) < verificationMessageScrollLimit {
+				continue
+			}
+			err = s.ChannelMessageDelete(channelID, messageID)
+			if err != nil && !isNotFound(err) {
+				logger.Warn("Error removing old verification message", "error", err)
+			}
+		}
+		repostVerificationMessage(s, guildID, channelID)
+	}
+}
+
+// verificationMessageDeleted puts a verify message back as soon as someone
+// deletes it
+func verificationMessageDeleted(s discordSession, m *discordgo.MessageDelete) {
+	if m.GuildID == "" {
+		return
+	}
+
+	configMutex.RLock()
+	messageID, tracked := config.Servers[m.GuildID].VerificationMessages[m.ChannelID]
+	configMutex.RUnlock()
+
+	if tracked && messageID == m.ID {
+		repostVerificationMessage(s, m.GuildID, m.ChannelID)
+	}
+}
+
+func setupVerificationMessage(s discordSession, i *discordgo.InteractionCreate) {
+	channelID := i.ApplicationCommandData().Options[0].ChannelValue(sessionOf(s)).ID
+	guildID := i.GuildID
+
+	msg, err := s.ChannelMessageSendComplex(channelID, verifyButtonMessage())
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error posting the verification message in that channel: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	// Replace any earlier message in the same channel
+	configMutex.RLock()
+	previousID := config.Servers[guildID].VerificationMessages[channelID]
+	configMutex.RUnlock()
+
+	err = recordVerificationMessage(guildID, channelID, msg.ID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+	if previousID != "" {
+		s.ChannelMessageDelete(channelID, previousID)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Verification message posted successfully! :white_check_mark: It will be re-posted in <#" + channelID + "> if it's deleted or scrolls away.",
+		},
+	})
+}
+
+func removeVerificationMessage(s discordSession, i *discordgo.InteractionCreate) {
+	channelID := i.ApplicationCommandData().Options[0].ChannelValue(sessionOf(s)).ID
+	guildID := i.GuildID
+
+	configMutex.RLock()
+	messageID, tracked := config.Servers[guildID].VerificationMessages[channelID]
+	configMutex.RUnlock()
+
+	if !tracked {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "There's no verification message in <#" + channelID + ">",
+			},
+		})
+		return
+	}
+
+	// Forget the message first so deleting it doesn't trigger a re-post
+	err := recordVerificationMessage(guildID, channelID, "")
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+	s.ChannelMessageDelete(channelID, messageID)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Verification message removed successfully! :white_check_mark:",
+		},
+	})
+}
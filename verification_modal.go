@@ -0,0 +1,188 @@
+package main
+
+import (
+	"log/slog"
+	"strings"
+	"sync"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Submissions that came from the verification modal rather than a DM, keyed
+// by the synthetic message ID. Replies to these go back to the member as
+// ephemeral follow-ups, since they may have DMs disabled.
+var (
+	modalSubmissions     = make(map[string]*discordgo.Interaction)
+	modalSubmissionsLock sync.Mutex
+)
+
+// replyToSubmission answers a verification submission wherever it came from.
+func replyToSubmission(s discordSession, m *discordgo.MessageCreate, content string) {
+	modalSubmissionsLock.Lock()
+	interaction, fromModal := modalSubmissions[m.ID]
+	modalSubmissionsLock.Unlock()
+
+	var err error
+	if fromModal {
+		_, err = s.FollowupMessageCreate(interaction, true, &discordgo.WebhookParams{
+			Content: content,
+			Flags:   discordgo.MessageFlagsEphemeral,
+		})
+	} else {
+		_, err = s.ChannelMessageSend(m.ChannelID, content)
+	}
+	if err != nil {
+		slog.Error("Error replying to verification submission", "user_id", m.Author.ID, "error", err)
+	}
+}
+
+func verifyButtonMessage() *discordgo.MessageSend {
+	return &discordgo.MessageSend{
+		Content: "Click below to verify your university email and get access to the server.",
+		Components: []discordgo.MessageComponent{
+			discordgo.ActionsRow{
+				Components: []discordgo.MessageComponent{
+					discordgo.Button{
+						Label:    "Verify",
+						Style:    discordgo.PrimaryButton,
+						CustomID: "verifymodal",
+					},
+				},
+			},
+		},
+	}
+}
+
+func openVerificationModal(s discordSession, i *discordgo.InteractionCreate) {
+	configMutex.RLock()
+	serverConfig := config.Servers[i.GuildID]
+	configMutex.RUnlock()
+
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseModal,
+		Data: &discordgo.InteractionResponseData{
+			CustomID: "verifymodal",
+			Title:    "Verify your email",
+			Components: []discordgo.MessageComponent{
+				discordgo.ActionsRow{
+					Components: []discordgo.MessageComponent{
+						discordgo.TextInput{
+							CustomID:    "email",
+							Label:       "University email",
+							Style:       discordgo.TextInputShort,
+							Placeholder: exampleEmail(serverConfig),
+							Required:    true,
+							MaxLength:   254,
+						},
+					},
+				},
+			},
+		},
+	})
+	if err != nil {
+		slog.Error("Error opening verification modal", "guild_id", i.GuildID, "error", err)
+	}
+}
+
+func modalEmail(data discordgo.ModalSubmitInteractionData) string {
+	for _, row := range data.Components {
+		actionsRow, ok := row.(*discordgo.ActionsRow)
+		if !ok {
+			continue
+		}
+		for _, component := range actionsRow.Components {
+			if input, ok := component.(*discordgo.TextInput); ok && input.CustomID == "email" {
+				return strings.TrimSpace(input.Value)
+			}
+		}
+	}
+	return ""
+}
+
+// handleVerificationModal feeds a modal submission into the same flow as an
+// emailed DM.
+func handleVerificationModal(s discordSession, i *discordgo.InteractionCreate) {
+	err := s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseDeferredChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Flags: discordgo.MessageFlagsEphemeral,
+		},
+	})
+	if err != nil {
+		slog.Error("Error acknowledging interaction", "error", err)
+		return
+	}
+
+	user := i.Member.User
+	m := &discordgo.MessageCreate{
+		Message: &discordgo.Message{
+			ID:      "modal:" + i.ID,
+			GuildID: i.GuildID,
+			Author:  user,
+			Content: modalEmail(i.ModalSubmitData()),
+		},
+	}
+
+	// Replies to queued submissions can outlive the interaction, so fall back
+	// to a DM where possible
+	if dmChannel, err := s.UserChannelCreate(user.ID); err == nil {
+		m.ChannelID = dmChannel.ID
+	}
+
+	modalSubmissionsLock.Lock()
+	modalSubmissions[m.ID] = i.Interaction
+	modalSubmissionsLock.Unlock()
+
+	processEmailVerification(s, m)
+
+	modalSubmissionsLock.Lock()
+	delete(modalSubmissions, m.ID)
+	modalSubmissionsLock.Unlock()
+
+	// Remove the "thinking" placeholder now the replies have been sent
+	err = s.InteractionResponseDelete(i.Interaction)
+	if err != nil {
+		slog.Error("Error removing deferred response", "error", err)
+	}
+}
+
+func setVerificationChannel(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	channelID := options[0].ChannelValue(sessionOf(s)).ID
+	guildID := i.GuildID
+
+	_, err := s.ChannelMessageSendComplex(channelID, verifyButtonMessage())
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error posting the verify button in that channel: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.VerificationChannelID = channelID
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err = saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: "Verification channel set successfully! :white_check_mark: Members can now verify with the button in <#" + channelID + ">",
+		},
+	})
+}
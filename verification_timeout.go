@@ -0,0 +1,204 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"os"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// How often guilds are scanned for members who never verified, unless
+// VERIFICATION_SCAN_INTERVAL says otherwise
+const defaultVerificationScanInterval = 10 * time.Minute
+
+// verificationExpired reports whether the member has sat unverified for
+// longer than the timeout. Members who have picked up any other role are left
+// alone in case a moderator is dealing with them.
+func verificationExpired(member *discordgo.Member, unverifiedRoleID string, timeout time.Duration, now time.Time) bool {
+	if len(member.Roles) != 1 || member.Roles[0] != unverifiedRoleID {
+		return false
+	}
+	return !member.JoinedAt.IsZero() && now.Sub(member.JoinedAt) > timeout
+}
+
+func kickExpiredMembers(s discordSession, guildID string, serverConfig ServerConfig) {
+	members, err := fetchAllMembers(s, guildID)
+	if err != nil {
+		slog.Error("Error fetching members", "guild_id", guildID, "error", err)
+		return
+	}
+
+	kickUnverifiedMembers(s, guildID, serverConfig, members, time.Now())
+}
+
+// kickUnverifiedMembers kicks the members who are past the guild's
+// verification timeout, sending each the auto-kick message first.
+func kickUnverifiedMembers(s discordSession, guildID string, serverConfig ServerConfig, members []*discordgo.Member, now time.Time) {
+	throttle := newBulkThrottle(guildBulkPolicy(guildID))
+	for _, member := range members {
+		if member.User.Bot || !verificationExpired(member, serverConfig.UnverifiedRoleID, serverConfig.VerificationTimeout, now) {
+			continue
+		}
+		logger := slog.With("guild_id", guildID, "user_id", member.User.ID)
+
+		// Members waiting on a moderator did verify in time
+		if hasPendingVerification(guildID, member.User.ID) {
+			logger.Debug("Not kicking member with a pending request")
+			continue
+		}
+
+		// Let them know why before they lose access to the server
+		if err := sendDM(s, member.User.ID, autoKickDM(serverConfig)); err != nil {
+			logger.Error("Error sending DM", "error", err)
+		}
+
+		err := withRetry(func() error { return memberKick(s, guildID, member.User.ID) })
+		throttle.done()
+		if err != nil {
+			logger.Error("Error kicking unverified member", "error", err)
+			continue
+		}
+		logger.Info("Kicked member who did not verify in time")
+
+		resetDenialRetries(guildID, member.User.ID)
+		recordDecision(s, VerificationLogEntry{
+			GuildID: guildID,
+			UserID:  member.User.ID,
+			Action:  "timeout",
+			Time:    now,
+		})
+	}
+}
+
+// timeoutScanDue picks the guilds the auto-kick should scan: those with a
+// timeout and an unverified role set, and the auto-kick not paused.
+func timeoutScanDue(servers map[string]ServerConfig) map[string]ServerConfig {
+	due := make(map[string]ServerConfig)
+	for guildID, serverConfig := range servers {
+		if serverConfig.VerificationTimeout > 0 && serverConfig.UnverifiedRoleID != "" && !serverConfig.AutoKickPaused {
+			due[guildID] = serverConfig
+		}
+	}
+	return due
+}
+
+func runVerificationTimeouts(s discordSession) {
+	interval := defaultVerificationScanInterval
+	if value := os.Getenv("VERIFICATION_SCAN_INTERVAL"); value != "" {
+		parsed, err := time.ParseDuration(value)
+		if err != nil || parsed <= 0 {
+			slog.Warn("Ignoring invalid VERIFICATION_SCAN_INTERVAL", "value", value)
+		} else {
+			interval = parsed
+		}
+	}
+
+	ticker := time.NewTicker(interval)
+	defer ticker.Stop()
+
+	for range ticker.C {
+		configMutex.RLock()
+		due := timeoutScanDue(config.Servers)
+		configMutex.RUnlock()
+
+		for guildID, serverConfig := range due {
+			kickExpiredMembers(s, guildID, serverConfig)
+		}
+	}
+}
+
+func setVerificationTimeout(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	hours := options[0].IntValue()
+	guildID := i.GuildID
+
+	if hours < 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The timeout cannot be negative",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.VerificationTimeout = time.Duration(hours) * time.Hour
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Verification timeout disabled successfully! :white_check_mark:"
+	if hours > 0 {
+		content = fmt.Sprintf("Members who haven't verified within %d hours will be kicked! :white_check_mark:", hours)
+		if serverConfig.UnverifiedRoleID == "" {
+			content += "\nNote: no unverified role is set, so nobody will be kicked until you run /set_unverified_role"
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+// pauseAutoKick stops the auto-kick in a guild, e.g. during freshers' week
+// when joins spike, without clearing the configured timeout.
+func pauseAutoKick(s discordSession, i *discordgo.InteractionCreate) {
+	setAutoKickPaused(s, i, true)
+}
+
+func resumeAutoKick(s discordSession, i *discordgo.InteractionCreate) {
+	setAutoKickPaused(s, i, false)
+}
+
+func setAutoKickPaused(s discordSession, i *discordgo.InteractionCreate, paused bool) {
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.AutoKickPaused = paused
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Auto-kick paused successfully! :white_check_mark:\nThe verification timeout is kept; run /resume_auto_kick to start kicking again."
+	if !paused {
+		content = fmt.Sprintf("Auto-kick resumed successfully! :white_check_mark:\nMembers who haven't verified within %s will be kicked on the next scan.", serverConfig.VerificationTimeout)
+		if serverConfig.VerificationTimeout == 0 {
+			content = "Auto-kick resumed successfully! :white_check_mark:\nNote: no verification timeout is set, so nobody will be kicked until you run /set_verification_timeout"
+		}
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
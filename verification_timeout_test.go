@@ -0,0 +1,101 @@
+package main
+
+import (
+	"slices"
+	"testing"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestVerificationExpired(t *testing.T) {
+	now := time.Date(2026, 9, 21, 12, 0, 0, 0, time.UTC)
+
+	tests := []struct {
+		name     string
+		roles    []string
+		joinedAt time.Time
+		want     bool
+	}{
+		{name: "past the timeout", roles: []string{"unverified"}, joinedAt: now.Add(-49 * time.Hour), want: true},
+		{name: "within the timeout", roles: []string{"unverified"}, joinedAt: now.Add(-47 * time.Hour)},
+		{name: "picked up another role", roles: []string{"unverified", "guest"}, joinedAt: now.Add(-49 * time.Hour)},
+		{name: "already verified", roles: []string{"verified"}, joinedAt: now.Add(-49 * time.Hour)},
+		{name: "unknown join time", roles: []string{"unverified"}},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			member := &discordgo.Member{Roles: tt.roles, JoinedAt: tt.joinedAt}
+			if got := verificationExpired(member, "unverified", 48*time.Hour, now); got != tt.want {
+				t.Errorf("verificationExpired() = %v, want %v", got, tt.want)
+			}
+		})
+	}
+}
+
+func TestTimeoutScanDue(t *testing.T) {
+	servers := map[string]ServerConfig{
+		"running":  {VerificationTimeout: 48 * time.Hour, UnverifiedRoleID: "unverified"},
+		"paused":   {VerificationTimeout: 48 * time.Hour, UnverifiedRoleID: "unverified", AutoKickPaused: true},
+		"no role":  {VerificationTimeout: 48 * time.Hour},
+		"disabled": {UnverifiedRoleID: "unverified"},
+	}
+
+	due := timeoutScanDue(servers)
+	if got := sortedKeys(due); !slices.Equal(got, []string{"running"}) {
+		t.Fatalf("due = %v, want [running]", got)
+	}
+
+	// Resuming picks the guild straight back up with its timeout intact
+	resumed := servers["paused"]
+	resumed.AutoKickPaused = false
+	servers["paused"] = resumed
+
+	due = timeoutScanDue(servers)
+	if got := sortedKeys(due); !slices.Equal(got, []string{"paused", "running"}) {
+		t.Fatalf("due after resuming = %v, want [paused running]", got)
+	}
+	if due["paused"].VerificationTimeout != 48*time.Hour {
+		t.Errorf("timeout after resuming = %s, want 48h", due["paused"].VerificationTimeout)
+	}
+}
+
+func sortedKeys(m map[string]ServerConfig) []string {
+	keys := make([]string, 0, len(m))
+	for key := range m {
+		keys = append(keys, key)
+	}
+	slices.Sort(keys)
+	return keys
+}
+
+func TestKickUnverifiedMembersSkipsPending(t *testing.T) {
+	const (
+		guildID      = "100000000000000018"
+		pendingID    = "200000000000000098"
+		unverifiedID = "200000000000000099"
+	)
+	serverConfig := ServerConfig{
+		UnverifiedRoleID:    "unverified",
+		VerificationTimeout: 48 * time.Hour,
+	}
+	useServerConfig(t, guildID, serverConfig)
+
+	addPendingVerification(guildID, pendingID, "student@uclan.ac.uk")
+	t.Cleanup(func() { removePendingVerification(guildID, pendingID) })
+
+	now := time.Now()
+	s := &fakeSession{}
+	kickUnverifiedMembers(s, guildID, serverConfig, []*discordgo.Member{
+		{User: &discordgo.User{ID: pendingID}, Roles: []string{"unverified"}, JoinedAt: now.Add(-72 * time.Hour)},
+		{User: &discordgo.User{ID: unverifiedID}, Roles: []string{"unverified"}, JoinedAt: now.Add(-72 * time.Hour)},
+	}, now)
+
+	if s.called("GuildMemberDelete "+guildID+" "+pendingID) || s.called("UserChannelCreate "+pendingID) {
+		t.Errorf("member with a pending request was kicked (calls %q)", s.calls)
+	}
+	if !s.called("GuildMemberDelete " + guildID + " " + unverifiedID) {
+		t.Errorf("unverified member not kicked (calls %q)", s.calls)
+	}
+}
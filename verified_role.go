@@ -0,0 +1,62 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// grantVerifiedRole gives an approved member the guild's verified role, for
+// servers that gate channels on it rather than on the unverified role. It
+// does nothing when no verified role is set.
+func grantVerifiedRole(s discordSession, guildID, userID string) {
+	configMutex.RLock()
+	verifiedRoleID := config.Servers[guildID].VerifiedRoleID
+	configMutex.RUnlock()
+
+	if verifiedRoleID == "" {
+		return
+	}
+	err := withRetry(func() error { return memberRoleAdd(s, guildID, userID, verifiedRoleID) })
+	if err != nil {
+		slog.Error("Error adding verified role", "guild_id", guildID, "user_id", userID, "error", err)
+	}
+}
+
+func setVerifiedRole(s discordSession, i *discordgo.InteractionCreate) {
+	var roleID string
+	if options := i.ApplicationCommandData().Options; len(options) > 0 {
+		roleID = options[0].RoleValue(sessionOf(s), i.GuildID).ID
+	}
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.VerifiedRoleID = roleID
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Verified role cleared successfully! :white_check_mark:"
+	if roleID != "" {
+		content = fmt.Sprintf("Verified role set successfully! :white_check_mark: <@&%s>", roleID)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
@@ -0,0 +1,90 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// verifyUser lets a moderator verify someone by hand, e.g. when the member's
+// DMs are closed and they can never start the flow.
+func verifyUser(s discordSession, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	if !isModerator(i) {
+		respond("You need the Timeout Members permission to use this command.")
+		return
+	}
+
+	options := i.ApplicationCommandData().Options
+	target := options[0].UserValue(sessionOf(s))
+	guildID := i.GuildID
+	moderatorID := interactionUserID(i)
+
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	if serverConfig.UnverifiedRoleID == "" {
+		respond("No unverified role is set for this server, so there's nothing to remove. Use /set_unverified_role first.")
+		return
+	}
+
+	member, err := s.GuildMember(guildID, target.ID)
+	if err != nil {
+		respond("That user isn't a member of this server.")
+		return
+	}
+	if !memberHasRole(member, serverConfig.UnverifiedRoleID) {
+		respond(fmt.Sprintf("<@%s> is already verified.", target.ID))
+		return
+	}
+
+	err = withRetry(func() error { return memberRoleRemove(s, guildID, target.ID, serverConfig.UnverifiedRoleID) })
+	if err != nil {
+		slog.Error("Error removing unverified role", "error", err)
+		respond("Error removing the unverified role: " + err.Error())
+		return
+	}
+	grantVerifiedRole(s, guildID, target.ID)
+
+	// The DM is best effort since closed DMs are the usual reason for this
+	if dmChannel, err := s.UserChannelCreate(target.ID); err == nil {
+		_, err = s.ChannelMessageSend(dmChannel.ID, approvalMessage)
+		if err != nil {
+			slog.Error("Error sending DM", "error", err)
+		}
+	}
+
+	resetDenialRetries(guildID, target.ID)
+	pending, _ := removePendingVerification(guildID, target.ID)
+	recordDecision(s, VerificationLogEntry{
+		GuildID:     guildID,
+		UserID:      target.ID,
+		Action:      "approve",
+		ModeratorID: moderatorID,
+		Email:       pending.Email,
+		Time:        time.Now(),
+		SubmittedAt: pending.SubmittedAt,
+	})
+
+	if serverConfig.MemberAuditChannelID != "" {
+		_, err = s.ChannelMessageSend(serverConfig.MemberAuditChannelID, fmt.Sprintf("<@%s> was verified manually by <@%s>.", target.ID, moderatorID))
+		if err != nil {
+			slog.Error("Error posting manual verification to audit channel", "error", err)
+		}
+	}
+
+	welcomeMember(s, guildID, target.ID)
+	respond(fmt.Sprintf("<@%s> has been verified successfully! :white_check_mark:", target.ID))
+}
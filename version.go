@@ -0,0 +1,46 @@
+package main
+
+import (
+	"fmt"
+	"runtime"
+	"runtime/debug"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Set at build time with:
+//
+//	go build -ldflags "-X main.version=v1.2.0 -X main.commit=$(git rev-parse --short HEAD)"
+var (
+	version = "dev"
+	commit  = ""
+)
+
+// buildCommit falls back to the revision Go embeds when building from a git
+// checkout, so plain `go build` binaries still report a commit.
+func buildCommit() string {
+	if commit != "" {
+		return commit
+	}
+	if info, ok := debug.ReadBuildInfo(); ok {
+		for _, setting := range info.Settings {
+			if setting.Key == "vcs.revision" && len(setting.Value) >= 7 {
+				return setting.Value[:7]
+			}
+		}
+	}
+	return "unknown"
+}
+
+func versionString(version, commit, goVersion string) string {
+	return fmt.Sprintf("computing-society-mod-bot %s (commit %s, %s)", version, commit, goVersion)
+}
+
+func showVersion(s discordSession, i *discordgo.InteractionCreate) {
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: versionString(version, buildCommit(), runtime.Version()),
+		},
+	})
+}
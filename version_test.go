@@ -0,0 +1,52 @@
+package main
+
+import (
+	"runtime"
+	"strings"
+	"testing"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+func TestVersionString(t *testing.T) {
+	got := versionString("v1.2.0", "abc1234", "go1.23.2")
+	if want := "computing-society-mod-bot v1.2.0 (commit abc1234, go1.23.2)"; got != want {
+		t.Errorf("versionString() = %q, want %q", got, want)
+	}
+}
+
+func TestShowVersion(t *testing.T) {
+	previousVersion, previousCommit := version, commit
+	version, commit = "v1.2.0", "abc1234"
+	t.Cleanup(func() { version, commit = previousVersion, previousCommit })
+
+	s := &fakeSession{}
+	showVersion(s, &discordgo.InteractionCreate{Interaction: &discordgo.Interaction{
+		Type: discordgo.InteractionApplicationCommand,
+		Data: discordgo.ApplicationCommandInteractionData{Name: "version"},
+	}})
+
+	if len(s.responses) != 1 {
+		t.Fatalf("got %d responses, want 1", len(s.responses))
+	}
+	content := s.responses[0].Data.Content
+	if !strings.Contains(content, "v1.2.0 (commit abc1234, "+runtime.Version()+")") {
+		t.Errorf("response = %q, want the build's version, commit and Go version", content)
+	}
+}
+
+func TestBuildCommitPrefersLinkerFlag(t *testing.T) {
+	previous := commit
+	t.Cleanup(func() { commit = previous })
+
+	commit = "abc1234"
+	if got := buildCommit(); got != "abc1234" {
+		t.Errorf("buildCommit() = %q, want the -X value", got)
+	}
+
+	// Without it there is still something to report
+	commit = ""
+	if got := buildCommit(); got == "" {
+		t.Errorf("buildCommit() = %q, want a fallback", got)
+	}
+}
@@ -0,0 +1,193 @@
+package main
+
+import (
+	"fmt"
+	"log/slog"
+	"strings"
+	"time"
+
+	"github.com/bwmarrin/discordgo"
+	"github.com/ldavidson8/computing-society-mod-bot/store"
+)
+
+// Hides moderation commands from members who can't time others out
+var moderatePermission int64 = discordgo.PermissionModerateMembers
+
+func isModerator(i *discordgo.InteractionCreate) bool {
+	return i.Member != nil && i.Member.Permissions&(discordgo.PermissionModerateMembers|discordgo.PermissionAdministrator) != 0
+}
+
+// Discord won't time anyone out for longer than this
+const maxWarnTimeout = 28 * 24 * time.Hour
+
+// warnTimeoutDue reports whether a new strike takes the member past the
+// guild's threshold
+func warnTimeoutDue(serverConfig ServerConfig, strikes int) bool {
+	return serverConfig.WarnThreshold > 0 && serverConfig.WarnTimeout > 0 && strikes >= serverConfig.WarnThreshold
+}
+
+func warnUser(s discordSession, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	if !isModerator(i) {
+		respond("You need the Timeout Members permission to use this command.")
+		return
+	}
+
+	var target *discordgo.User
+	var reason string
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "user":
+			target = option.UserValue(sessionOf(s))
+		case "reason":
+			reason = option.StringValue()
+		}
+	}
+	guildID := i.GuildID
+	moderatorID := interactionUserID(i)
+
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+	cooldownWarning := modActionCooldownWarning(guildID, target.ID, serverConfig.ModActionCooldown, time.Now())
+
+	err := configStore.AddWarning(store.Warning{
+		GuildID:     guildID,
+		UserID:      target.ID,
+		ModeratorID: moderatorID,
+		Reason:      reason,
+		CreatedAt:   time.Now(),
+	})
+	if err != nil {
+		slog.Error("Error saving warning", "guild_id", guildID, "user_id", target.ID, "error", err)
+		respond("Error saving warning: " + err.Error())
+		return
+	}
+
+	warnings, err := configStore.Warnings(guildID, target.ID)
+	if err != nil {
+		slog.Error("Error loading warnings", "guild_id", guildID, "user_id", target.ID, "error", err)
+	}
+
+	// The DM is best effort; the strike is recorded either way
+	guildName := guildID
+	if guild, err := stateOf(s).Guild(guildID); err == nil {
+		guildName = guild.Name
+	}
+	if dmChannel, err := s.UserChannelCreate(target.ID); err == nil {
+		_, err = s.ChannelMessageSend(dmChannel.ID, fmt.Sprintf("You have received a warning in %s: %s", guildName, reason))
+		if err != nil {
+			slog.Error("Error sending DM", "error", err)
+		}
+	}
+
+	recordModAction(guildID, target.ID, time.Now())
+
+	content := fmt.Sprintf("<@%s> has been warned (%d total) :white_check_mark:", target.ID, len(warnings))
+	if warnTimeoutDue(serverConfig, len(warnings)) {
+		until := time.Now().Add(serverConfig.WarnTimeout)
+		err = s.GuildMemberTimeout(guildID, target.ID, &until)
+		if err != nil {
+			slog.Error("Error timing out member", "guild_id", guildID, "user_id", target.ID, "error", err)
+			content += "\nThey've passed the warning threshold, but the timeout failed: " + err.Error()
+		} else {
+			content += fmt.Sprintf("\nThey've passed the warning threshold and have been timed out until <t:%d:f>.", until.Unix())
+		}
+	}
+
+	respond(content + cooldownWarning)
+}
+
+func listWarnings(s discordSession, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content:         content,
+				Flags:           discordgo.MessageFlagsEphemeral,
+				AllowedMentions: &discordgo.MessageAllowedMentions{},
+			},
+		})
+	}
+
+	if !isModerator(i) {
+		respond("You need the Timeout Members permission to use this command.")
+		return
+	}
+
+	target := i.ApplicationCommandData().Options[0].UserValue(sessionOf(s))
+	warnings, err := configStore.Warnings(i.GuildID, target.ID)
+	if err != nil {
+		slog.Error("Error loading warnings", "guild_id", i.GuildID, "user_id", target.ID, "error", err)
+		respond("Error loading warnings: " + err.Error())
+		return
+	}
+
+	if len(warnings) == 0 {
+		respond(fmt.Sprintf("<@%s> has no warnings.", target.ID))
+		return
+	}
+
+	var b strings.Builder
+	fmt.Fprintf(&b, "<@%s> has %d warning(s):", target.ID, len(warnings))
+	for n, w := range warnings {
+		fmt.Fprintf(&b, "\n%d. <t:%d:f> by <@%s>: %s", n+1, w.CreatedAt.Unix(), w.ModeratorID, w.Reason)
+	}
+	respond(b.String())
+}
+
+func setWarnThreshold(s discordSession, i *discordgo.InteractionCreate) {
+	options := i.ApplicationCommandData().Options
+	threshold := options[0].IntValue()
+	minutes := options[1].IntValue()
+	guildID := i.GuildID
+
+	if threshold < 0 || minutes < 0 {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "The threshold and timeout cannot be negative",
+			},
+		})
+		return
+	}
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.WarnThreshold = int(threshold)
+	serverConfig.WarnTimeout = time.Duration(minutes) * time.Minute
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Automatic timeouts for warnings disabled successfully! :white_check_mark:"
+	if warnTimeoutDue(serverConfig, int(threshold)) {
+		content = fmt.Sprintf("Members with %d or more warnings will be timed out for %d minutes! :white_check_mark:", threshold, minutes)
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
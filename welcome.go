@@ -0,0 +1,216 @@
+package main
+
+import (
+	"errors"
+	"fmt"
+	"log/slog"
+	"strings"
+
+	"github.com/bwmarrin/discordgo"
+)
+
+// Sent to new members when a guild hasn't set its own welcome message
+const defaultWelcomeMessage = "Welcome! Please provide your university email for verification. For example:```example@{email_domain}```"
+
+// welcomeDM fills in the guild's welcome message placeholders.
+func welcomeDM(serverConfig ServerConfig, serverName string) string {
+	message := serverConfig.WelcomeMessage
+	if message == "" {
+		message = defaultWelcomeMessage
+	}
+	return strings.NewReplacer(
+		"{email_domain}", guildEmailDomains(serverConfig)[0],
+		"{server_name}", serverName,
+	).Replace(message)
+}
+
+// welcomeTarget decides where a newly approved member is greeted. Members
+// sitting in voice are moved to the welcome voice channel; everyone else is
+// tagged in the welcome text channel if one is set.
+func welcomeTarget(serverConfig ServerConfig, inVoice bool) (moveTo, greetIn string) {
+	if inVoice && serverConfig.WelcomeVoiceChannelID != "" {
+		return serverConfig.WelcomeVoiceChannelID, ""
+	}
+	return "", serverConfig.WelcomeChannelID
+}
+
+func welcomeMember(s discordSession, guildID, userID string) {
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	if serverConfig.WelcomeVoiceChannelID == "" && serverConfig.WelcomeChannelID == "" {
+		return
+	}
+
+	// Voice states come from the gateway cache, so a missing entry just means
+	// they aren't connected
+	voiceState, err := stateOf(s).VoiceState(guildID, userID)
+	inVoice := err == nil && voiceState.ChannelID != ""
+
+	moveTo, greetIn := welcomeTarget(serverConfig, inVoice)
+	if moveTo != "" {
+		err := s.GuildMemberMove(guildID, userID, &moveTo)
+		if err == nil {
+			return
+		}
+		slog.Error("Error moving user to welcome voice channel", "user_id", userID, "error", err)
+		// Fall back to the text greeting
+		greetIn = serverConfig.WelcomeChannelID
+	}
+	if greetIn == "" {
+		return
+	}
+
+	_, err = s.ChannelMessageSend(greetIn, fmt.Sprintf("Welcome <@%s>! 👋", userID))
+	if err != nil {
+		slog.Error("Error sending welcome message", "error", err)
+	}
+}
+
+func setWelcomeChannels(s discordSession, i *discordgo.InteractionCreate) {
+	var voiceChannelID, textChannelID string
+	for _, option := range i.ApplicationCommandData().Options {
+		switch option.Name {
+		case "voice_channel":
+			voiceChannelID = option.ChannelValue(sessionOf(s)).ID
+		case "text_channel":
+			textChannelID = option.ChannelValue(sessionOf(s)).ID
+		}
+	}
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.WelcomeVoiceChannelID = voiceChannelID
+	serverConfig.WelcomeChannelID = textChannelID
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	content := "Welcome channels set successfully! :white_check_mark:"
+	if voiceChannelID == "" && textChannelID == "" {
+		content = "Welcome channels cleared successfully! :white_check_mark:"
+	}
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+func setWelcomeMessage(s discordSession, i *discordgo.InteractionCreate) {
+	var message string
+	for _, option := range i.ApplicationCommandData().Options {
+		if option.Name == "message" {
+			message = strings.TrimSpace(option.StringValue())
+		}
+	}
+	guildID := i.GuildID
+
+	configMutex.Lock()
+	serverConfig := getOrCreateServerConfigLocked(guildID)
+	serverConfig.WelcomeMessage = message
+	config.Servers[guildID] = serverConfig
+	configMutex.Unlock()
+
+	err := saveConfig(guildID)
+	if err != nil {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: "Error saving config: " + err.Error(),
+			},
+		})
+		return
+	}
+
+	serverName := guildID
+	if guild, err := stateOf(s).Guild(guildID); err == nil {
+		serverName = guild.Name
+	}
+
+	content := "Welcome message reset to the default successfully! :white_check_mark:"
+	if message != "" {
+		content = "Welcome message set successfully! :white_check_mark:"
+	}
+	content += " New members will see:\n" + welcomeDM(serverConfig, serverName)
+
+	s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+		Type: discordgo.InteractionResponseChannelMessageWithSource,
+		Data: &discordgo.InteractionResponseData{
+			Content: content,
+		},
+	})
+}
+
+// sendWelcomeDM sends the verification prompt a member gets on joining.
+func sendWelcomeDM(s discordSession, guildID, userID string, serverConfig ServerConfig) error {
+	welcome := &discordgo.MessageSend{}
+
+	// Offer a shortcut to members already verified in the partner server
+	if serverConfig.PartnerGuildID != "" {
+		partnerName := "our partner server"
+		if partner, err := stateOf(s).Guild(serverConfig.PartnerGuildID); err == nil {
+			partnerName = partner.Name
+		}
+		welcome.Components = []discordgo.MessageComponent{partnerButton(guildID, partnerName)}
+	}
+
+	serverName := guildID
+	if guild, err := stateOf(s).Guild(guildID); err == nil {
+		serverName = guild.Name
+	}
+	welcome.Content = welcomeDM(serverConfig, serverName)
+
+	return sendDMComplex(s, userID, welcome)
+}
+
+// resendWelcome re-sends the verification prompt to a member who had DMs
+// closed when they joined.
+func resendWelcome(s discordSession, i *discordgo.InteractionCreate) {
+	respond := func(content string) {
+		s.InteractionRespond(i.Interaction, &discordgo.InteractionResponse{
+			Type: discordgo.InteractionResponseChannelMessageWithSource,
+			Data: &discordgo.InteractionResponseData{
+				Content: content,
+				Flags:   discordgo.MessageFlagsEphemeral,
+			},
+		})
+	}
+
+	if !isModerator(i) {
+		respond("You need the Timeout Members permission to use this command.")
+		return
+	}
+
+	target := i.ApplicationCommandData().Options[0].UserValue(sessionOf(s))
+	guildID := i.GuildID
+
+	configMutex.RLock()
+	serverConfig := config.Servers[guildID]
+	configMutex.RUnlock()
+
+	err := sendWelcomeDM(s, guildID, target.ID, serverConfig)
+	switch {
+	case errors.Is(err, errDMsClosed):
+		respond(fmt.Sprintf("<@%s> still has DMs blocked, so you'll need to reach them another way.", target.ID))
+	case err != nil:
+		slog.Error("Error resending welcome DM", "guild_id", guildID, "user_id", target.ID, "error", err)
+		respond("Error sending the welcome message: " + err.Error())
+	default:
+		respond(fmt.Sprintf("Welcome message sent to <@%s> :white_check_mark:", target.ID))
+	}
+}
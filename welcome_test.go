@@ -0,0 +1,29 @@
+package main
+
+import "testing"
+
+func TestWelcomeTarget(t *testing.T) {
+	tests := []struct {
+		name        string
+		voice, text string
+		inVoice     bool
+		wantMoveTo  string
+		wantGreetIn string
+	}{
+		{name: "nothing set", inVoice: true},
+		{name: "in voice", voice: "voice", text: "text", inVoice: true, wantMoveTo: "voice"},
+		{name: "not in voice", voice: "voice", text: "text", wantGreetIn: "text"},
+		{name: "in voice without a welcome voice channel", text: "text", inVoice: true, wantGreetIn: "text"},
+		{name: "only a voice channel", voice: "voice"},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			serverConfig := ServerConfig{WelcomeVoiceChannelID: tt.voice, WelcomeChannelID: tt.text}
+			moveTo, greetIn := welcomeTarget(serverConfig, tt.inVoice)
+			if moveTo != tt.wantMoveTo || greetIn != tt.wantGreetIn {
+				t.Errorf("welcomeTarget() = %q, %q, want %q, %q", moveTo, greetIn, tt.wantMoveTo, tt.wantGreetIn)
+			}
+		})
+	}
+}